@@ -1,8 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -13,64 +24,160 @@ import (
 	webflag "github.com/prometheus/exporter-toolkit/web/kingpinflag"
 
 	"github.com/vexxhost/openstack_database_exporter/internal/collector"
+	"github.com/vexxhost/openstack_database_exporter/internal/config"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
+	"github.com/vexxhost/openstack_database_exporter/internal/redact"
 )
 
 var (
 	metricsPath = kingpin.Flag(
 		"web.telemetry-path",
-		"Path under which to expose metrics.",
+		"Path under which to expose metrics. The metrics of a single service are served below it, e.g. at /metrics/nova, or selected with collect[] parameters.",
 	).Default("/metrics").String()
 	toolkitFlags = webflag.AddFlags(kingpin.CommandLine, ":9180")
+	configFile   = kingpin.Flag(
+		"config.file",
+		"Path to a YAML configuration file. Its settings take precedence over the flags and are re-read on SIGHUP or a POST to /-/reload. Its relabel rules sum the series merged by drop_labels, keep_labels and max_series, which only suits count-like metrics, not state gauges such as nova_server_status.",
+	).Envar("CONFIG_FILE").String()
+	configCheckInterval = kingpin.Flag(
+		"config.check-interval",
+		"Interval at which the configuration file and the service config and password files are checked for changes, reloading the configuration when they change (0 disables).",
+	).Default("30s").Duration()
+	shutdownTimeout = kingpin.Flag(
+		"web.shutdown-timeout",
+		"Maximum time to wait for in-flight scrapes on SIGINT or SIGTERM before cancelling them and closing the database connections.",
+	).Default("30s").Duration()
+
+	// Scrape flags
+	scrapeTimeout = kingpin.Flag(
+		"scrape.timeout",
+		"Maximum duration of a scrape. The Prometheus scrape timeout header takes precedence when shorter; 0 disables the limit.",
+	).Default("0s").Envar("SCRAPE_TIMEOUT").Duration()
+	scrapeTimeoutOffset = kingpin.Flag(
+		"scrape.timeout-offset",
+		"Offset to subtract from the Prometheus scrape timeout header to leave room for encoding the response.",
+	).Default("250ms").Envar("SCRAPE_TIMEOUT_OFFSET").Duration()
+	scrapeParallelism = kingpin.Flag(
+		"scrape.parallelism",
+		"Maximum number of collectors running concurrently during a scrape (0 for unlimited). Each service is additionally limited to its connection pool size.",
+	).Default("4").Envar("SCRAPE_PARALLELISM").Int()
+	scrapeSnapshot = kingpin.Flag(
+		"scrape.snapshot",
+		"Run the queries of each service in a read-only transaction with a consistent snapshot, so that its metrics agree with each other. The collectors of a service then run one at a time.",
+	).Default("false").Envar("SCRAPE_SNAPSHOT").Bool()
+	pollInterval = kingpin.Flag(
+		"poll.interval",
+		"Refresh every service in the background at this interval and serve scrapes from the cached snapshot (0 collects on every scrape).",
+	).Default("0s").Envar("POLL_INTERVAL").Duration()
+	servicePollIntervals = kingpin.Flag(
+		"poll.service-interval",
+		"Per-service background refresh interval as <service>=<duration>, overriding --poll.interval. Repeatable.",
+	).PlaceHolder("SERVICE=DURATION").StringMap()
+
+	// Connection pool flags
+	dbMaxOpenConns = kingpin.Flag(
+		"db.max-open-conns",
+		"Maximum number of open connections to each database.",
+	).Default("5").Envar("DB_MAX_OPEN_CONNS").Int()
+	dbMaxIdleConns = kingpin.Flag(
+		"db.max-idle-conns",
+		"Maximum number of idle connections kept to each database.",
+	).Default("2").Envar("DB_MAX_IDLE_CONNS").Int()
+	dbConnMaxLifetime = kingpin.Flag(
+		"db.conn-max-lifetime",
+		"Maximum amount of time a database connection may be reused (0 for unlimited, or 1m for database URLs listing several databases).",
+	).Default("0s").Envar("DB_CONN_MAX_LIFETIME").Duration()
+	dbConnMaxIdleTime = kingpin.Flag(
+		"db.conn-max-idle-time",
+		"Maximum amount of time a database connection may be idle (0 for unlimited).",
+	).Default("0s").Envar("DB_CONN_MAX_IDLE_TIME").Duration()
+	serviceMaxOpenConns = kingpin.Flag(
+		"db.service-max-open-conns",
+		"Per-service maximum number of open connections as <service>=<count>, overriding --db.max-open-conns. Repeatable.",
+	).PlaceHolder("SERVICE=COUNT").StringMap()
+	serviceMaxIdleConns = kingpin.Flag(
+		"db.service-max-idle-conns",
+		"Per-service maximum number of idle connections as <service>=<count>, overriding --db.max-idle-conns. Repeatable.",
+	).PlaceHolder("SERVICE=COUNT").StringMap()
+	serviceConnMaxLifetime = kingpin.Flag(
+		"db.service-conn-max-lifetime",
+		"Per-service maximum connection lifetime as <service>=<duration>, overriding --db.conn-max-lifetime. Repeatable.",
+	).PlaceHolder("SERVICE=DURATION").StringMap()
+	serviceConnMaxIdleTime = kingpin.Flag(
+		"db.service-conn-max-idle-time",
+		"Per-service maximum connection idle time as <service>=<duration>, overriding --db.conn-max-idle-time. Repeatable.",
+	).PlaceHolder("SERVICE=DURATION").StringMap()
+
+	dbMaxReplicationLag = kingpin.Flag(
+		"db.max-replication-lag",
+		"Skip replicas listed before the primary in a database URL while their replication lag exceeds this duration (0 disables the check). Requires the REPLICATION CLIENT privilege.",
+	).Default("0s").Envar("DB_MAX_REPLICATION_LAG").Duration()
+	dbMaxWsrepRecvQueue = kingpin.Flag(
+		"db.max-wsrep-recv-queue",
+		"Skip Galera nodes listed before the last one in a database URL while their receive queue is longer than this, if --db.max-replication-lag is set.",
+	).Default("16").Envar("DB_MAX_WSREP_RECV_QUEUE").Int()
+
+	// Collector flags
+	disableDefaultCollectors = kingpin.Flag(
+		"collector.disable-defaults",
+		"Set all collectors to disabled by default.",
+	).Default("false").Bool()
+	collectorAggregate = kingpin.Flag(
+		"collector.aggregate",
+		"Replace the per-object metrics of cinder volumes, nova servers and neutron floating IPs with per-project aggregates counted by the database.",
+	).Default("false").Envar("COLLECTOR_AGGREGATE").Bool()
+	collectorFlags = addCollectorFlags(kingpin.CommandLine)
 
 	// Database connection flags
 	cinderDatabaseURL = kingpin.Flag(
 		"cinder.database-url",
-		"Cinder database connection URL (oslo.db format)",
+		"Cinder database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("CINDER_DATABASE_URL").String()
 	glanceDatabaseURL = kingpin.Flag(
 		"glance.database-url",
-		"Glance database connection URL (oslo.db format)",
+		"Glance database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("GLANCE_DATABASE_URL").String()
 	heatDatabaseURL = kingpin.Flag(
 		"heat.database-url",
-		"Heat database connection URL (oslo.db format)",
+		"Heat database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("HEAT_DATABASE_URL").String()
 	ironicDatabaseURL = kingpin.Flag(
 		"ironic.database-url",
-		"Ironic database connection URL (oslo.db format)",
+		"Ironic database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("IRONIC_DATABASE_URL").String()
 	keystoneDatabaseURL = kingpin.Flag(
 		"keystone.database-url",
-		"Keystone database connection URL (oslo.db format)",
+		"Keystone database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("KEYSTONE_DATABASE_URL").String()
 	magnumDatabaseURL = kingpin.Flag(
 		"magnum.database-url",
-		"Magnum database connection URL (oslo.db format)",
+		"Magnum database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("MAGNUM_DATABASE_URL").String()
 	manilaDatabaseURL = kingpin.Flag(
 		"manila.database-url",
-		"Manila database connection URL (oslo.db format)",
+		"Manila database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("MANILA_DATABASE_URL").String()
 	neutronDatabaseURL = kingpin.Flag(
 		"neutron.database-url",
-		"Neutron database connection URL (oslo.db format)",
+		"Neutron database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("NEUTRON_DATABASE_URL").String()
 	octaviaDatabaseURL = kingpin.Flag(
 		"octavia.database-url",
-		"Octavia database connection URL (oslo.db format)",
+		"Octavia database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("OCTAVIA_DATABASE_URL").String()
 	placementDatabaseURL = kingpin.Flag(
 		"placement.database-url",
-		"Placement database connection URL (oslo.db format)",
+		"Placement database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("PLACEMENT_DATABASE_URL").String()
 	novaDatabaseURL = kingpin.Flag(
 		"nova.database-url",
-		"Nova database connection URL (oslo.db format)",
+		"Nova database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("NOVA_DATABASE_URL").String()
 	novaAPIDatabaseURL = kingpin.Flag(
 		"nova-api.database-url",
-		"Nova API database connection URL (oslo.db format)",
+		"Nova API database connection URL (oslo.db format), or a comma-separated list tried in order",
 	).Envar("NOVA_API_DATABASE_URL").String()
+	databaseSources = addDatabaseSourceFlags(kingpin.CommandLine)
 	projectCacheTTL = kingpin.Flag(
 		"project-cache-ttl",
 		"TTL for the keystone project name cache (default 5m).",
@@ -85,12 +192,36 @@ func main() {
 	kingpin.HelpFlag.Short('h')
 	kingpin.Parse()
 
-	logger := promslog.New(promslogConfig)
+	// Database URLs end up in error messages; keep their passwords out of
+	// the logs.
+	logger := slog.New(redact.NewHandler(promslog.New(promslogConfig).Handler()))
 
 	logger.Info("Starting openstack_database_exporter", "version", version.Info())
 	logger.Info("Build context", "build_context", version.BuildContext())
 
-	reg := collector.NewRegistry(collector.Config{
+	pollIntervals := make(map[string]time.Duration, len(*servicePollIntervals))
+	for service, value := range *servicePollIntervals {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			logger.Error("Invalid --poll.service-interval", "service", service, "err", err)
+			os.Exit(1)
+		}
+		pollIntervals[service] = d
+	}
+
+	pool := db.PoolConfig{
+		MaxOpenConns:    *dbMaxOpenConns,
+		MaxIdleConns:    *dbMaxIdleConns,
+		ConnMaxLifetime: *dbConnMaxLifetime,
+		ConnMaxIdleTime: *dbConnMaxIdleTime,
+	}
+	servicePools, err := parseServicePools(pool)
+	if err != nil {
+		logger.Error("Invalid connection pool flag", "err", err)
+		os.Exit(1)
+	}
+
+	flagConfig := collector.Config{
 		CinderDatabaseURL:    *cinderDatabaseURL,
 		GlanceDatabaseURL:    *glanceDatabaseURL,
 		HeatDatabaseURL:      *heatDatabaseURL,
@@ -104,9 +235,71 @@ func main() {
 		NovaDatabaseURL:      *novaDatabaseURL,
 		NovaAPIDatabaseURL:   *novaAPIDatabaseURL,
 		ProjectCacheTTL:      *projectCacheTTL,
-	}, logger)
+		Parallelism:          *scrapeParallelism,
+		Snapshot:             *scrapeSnapshot,
+		PollInterval:         *pollInterval,
+		ServicePollIntervals: pollIntervals,
+		ScrapeTimeout:        *scrapeTimeout,
+		ScrapeTimeoutOffset:  *scrapeTimeoutOffset,
+		Pool:                 pool,
+		ServicePools:         servicePools,
+		Replication: db.ReplicationConfig{
+			MaxLag:       *dbMaxReplicationLag,
+			MaxRecvQueue: *dbMaxWsrepRecvQueue,
+		},
+		Collectors:               collectorFlags(),
+		DisableDefaultCollectors: *disableDefaultCollectors,
+		Aggregate:                *collectorAggregate,
+	}
+	sources := databaseSources()
+	loadConfig := func() (collector.Config, error) {
+		return config.LoadWithDatabaseSources(*configFile, flagConfig, sources)
+	}
+
+	reloader, err := collector.NewReloader(loadConfig, promhttp.HandlerOpts{}, logger)
+	if err != nil {
+		logger.Error("Error loading configuration", "err", err)
+		os.Exit(1)
+	}
 
-	http.Handle(*metricsPath, promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := reloader.Reload(); err != nil {
+				logger.Error("Failed to reload configuration", "err", err)
+			}
+		}
+	}()
+
+	var watched []string
+	if *configFile != "" {
+		watched = append(watched, *configFile)
+	}
+	for _, service := range slices.Sorted(maps.Keys(sources)) {
+		watched = append(watched, sources[service].Files()...)
+	}
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	if len(watched) > 0 && *configCheckInterval > 0 {
+		go config.Watch(watchCtx, watched, *configCheckInterval, func() {
+			logger.Info("Configuration files changed, reloading")
+			if err := reloader.Reload(); err != nil {
+				logger.Error("Failed to reload configuration", "err", err)
+			}
+		})
+	}
+
+	http.Handle(*metricsPath, reloader)
+	if servicePath := strings.TrimSuffix(*metricsPath, "/") + "/"; servicePath != "/" {
+		http.Handle(servicePath, reloader.ServiceHandler(servicePath))
+	}
+	http.Handle("/-/reload", reloader.ReloadHandler())
+	http.Handle("/probe", reloader.ProbeHandler())
+	http.HandleFunc("/-/healthy", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintln(w, "Healthy")
+	})
+	http.Handle("/-/ready", reloader.ReadyHandler())
 	if *metricsPath != "/" && *metricsPath != "" {
 		landingPage, err := web.NewLandingPage(web.LandingConfig{
 			Name:        "OpenStack Database Exporter",
@@ -115,6 +308,7 @@ func main() {
 			Profiling:   "false",
 			Links: []web.LandingLinks{
 				{Address: *metricsPath, Text: "Metrics"},
+				{Address: "/-/ready", Text: "Readiness"},
 			},
 		})
 		if err != nil {
@@ -125,8 +319,166 @@ func main() {
 	}
 
 	srv := &http.Server{}
-	if err := web.ListenAndServe(srv, toolkitFlags, logger); err != nil {
+	served := make(chan error, 1)
+	go func() {
+		served <- web.ListenAndServe(srv, toolkitFlags, logger)
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-served:
 		logger.Error("Error starting HTTP server", "err", err)
+		_ = reloader.Close()
 		os.Exit(1)
+	case sig := <-stop:
+		logger.Info("Shutting down", "signal", sig.String(), "timeout", *shutdownTimeout)
+	}
+	stopWatching()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	// The listener is closed right away, while the reloader answers the
+	// requests of open connections with 503 and waits for the scrapes in
+	// flight before closing the connection pools.
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Warn("HTTP server did not shut down cleanly", "err", err)
+		}
+	})
+	if err := reloader.Shutdown(ctx); err != nil {
+		logger.Warn("Scrapes were cancelled on shutdown", "err", err)
+	}
+	wg.Wait()
+
+	logger.Info("Shut down")
+}
+
+// addDatabaseSourceFlags adds the --<service>.config-file and
+// --<service>.database-password-file flags. The returned function reports the
+// sources set on the command line, keyed by service name.
+func addDatabaseSourceFlags(app *kingpin.Application) func() map[string]config.DatabaseSource {
+	configFiles := make(map[string]*string)
+	passwordFiles := make(map[string]*string)
+
+	for _, service := range []string{"cinder", "glance", "heat", "ironic", "keystone", "magnum", "manila", "neutron", "nova", "nova_api", "octavia", "placement"} {
+		prefix := strings.ReplaceAll(service, "_", "-")
+		envPrefix := strings.ToUpper(service)
+		configFiles[service] = app.Flag(
+			prefix+".config-file",
+			fmt.Sprintf("Path to an oslo.config file whose [%s] connection option is used when neither --%s.database-url nor the configuration file sets the database URL.", config.DatabaseSection(service), prefix),
+		).Envar(envPrefix + "_CONFIG_FILE").String()
+		passwordFiles[service] = app.Flag(
+			prefix+".database-password-file",
+			fmt.Sprintf("Path to a file holding the password of the %s database URL, including one set by the configuration file.", prefix),
+		).Envar(envPrefix + "_DATABASE_PASSWORD_FILE").String()
+	}
+
+	return func() map[string]config.DatabaseSource {
+		sources := make(map[string]config.DatabaseSource)
+		for service, configFile := range configFiles {
+			if *configFile == "" && *passwordFiles[service] == "" {
+				continue
+			}
+			sources[service] = config.DatabaseSource{
+				ConfigFile:   *configFile,
+				Section:      config.DatabaseSection(service),
+				PasswordFile: *passwordFiles[service],
+			}
+		}
+		return sources
+	}
+}
+
+// parseServicePools merges the per-service connection pool flags into a pool
+// setting per service, inheriting the values not set from global.
+func parseServicePools(global db.PoolConfig) (map[string]db.PoolConfig, error) {
+	pools := make(map[string]db.PoolConfig)
+	service := func(name string) (db.PoolConfig, error) {
+		if _, ok := collector.Collectors[name]; !ok {
+			return db.PoolConfig{}, fmt.Errorf("unknown service %q", name)
+		}
+		if pool, ok := pools[name]; ok {
+			return pool, nil
+		}
+		return global, nil
+	}
+
+	for name, value := range *serviceMaxOpenConns {
+		pool, err := service(name)
+		if err != nil {
+			return nil, err
+		}
+		if pool.MaxOpenConns, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("--db.service-max-open-conns for %s: %w", name, err)
+		}
+		pools[name] = pool
+	}
+	for name, value := range *serviceMaxIdleConns {
+		pool, err := service(name)
+		if err != nil {
+			return nil, err
+		}
+		if pool.MaxIdleConns, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("--db.service-max-idle-conns for %s: %w", name, err)
+		}
+		pools[name] = pool
+	}
+	for name, value := range *serviceConnMaxLifetime {
+		pool, err := service(name)
+		if err != nil {
+			return nil, err
+		}
+		if pool.ConnMaxLifetime, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("--db.service-conn-max-lifetime for %s: %w", name, err)
+		}
+		pools[name] = pool
+	}
+	for name, value := range *serviceConnMaxIdleTime {
+		pool, err := service(name)
+		if err != nil {
+			return nil, err
+		}
+		if pool.ConnMaxIdleTime, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("--db.service-conn-max-idle-time for %s: %w", name, err)
+		}
+		pools[name] = pool
+	}
+	return pools, nil
+}
+
+// addCollectorFlags adds a --collector.<service>.<name> flag for every
+// collector. The returned function reports the collectors enabled or
+// disabled on the command line, keyed by "<service>.<name>".
+func addCollectorFlags(app *kingpin.Application) func() map[string]bool {
+	flags := make(map[string]*bool)
+	set := make(map[string]bool)
+
+	for _, service := range slices.Sorted(maps.Keys(collector.Collectors)) {
+		for _, name := range collector.Collectors[service] {
+			key := service + "." + name
+			enabled := !collector.DefaultDisabledCollectors[key]
+			defaultState := "enabled"
+			if !enabled {
+				defaultState = "disabled"
+			}
+			flags[key] = app.Flag(
+				"collector."+key,
+				fmt.Sprintf("Enable the %s collector of %s (default: %s).", name, service, defaultState),
+			).Default(strconv.FormatBool(enabled)).Action(func(*kingpin.ParseContext) error {
+				set[key] = true
+				return nil
+			}).Bool()
+		}
+	}
+
+	return func() map[string]bool {
+		collectors := make(map[string]bool, len(set))
+		for key := range set {
+			collectors[key] = *flags[key]
+		}
+		return collectors
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	cinderdb "github.com/vexxhost/openstack_database_exporter/internal/db/cinder"
 )
 
@@ -48,9 +49,13 @@ func (c *AgentsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *AgentsCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *AgentsCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "cinder", c.queries)
 
-	services, err := c.queries.GetAllServices(ctx)
+	services, err := queries.GetAllServices(ctx)
 	if err != nil {
 		c.logger.Error("failed to query", "error", err)
 		return
@@ -1,6 +1,7 @@
 package cinder
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,23 +15,36 @@ const (
 	Subsystem = "cinder"
 )
 
-func RegisterCollectors(registry *prometheus.Registry, databaseURL string, projectResolver *project.Resolver, logger *slog.Logger) {
-	if databaseURL == "" {
+// Collectors lists the names of the collectors that can be enabled or
+// disabled individually.
+var Collectors = []string{
+	"agents",
+	"limits",
+	"snapshots",
+	"volumes",
+}
+
+func RegisterCollectors(registry prometheus.Registerer, database db.Config, enabled util.CollectorFilter, aggregate bool, projectResolver *project.Resolver, logger *slog.Logger) {
+	if database.URL == "" {
 		logger.Info("Collector not loaded", "service", "cinder", "reason", "database URL not configured")
 		return
 	}
-
-	conn, err := db.Connect(databaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to database", "service", "cinder", "error", err)
-		registry.MustRegister(util.NewDownCollector(Namespace, Subsystem))
+	if !enabled.Any(Collectors) {
+		logger.Info("Collector not loaded", "service", "cinder", "reason", "all collectors disabled")
 		return
 	}
 
-	registry.MustRegister(NewAgentsCollector(conn, logger))
-	registry.MustRegister(NewLimitsCollector(conn, logger, projectResolver))
-	registry.MustRegister(NewSnapshotsCollector(conn, logger))
-	registry.MustRegister(NewVolumesCollector(conn, logger))
+	util.RegisterWhenConnected(registry, Namespace, Subsystem, "cinder", func(ctx context.Context) ([]prometheus.Collector, error) {
+		conn, err := db.ConnectContext(ctx, database)
+		if err != nil {
+			return nil, err
+		}
 
-	logger.Info("Registered collectors", "service", "cinder")
+		return enabled.Select(map[string]prometheus.Collector{
+			"agents":    NewAgentsCollector(conn, logger),
+			"limits":    NewLimitsCollector(conn, logger, projectResolver),
+			"snapshots": NewSnapshotsCollector(conn, logger),
+			"volumes":   NewVolumesCollector(conn, logger, aggregate, projectResolver),
+		}), nil
+	}, logger)
 }
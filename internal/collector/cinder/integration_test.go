@@ -25,7 +25,7 @@ func TestIntegration_VolumesCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewVolumesCollector(db, logger)
+		collector := NewVolumesCollector(db, logger, false, project.NewResolver(logger, nil, 0))
 
 		// Should emit volumes=0, _up=1, and all 20 status_counter metrics (including reserved)
 		count := testutil.CollectAndCount(collector)
@@ -48,7 +48,7 @@ func TestIntegration_VolumesCollector(t *testing.T) {
 			('att-001', 'vol-001', 'server-001', 0)`,
 		)
 
-		collector := NewVolumesCollector(db, logger)
+		collector := NewVolumesCollector(db, logger, false, project.NewResolver(logger, nil, 0))
 
 		// 2 active volumes × 2 metrics (volume_gb + volume_status) = 4
 		// + 20 status counters + 1 volumes gauge + 1 up = 26
@@ -70,8 +70,8 @@ openstack_cinder_volumes 2
 		// Verify volume_status values are correct (reserved at index 2 shifts in-use to 5)
 		err = testutil.CollectAndCompare(collector, strings.NewReader(`# HELP openstack_cinder_volume_status volume_status
 # TYPE openstack_cinder_volume_status gauge
-openstack_cinder_volume_status{bootable="true",id="vol-001",name="boot-vol",server_id="server-001",size="40",status="in-use",tenant_id="proj-001",volume_type="SSD"} 5
-openstack_cinder_volume_status{bootable="false",id="vol-002",name="data-vol",server_id="",size="100",status="available",tenant_id="proj-001",volume_type="HDD"} 1
+openstack_cinder_volume_status{bootable="true",domain_id="",id="vol-001",name="boot-vol",server_id="server-001",size="40",status="in-use",tenant="proj-001",tenant_id="proj-001",volume_type="SSD"} 5
+openstack_cinder_volume_status{bootable="false",domain_id="",id="vol-002",name="data-vol",server_id="",size="100",status="available",tenant="proj-001",tenant_id="proj-001",volume_type="HDD"} 1
 `), "openstack_cinder_volume_status")
 		if err != nil {
 			t.Fatalf("unexpected volume_status error: %v", err)
@@ -105,6 +105,26 @@ openstack_cinder_volume_status_counter{status="extending"} 0
 			t.Fatalf("unexpected volume_status_counter error: %v", err)
 		}
 	})
+
+	t.Run("aggregates", func(t *testing.T) {
+		collector := NewVolumesCollector(db, logger, true, project.NewResolver(logger, nil, 0))
+
+		err := testutil.CollectAndCompare(collector, strings.NewReader(`# HELP openstack_cinder_volume_count volume_count
+# TYPE openstack_cinder_volume_count gauge
+openstack_cinder_volume_count{availability_zone="nova",domain_id="",status="available",tenant="proj-001",tenant_id="proj-001",volume_type="HDD"} 1
+openstack_cinder_volume_count{availability_zone="nova",domain_id="",status="in-use",tenant="proj-001",tenant_id="proj-001",volume_type="SSD"} 1
+# HELP openstack_cinder_volumes volumes
+# TYPE openstack_cinder_volumes gauge
+openstack_cinder_volumes 2
+# HELP openstack_cinder_volumes_gb volumes_gb
+# TYPE openstack_cinder_volumes_gb gauge
+openstack_cinder_volumes_gb{availability_zone="nova",domain_id="",status="available",tenant="proj-001",tenant_id="proj-001",volume_type="HDD"} 100
+openstack_cinder_volumes_gb{availability_zone="nova",domain_id="",status="in-use",tenant="proj-001",tenant_id="proj-001",volume_type="SSD"} 40
+`), "openstack_cinder_volume_count", "openstack_cinder_volumes", "openstack_cinder_volumes_gb", "openstack_cinder_volume_gb")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
 }
 
 func TestIntegration_SnapshotsCollector(t *testing.T) {
@@ -301,3 +321,31 @@ openstack_cinder_volume_type_quota_gigabytes{tenant="proj-002",tenant_id="proj-0
 		}
 	})
 }
+
+func TestIntegration_AgentsCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "cinder", "../../../sql/cinder/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	now := "(now() AT TIME ZONE 'UTC')"
+	itest.SeedSQL(t, db,
+		`INSERT INTO services (host, "binary", report_count, disabled, availability_zone, disabled_reason, updated_at, deleted, uuid) VALUES`+
+			" ('host-a@lvm', 'cinder-volume', 10, false, 'nova', NULL, "+now+", false, 'uuid-001'),"+
+			" ('host-b', 'cinder-scheduler', 5, true, 'nova', 'maintenance', "+now+", false, 'uuid-002'),"+
+			" ('host-c', 'cinder-backup', 1, false, 'az-2', NULL, "+now+" - INTERVAL '5 minutes', false, 'uuid-003')",
+	)
+
+	collector := NewAgentsCollector(db, logger)
+
+	expected := `# HELP openstack_cinder_agent_state agent_state
+# TYPE openstack_cinder_agent_state gauge
+openstack_cinder_agent_state{adminState="enabled",disabledReason="",hostname="host-a@lvm",service="cinder-volume",uuid="uuid-001",zone="nova"} 1
+openstack_cinder_agent_state{adminState="disabled",disabledReason="maintenance",hostname="host-b",service="cinder-scheduler",uuid="uuid-002",zone="nova"} 1
+openstack_cinder_agent_state{adminState="enabled",disabledReason="",hostname="host-c",service="cinder-backup",uuid="uuid-003",zone="az-2"} 0
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "openstack_cinder_agent_state")
+	if err != nil {
+		t.Fatalf("unexpected agent_state error: %v", err)
+	}
+}
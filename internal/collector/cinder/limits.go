@@ -7,6 +7,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	cinderdb "github.com/vexxhost/openstack_database_exporter/internal/db/cinder"
 )
 
@@ -106,17 +107,21 @@ func (c *LimitsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *LimitsCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *LimitsCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "cinder", c.queries)
 
 	// Get quota limits from cinder DB
-	quotaLimits, err := c.queries.GetProjectQuotaLimits(ctx)
+	quotaLimits, err := queries.GetProjectQuotaLimits(ctx)
 	if err != nil {
 		c.logger.Error("failed to query quota limits", "error", err)
 		return
 	}
 
 	// Get volume types for volume_type_quota_gigabytes
-	volumeTypes, err := c.queries.GetVolumeTypes(ctx)
+	volumeTypes, err := queries.GetVolumeTypes(ctx)
 	if err != nil {
 		c.logger.Error("failed to query volume types", "error", err)
 		return
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	cinderdb "github.com/vexxhost/openstack_database_exporter/internal/db/cinder"
 )
 
@@ -41,9 +42,13 @@ func (c *SnapshotsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *SnapshotsCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *SnapshotsCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "cinder", c.queries)
 
-	count, err := c.queries.GetSnapshotCount(ctx)
+	count, err := queries.GetSnapshotCount(ctx)
 	if err != nil {
 		c.logger.Error("failed to query", "error", err)
 		return
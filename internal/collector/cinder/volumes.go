@@ -7,6 +7,8 @@ import (
 	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	cinderdb "github.com/vexxhost/openstack_database_exporter/internal/db/cinder"
 	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
@@ -29,6 +31,8 @@ var (
 			"availability_zone",
 			"bootable",
 			"tenant_id",
+			"tenant",
+			"domain_id",
 			"user_id",
 			"volume_type",
 			"server_id",
@@ -45,6 +49,8 @@ var (
 			"status",
 			"bootable",
 			"tenant_id",
+			"tenant",
+			"domain_id",
 			"size",
 			"volume_type",
 			"server_id",
@@ -67,38 +73,89 @@ var (
 		nil,
 		nil,
 	)
+
+	// volumeCountDesc and volumesGbDesc replace volume_gb and volume_status
+	// in aggregate mode.
+	volumeCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, Subsystem, "volume_count"),
+		"volume_count",
+		[]string{
+			"tenant_id",
+			"tenant",
+			"domain_id",
+			"status",
+			"volume_type",
+			"availability_zone",
+		},
+		nil,
+	)
+
+	volumesGbDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, Subsystem, "volumes_gb"),
+		"volumes_gb",
+		[]string{
+			"tenant_id",
+			"tenant",
+			"domain_id",
+			"status",
+			"volume_type",
+			"availability_zone",
+		},
+		nil,
+	)
 )
 
+// VolumesCollector exports one series per volume, or in aggregate mode the
+// number and size of the volumes per project, status, type and availability
+// zone, counted by the database.
 type VolumesCollector struct {
-	db      *sql.DB
-	queries *cinderdb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *cinderdb.Queries
+	logger          *slog.Logger
+	aggregate       bool
+	projectResolver *project.Resolver
 }
 
-func NewVolumesCollector(db *sql.DB, logger *slog.Logger) *VolumesCollector {
+func NewVolumesCollector(db *sql.DB, logger *slog.Logger, aggregate bool, projectResolver *project.Resolver) *VolumesCollector {
 	return &VolumesCollector{
-		db:      db,
-		queries: cinderdb.New(db),
+		db:        db,
+		queries:   cinderdb.New(db),
+		aggregate: aggregate,
 		logger: logger.With(
 			"namespace", Namespace,
 			"subsystem", Subsystem,
 			"collector", "volumes",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
 func (c *VolumesCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- volumesDesc
-	ch <- volumeGbDesc
-	ch <- volumeStatusDesc
+	if c.aggregate {
+		ch <- volumeCountDesc
+		ch <- volumesGbDesc
+	} else {
+		ch <- volumeGbDesc
+		ch <- volumeStatusDesc
+	}
 	ch <- volumeStatusCounterDesc
 	ch <- volumesUpDesc
 }
 
 func (c *VolumesCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
 
-	volumes, err := c.queries.GetAllVolumes(ctx)
+func (c *VolumesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	if c.aggregate {
+		c.collectAggregates(ctx, ch)
+		return
+	}
+
+	queries := db.InSnapshot(ctx, "cinder", c.queries)
+
+	volumes, err := queries.GetAllVolumes(ctx)
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(volumesUpDesc, prometheus.GaugeValue, 0)
 
@@ -106,31 +163,11 @@ func (c *VolumesCollector) Collect(ch chan<- prometheus.Metric) {
 		return
 	}
 
-	volume_status_counter := map[string]int{
-		"creating":          0,
-		"available":         0,
-		"reserved":          0,
-		"attaching":         0,
-		"detaching":         0,
-		"in-use":            0,
-		"maintenance":       0,
-		"deleting":          0,
-		"awaiting-transfer": 0,
-		"error":             0,
-		"error_deleting":    0,
-		"backing-up":        0,
-		"restoring-backup":  0,
-		"error_backing-up":  0,
-		"error_restoring":   0,
-		"error_extending":   0,
-		"downloading":       0,
-		"uploading":         0,
-		"retyping":          0,
-		"extending":         0,
-	}
+	volume_status_counter := newVolumeStatusCounter()
 
 	for _, volume := range volumes {
 		volume_status_counter[volume.Status.String]++
+		projectName, domainID := c.projectResolver.Resolve(volume.ProjectID.String)
 
 		ch <- prometheus.MustNewConstMetric(
 			volumeGbDesc,
@@ -142,6 +179,8 @@ func (c *VolumesCollector) Collect(ch chan<- prometheus.Metric) {
 			volume.AvailabilityZone.String,
 			strconv.FormatBool(volume.Bootable.Bool),
 			volume.ProjectID.String,
+			projectName,
+			domainID,
 			volume.UserID.String,
 			volume.VolumeType.String,
 			volume.ServerID.String,
@@ -177,6 +216,8 @@ func (c *VolumesCollector) Collect(ch chan<- prometheus.Metric) {
 			volume.Status.String,
 			strconv.FormatBool(volume.Bootable.Bool),
 			volume.ProjectID.String,
+			projectName,
+			domainID,
 			strconv.Itoa(int(volume.Size.Int32)),
 			volume.VolumeType.String,
 			volume.ServerID.String,
@@ -200,3 +241,94 @@ func (c *VolumesCollector) Collect(ch chan<- prometheus.Metric) {
 
 	ch <- prometheus.MustNewConstMetric(volumesUpDesc, prometheus.GaugeValue, 1)
 }
+
+// collectAggregates exports the volume totals counted by the database, whose
+// number of series does not grow with the number of volumes.
+func (c *VolumesCollector) collectAggregates(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "cinder", c.queries)
+
+	aggregates, err := queries.GetVolumeAggregates(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(volumesUpDesc, prometheus.GaugeValue, 0)
+
+		c.logger.Error("failed to query", "error", err)
+		return
+	}
+
+	volume_status_counter := newVolumeStatusCounter()
+	var total int64
+
+	for _, aggregate := range aggregates {
+		volume_status_counter[aggregate.Status.String] += int(aggregate.Count)
+		total += aggregate.Count
+		projectName, domainID := c.projectResolver.Resolve(aggregate.ProjectID.String)
+
+		ch <- prometheus.MustNewConstMetric(
+			volumeCountDesc,
+			prometheus.GaugeValue,
+			float64(aggregate.Count),
+			aggregate.ProjectID.String,
+			projectName,
+			domainID,
+			aggregate.Status.String,
+			aggregate.VolumeType.String,
+			aggregate.AvailabilityZone.String,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			volumesGbDesc,
+			prometheus.GaugeValue,
+			float64(aggregate.Size),
+			aggregate.ProjectID.String,
+			projectName,
+			domainID,
+			aggregate.Status.String,
+			aggregate.VolumeType.String,
+			aggregate.AvailabilityZone.String,
+		)
+	}
+
+	for status, count := range volume_status_counter {
+		ch <- prometheus.MustNewConstMetric(
+			volumeStatusCounterDesc,
+			prometheus.GaugeValue,
+			float64(count),
+			status,
+		)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		volumesDesc,
+		prometheus.GaugeValue,
+		float64(total),
+	)
+
+	ch <- prometheus.MustNewConstMetric(volumesUpDesc, prometheus.GaugeValue, 1)
+}
+
+// newVolumeStatusCounter returns the volume counts of the known statuses,
+// which are exported even without any volume.
+func newVolumeStatusCounter() map[string]int {
+	return map[string]int{
+		"creating":          0,
+		"available":         0,
+		"reserved":          0,
+		"attaching":         0,
+		"detaching":         0,
+		"in-use":            0,
+		"maintenance":       0,
+		"deleting":          0,
+		"awaiting-transfer": 0,
+		"error":             0,
+		"error_deleting":    0,
+		"backing-up":        0,
+		"restoring-backup":  0,
+		"error_backing-up":  0,
+		"error_restoring":   0,
+		"error_extending":   0,
+		"downloading":       0,
+		"uploading":         0,
+		"retyping":          0,
+		"extending":         0,
+	}
+}
@@ -1,11 +1,20 @@
 package cinder
 
 import (
+	"context"
 	"database/sql"
+	"io"
+	"log/slog"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	cinderdb "github.com/vexxhost/openstack_database_exporter/internal/db/cinder"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -32,12 +41,12 @@ func TestVolumesCollector(t *testing.T) {
 openstack_cinder_up 1
 # HELP openstack_cinder_volume_gb volume_gb
 # TYPE openstack_cinder_volume_gb gauge
-openstack_cinder_volume_gb{availability_zone="nova",bootable="false",id="6edbc2f4-1507-44f8-ac0d-eed1d2608d38",name="test-volume-attachments",server_id="f4fda93b-06e0-4743-8117-bc8bcecd651b",status="in-use",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",user_id="32779452fcd34ae1a53a797ac8a1e064",volume_type="lvmdriver-1"} 2
-openstack_cinder_volume_gb{availability_zone="nova",bootable="true",id="173f7b48-c4c1-4e70-9acc-086b39073506",name="test-volume",server_id="",status="available",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",user_id="32779452fcd34ae1a53a797ac8a1e064",volume_type="lvmdriver-1"} 1
+openstack_cinder_volume_gb{availability_zone="nova",bootable="false",domain_id="",id="6edbc2f4-1507-44f8-ac0d-eed1d2608d38",name="test-volume-attachments",server_id="f4fda93b-06e0-4743-8117-bc8bcecd651b",status="in-use",tenant="bab7d5c60cd041a0a36f7c4b6e1dd978",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",user_id="32779452fcd34ae1a53a797ac8a1e064",volume_type="lvmdriver-1"} 2
+openstack_cinder_volume_gb{availability_zone="nova",bootable="true",domain_id="",id="173f7b48-c4c1-4e70-9acc-086b39073506",name="test-volume",server_id="",status="available",tenant="bab7d5c60cd041a0a36f7c4b6e1dd978",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",user_id="32779452fcd34ae1a53a797ac8a1e064",volume_type="lvmdriver-1"} 1
 # HELP openstack_cinder_volume_status volume_status
 # TYPE openstack_cinder_volume_status gauge
-openstack_cinder_volume_status{bootable="false",id="6edbc2f4-1507-44f8-ac0d-eed1d2608d38",name="test-volume-attachments",server_id="f4fda93b-06e0-4743-8117-bc8bcecd651b",size="2",status="in-use",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",volume_type="lvmdriver-1"} 5
-openstack_cinder_volume_status{bootable="true",id="173f7b48-c4c1-4e70-9acc-086b39073506",name="test-volume",server_id="",size="1",status="available",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",volume_type="lvmdriver-1"} 1
+openstack_cinder_volume_status{bootable="false",domain_id="",id="6edbc2f4-1507-44f8-ac0d-eed1d2608d38",name="test-volume-attachments",server_id="f4fda93b-06e0-4743-8117-bc8bcecd651b",size="2",status="in-use",tenant="bab7d5c60cd041a0a36f7c4b6e1dd978",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",volume_type="lvmdriver-1"} 5
+openstack_cinder_volume_status{bootable="true",domain_id="",id="173f7b48-c4c1-4e70-9acc-086b39073506",name="test-volume",server_id="",size="1",status="available",tenant="bab7d5c60cd041a0a36f7c4b6e1dd978",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",volume_type="lvmdriver-1"} 1
 # HELP openstack_cinder_volume_status_counter volume_status_counter
 # TYPE openstack_cinder_volume_status_counter gauge
 openstack_cinder_volume_status_counter{status="attaching"} 0
@@ -75,5 +84,110 @@ openstack_cinder_volumes 2
 		},
 	}
 
-	testutil.RunCollectorTests(t, tests, NewVolumesCollector)
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *VolumesCollector {
+		return NewVolumesCollector(db, logger, false, project.NewResolver(logger, nil, 0))
+	})
+}
+
+func TestVolumesCollector_Aggregate(t *testing.T) {
+	tests := []testutil.CollectorTestCase{
+		{
+			Name: "successful collection with aggregates",
+			SetupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{
+					"project_id", "status", "volume_type", "availability_zone", "count", "size",
+				}).AddRow(
+					"bab7d5c60cd041a0a36f7c4b6e1dd978", "available", "lvmdriver-1", "nova", 2, 30,
+				).AddRow(
+					"bab7d5c60cd041a0a36f7c4b6e1dd978", "in-use", "lvmdriver-1", "nova", 1, 2,
+				).AddRow(
+					"f0b8bd6e4b1e4ad1a0f4c8d5cc1d6f2a", "in-use", nil, "nova", 3, 60,
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(cinderdb.GetVolumeAggregates)).WillReturnRows(rows)
+			},
+			ExpectedMetrics: `# HELP openstack_cinder_up up
+# TYPE openstack_cinder_up gauge
+openstack_cinder_up 1
+# HELP openstack_cinder_volume_count volume_count
+# TYPE openstack_cinder_volume_count gauge
+openstack_cinder_volume_count{availability_zone="nova",domain_id="",status="available",tenant="bab7d5c60cd041a0a36f7c4b6e1dd978",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",volume_type="lvmdriver-1"} 2
+openstack_cinder_volume_count{availability_zone="nova",domain_id="",status="in-use",tenant="bab7d5c60cd041a0a36f7c4b6e1dd978",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",volume_type="lvmdriver-1"} 1
+openstack_cinder_volume_count{availability_zone="nova",domain_id="",status="in-use",tenant="f0b8bd6e4b1e4ad1a0f4c8d5cc1d6f2a",tenant_id="f0b8bd6e4b1e4ad1a0f4c8d5cc1d6f2a",volume_type=""} 3
+# HELP openstack_cinder_volume_status_counter volume_status_counter
+# TYPE openstack_cinder_volume_status_counter gauge
+openstack_cinder_volume_status_counter{status="attaching"} 0
+openstack_cinder_volume_status_counter{status="available"} 2
+openstack_cinder_volume_status_counter{status="awaiting-transfer"} 0
+openstack_cinder_volume_status_counter{status="backing-up"} 0
+openstack_cinder_volume_status_counter{status="creating"} 0
+openstack_cinder_volume_status_counter{status="deleting"} 0
+openstack_cinder_volume_status_counter{status="detaching"} 0
+openstack_cinder_volume_status_counter{status="downloading"} 0
+openstack_cinder_volume_status_counter{status="error"} 0
+openstack_cinder_volume_status_counter{status="error_backing-up"} 0
+openstack_cinder_volume_status_counter{status="error_deleting"} 0
+openstack_cinder_volume_status_counter{status="error_extending"} 0
+openstack_cinder_volume_status_counter{status="error_restoring"} 0
+openstack_cinder_volume_status_counter{status="extending"} 0
+openstack_cinder_volume_status_counter{status="in-use"} 4
+openstack_cinder_volume_status_counter{status="maintenance"} 0
+openstack_cinder_volume_status_counter{status="reserved"} 0
+openstack_cinder_volume_status_counter{status="restoring-backup"} 0
+openstack_cinder_volume_status_counter{status="retyping"} 0
+openstack_cinder_volume_status_counter{status="uploading"} 0
+# HELP openstack_cinder_volumes volumes
+# TYPE openstack_cinder_volumes gauge
+openstack_cinder_volumes 6
+# HELP openstack_cinder_volumes_gb volumes_gb
+# TYPE openstack_cinder_volumes_gb gauge
+openstack_cinder_volumes_gb{availability_zone="nova",domain_id="",status="available",tenant="bab7d5c60cd041a0a36f7c4b6e1dd978",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",volume_type="lvmdriver-1"} 30
+openstack_cinder_volumes_gb{availability_zone="nova",domain_id="",status="in-use",tenant="bab7d5c60cd041a0a36f7c4b6e1dd978",tenant_id="bab7d5c60cd041a0a36f7c4b6e1dd978",volume_type="lvmdriver-1"} 2
+openstack_cinder_volumes_gb{availability_zone="nova",domain_id="",status="in-use",tenant="f0b8bd6e4b1e4ad1a0f4c8d5cc1d6f2a",tenant_id="f0b8bd6e4b1e4ad1a0f4c8d5cc1d6f2a",volume_type=""} 60
+`,
+		},
+		{
+			Name: "query error",
+			SetupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(cinderdb.GetVolumeAggregates)).WillReturnError(sql.ErrConnDone)
+			},
+			ExpectedMetrics: `# HELP openstack_cinder_up up
+# TYPE openstack_cinder_up gauge
+openstack_cinder_up 0
+`,
+		},
+	}
+
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *VolumesCollector {
+		return NewVolumesCollector(db, logger, true, project.NewResolver(logger, nil, 0))
+	})
+}
+
+func TestVolumesCollector_DeadlineExceeded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(cinderdb.GetAllVolumes)).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	collector := NewVolumesCollector(db, logger, false, project.NewResolver(logger, nil, 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	ch := make(chan prometheus.Metric, 10)
+	collector.CollectContext(ctx, ch)
+	close(ch)
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	require.Len(t, metrics, 1)
+	assert.Equal(t, volumesUpDesc, metrics[0].Desc())
+
+	var m dto.Metric
+	require.NoError(t, metrics[0].Write(&m))
+	assert.Equal(t, float64(0), m.GetGauge().GetValue())
 }
@@ -1,11 +1,10 @@
 package collector
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
-	"github.com/prometheus/client_golang/prometheus"
-
 	"github.com/vexxhost/openstack_database_exporter/internal/collector/cinder"
 	"github.com/vexxhost/openstack_database_exporter/internal/collector/glance"
 	"github.com/vexxhost/openstack_database_exporter/internal/collector/heat"
@@ -20,12 +19,41 @@ import (
 	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
+	"github.com/vexxhost/openstack_database_exporter/internal/relabel"
+	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
 
 const (
 	Namespace = "openstack"
 )
 
+// Collectors maps every service the exporter can collect to the names of
+// its collectors, which can be enabled and disabled individually.
+var Collectors = map[string][]string{
+	"cinder":    cinder.Collectors,
+	"glance":    glance.Collectors,
+	"heat":      heat.Collectors,
+	"ironic":    ironic.Collectors,
+	"keystone":  keystone.Collectors,
+	"magnum":    magnum.Collectors,
+	"manila":    manila.Collectors,
+	"neutron":   neutron.Collectors,
+	"nova":      nova.Collectors,
+	"octavia":   octavia.Collectors,
+	"placement": placement.Collectors,
+}
+
+// DefaultDisabledCollectors holds the collectors, by "<service>.<name>", that
+// are disabled unless enabled explicitly.
+var DefaultDisabledCollectors = map[string]bool{
+	// One series per user grows with the users of every domain.
+	"keystone.user_info": true,
+}
+
+// AggregateServices lists the services whose per-object metrics can be
+// replaced with aggregates, see Config.Aggregate.
+var AggregateServices = []string{"cinder", "neutron", "nova"}
+
 type Config struct {
 	CinderDatabaseURL    string
 	GlanceDatabaseURL    string
@@ -40,36 +68,143 @@ type Config struct {
 	NovaDatabaseURL      string
 	NovaAPIDatabaseURL   string
 	ProjectCacheTTL      time.Duration
+	// Parallelism limits how many collectors run at once across all
+	// services. Zero means unlimited.
+	Parallelism int
+	// PollInterval enables background polling: each service is refreshed
+	// at this interval and scrapes are served from the cached snapshot.
+	// Zero collects on every scrape.
+	PollInterval time.Duration
+	// ServicePollIntervals overrides PollInterval per service name.
+	ServicePollIntervals map[string]time.Duration
+	// ScrapeTimeout and ScrapeTimeoutOffset bound every scrape, see
+	// Registry.Handler.
+	ScrapeTimeout       time.Duration
+	ScrapeTimeoutOffset time.Duration
+	// Snapshot runs the queries of each collection of a service in one
+	// read-only transaction per database, so that its metrics are
+	// consistent with each other. The collectors of a service then run one
+	// at a time.
+	Snapshot bool
+	// Pool sizes the connection pool of every database.
+	Pool db.PoolConfig
+	// ServicePools overrides Pool per service name. A service's
+	// collectors never run more concurrently than its pool allows.
+	ServicePools map[string]db.PoolConfig
+	// Replication guards reading from replicas listed before the primary
+	// in a database URL.
+	Replication db.ReplicationConfig
+	// Collectors enables or disables collectors by "<service>.<name>".
+	// Collectors not listed are enabled unless DisableDefaultCollectors is
+	// set or they are in DefaultDisabledCollectors.
+	Collectors               map[string]bool
+	DisableDefaultCollectors bool
+	// Aggregate replaces the per-object metrics of cinder volumes, nova
+	// servers and neutron floating IPs with totals counted by the database
+	// per project, status and a few other labels, so that the number of
+	// series does not grow with the number of objects.
+	Aggregate bool
+	// ServiceAggregate overrides Aggregate per service name.
+	ServiceAggregate map[string]bool
+	// Relabel rewrites the gathered series, e.g. to bound the number of
+	// series of per-object metrics.
+	Relabel []relabel.Rule
+	// Region, if set, is added as the region label of every metric of the
+	// registry, including the exporter metrics.
+	Region string
+	// Targets holds further databases, e.g. those of other regions, each
+	// collected by a registry of its own with its own connection pools and
+	// project resolver. The Targets of a target are ignored.
+	Targets map[string]Config
 }
 
-func NewRegistry(cfg Config, logger *slog.Logger) *prometheus.Registry {
-	reg := prometheus.NewRegistry()
+// collectorFilter returns the filter selecting the enabled collectors of a
+// service.
+func (cfg Config) collectorFilter(service string) util.CollectorFilter {
+	return func(name string) bool {
+		if enabled, ok := cfg.Collectors[service+"."+name]; ok {
+			return enabled
+		}
+		return !cfg.DisableDefaultCollectors && !DefaultDisabledCollectors[service+"."+name]
+	}
+}
+
+// aggregate tells whether the collectors of a service export aggregates
+// instead of per-object metrics.
+func (cfg Config) aggregate(service string) bool {
+	if aggregate, ok := cfg.ServiceAggregate[service]; ok {
+		return aggregate
+	}
+	return cfg.Aggregate
+}
+
+// pool returns the connection pool settings of a service.
+func (cfg Config) pool(service string) db.PoolConfig {
+	if p, ok := cfg.ServicePools[service]; ok {
+		return p.WithDefaults()
+	}
+	return cfg.Pool.WithDefaults()
+}
+
+func NewRegistry(cfg Config, logger *slog.Logger) *Registry {
+	reg := newRegistry(cfg.Parallelism)
+	reg.logger = logger
+	reg.region = cfg.Region
+	reg.snapshot = cfg.Snapshot
+	reg.relabel = relabel.New(Namespace, cfg.Relabel)
+	reg.self.MustRegister(reg.relabel)
+
+	for service := range Collectors {
+		reg.scheduler.SetServiceLimit(service, cfg.pool(service).MaxOpenConns)
+	}
+	database := func(service, url string) db.Config {
+		return db.Config{URL: url, Pool: cfg.pool(service), Replication: cfg.Replication, Pools: reg.pools, Metrics: reg.queries, Service: service}
+	}
+	// The nova collectors read the nova API and placement databases too,
+	// whose queries are those of their own schema.
+	novaDatabase := func(schema, url string) db.Config {
+		database := database("nova", url)
+		database.Schema = schema
+		return database
+	}
 
 	// Create a single shared project resolver for all collectors that need
 	// project ID → name resolution. This avoids duplicate keystone DB
 	// connections and duplicate cache refreshes.
-	var keystoneQueries *keystonedb.Queries
+	// A keystone database that cannot be reached is retried in the
+	// background like those of the services.
+	var connectKeystone project.ConnectFunc
 	if cfg.KeystoneDatabaseURL != "" {
-		keystoneConn, err := db.Connect(cfg.KeystoneDatabaseURL)
-		if err != nil {
-			logger.Warn("Failed to connect to keystone database for project name resolution", "error", err)
-		} else {
-			keystoneQueries = keystonedb.New(keystoneConn)
+		keystoneDatabase := database("keystone", cfg.KeystoneDatabaseURL)
+		connectKeystone = func(ctx context.Context) (*keystonedb.Queries, error) {
+			keystoneConn, err := db.ConnectContext(ctx, keystoneDatabase)
+			if err != nil {
+				return nil, err
+			}
+			return keystonedb.New(keystoneConn), nil
 		}
 	}
-	projectResolver := project.NewResolver(logger, keystoneQueries, cfg.ProjectCacheTTL)
-
-	cinder.RegisterCollectors(reg, cfg.CinderDatabaseURL, projectResolver, logger)
-	glance.RegisterCollectors(reg, cfg.GlanceDatabaseURL, logger)
-	heat.RegisterCollectors(reg, cfg.HeatDatabaseURL, logger)
-	ironic.RegisterCollectors(reg, cfg.IronicDatabaseURL, logger)
-	keystone.RegisterCollectors(reg, cfg.KeystoneDatabaseURL, logger)
-	magnum.RegisterCollectors(reg, cfg.MagnumDatabaseURL, logger)
-	manila.RegisterCollectors(reg, cfg.ManilaDatabaseURL, logger)
-	neutron.RegisterCollectors(reg, cfg.NeutronDatabaseURL, projectResolver, logger)
-	nova.RegisterCollectors(reg, cfg.NovaDatabaseURL, cfg.NovaAPIDatabaseURL, cfg.PlacementDatabaseURL, projectResolver, logger)
-	octavia.RegisterCollectors(reg, cfg.OctaviaDatabaseURL, logger)
-	placement.RegisterCollectors(reg, cfg.PlacementDatabaseURL, logger)
+	// The users are only loaded for the keystone user_info collector.
+	withUsers := cfg.KeystoneDatabaseURL != "" && cfg.collectorFilter("keystone").Enabled("user_info")
+	projectResolver := project.NewConnectingResolver(logger, connectKeystone, cfg.ProjectCacheTTL, withUsers)
+	reg.resolver = projectResolver
+	reg.self.MustRegister(projectResolver)
+
+	cinder.RegisterCollectors(reg.Service("cinder"), database("cinder", cfg.CinderDatabaseURL), cfg.collectorFilter("cinder"), cfg.aggregate("cinder"), projectResolver, logger)
+	glance.RegisterCollectors(reg.Service("glance"), database("glance", cfg.GlanceDatabaseURL), cfg.collectorFilter("glance"), projectResolver, logger)
+	heat.RegisterCollectors(reg.Service("heat"), database("heat", cfg.HeatDatabaseURL), cfg.collectorFilter("heat"), logger)
+	ironic.RegisterCollectors(reg.Service("ironic"), database("ironic", cfg.IronicDatabaseURL), cfg.collectorFilter("ironic"), logger)
+	keystone.RegisterCollectors(reg.Service("keystone"), database("keystone", cfg.KeystoneDatabaseURL), cfg.collectorFilter("keystone"), projectResolver, logger)
+	magnum.RegisterCollectors(reg.Service("magnum"), database("magnum", cfg.MagnumDatabaseURL), cfg.collectorFilter("magnum"), projectResolver, logger)
+	manila.RegisterCollectors(reg.Service("manila"), database("manila", cfg.ManilaDatabaseURL), cfg.collectorFilter("manila"), projectResolver, logger)
+	neutron.RegisterCollectors(reg.Service("neutron"), database("neutron", cfg.NeutronDatabaseURL), cfg.collectorFilter("neutron"), cfg.aggregate("neutron"), projectResolver, logger)
+	nova.RegisterCollectors(reg.Service("nova"), novaDatabase("nova", cfg.NovaDatabaseURL), novaDatabase("nova_api", cfg.NovaAPIDatabaseURL), novaDatabase("placement", cfg.PlacementDatabaseURL), cfg.collectorFilter("nova"), cfg.aggregate("nova"), projectResolver, logger)
+	octavia.RegisterCollectors(reg.Service("octavia"), database("octavia", cfg.OctaviaDatabaseURL), cfg.collectorFilter("octavia"), projectResolver, logger)
+	placement.RegisterCollectors(reg.Service("placement"), database("placement", cfg.PlacementDatabaseURL), cfg.collectorFilter("placement"), logger)
+
+	if cfg.PollInterval > 0 || len(cfg.ServicePollIntervals) > 0 {
+		reg.StartPolling(cfg.PollInterval, cfg.ServicePollIntervals, logger)
+	}
 
 	return reg
 }
@@ -1,43 +1,123 @@
 package collector
 
 import (
-	"io"
 	"log/slog"
+	"net"
+	"sync"
 	"testing"
+	"time"
 
-	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestNewRegistry_AllEmpty(t *testing.T) {
-	// When all URLs are empty, no collectors should be registered.
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-
-	cfg := Config{}
-	reg := NewRegistry(cfg, logger)
-
-	// Gather should return no metric families (no collectors registered)
-	mfs, err := reg.Gather()
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+func TestConfig_CollectorFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected map[string]bool
+	}{
+		{
+			name:     "defaults",
+			expected: map[string]bool{"server": true, "flavors": true},
+		},
+		{
+			name:     "disabled collector",
+			cfg:      Config{Collectors: map[string]bool{"nova.server": false}},
+			expected: map[string]bool{"server": false, "flavors": true},
+		},
+		{
+			name:     "disable defaults",
+			cfg:      Config{DisableDefaultCollectors: true, Collectors: map[string]bool{"nova.flavors": true}},
+			expected: map[string]bool{"server": false, "flavors": true},
+		},
+		{
+			name:     "other service",
+			cfg:      Config{Collectors: map[string]bool{"cinder.server": false}},
+			expected: map[string]bool{"server": true, "flavors": true},
+		},
 	}
-	if len(mfs) != 0 {
-		t.Fatalf("expected 0 metric families with empty config, got %d", len(mfs))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.cfg.collectorFilter("nova")
+			for name, expected := range tt.expected {
+				assert.Equal(t, expected, filter.Enabled(name), name)
+			}
+		})
 	}
 }
 
-func TestNewRegistry_ReturnsValidRegistry(t *testing.T) {
-	// Verify the returned registry is a valid prometheus.Registry
-	// (even with empty config, it should be usable)
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+func TestConfig_CollectorFilterDefaultDisabled(t *testing.T) {
+	assert.False(t, Config{}.collectorFilter("keystone").Enabled("user_info"))
+	assert.True(t, Config{}.collectorFilter("keystone").Enabled("users"))
+
+	cfg := Config{Collectors: map[string]bool{"keystone.user_info": true}}
+	assert.True(t, cfg.collectorFilter("keystone").Enabled("user_info"))
+}
+
+func TestConfig_Aggregate(t *testing.T) {
+	cfg := Config{Aggregate: true, ServiceAggregate: map[string]bool{"neutron": false}}
+	assert.True(t, cfg.aggregate("nova"))
+	assert.False(t, cfg.aggregate("neutron"))
 
-	cfg := Config{}
-	reg := NewRegistry(cfg, logger)
+	cfg = Config{ServiceAggregate: map[string]bool{"cinder": true}}
+	assert.True(t, cfg.aggregate("cinder"))
+	assert.False(t, cfg.aggregate("nova"))
+}
+
+// hangingListener accepts connections but never answers, like a database
+// behind a firewall that drops packets.
+func hangingListener(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var conns []net.Conn
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
 
-	if reg == nil {
-		t.Fatal("expected non-nil registry")
+	return listener.Addr().String()
+}
+
+func TestNewRegistry_UnreachableDatabases(t *testing.T) {
+	url := "mysql+pymysql://user:password@" + hangingListener(t) + "/openstack"
+	cfg := Config{
+		CinderDatabaseURL:    url,
+		GlanceDatabaseURL:    url,
+		HeatDatabaseURL:      url,
+		IronicDatabaseURL:    url,
+		KeystoneDatabaseURL:  url,
+		MagnumDatabaseURL:    url,
+		ManilaDatabaseURL:    url,
+		NeutronDatabaseURL:   url,
+		OctaviaDatabaseURL:   url,
+		PlacementDatabaseURL: url,
+		NovaDatabaseURL:      url,
+		NovaAPIDatabaseURL:   url,
 	}
 
-	// Should be a *prometheus.Registry that can be gathered from
-	var _ prometheus.Gatherer = reg
-	var _ prometheus.Registerer = reg
+	start := time.Now()
+	reg := NewRegistry(cfg, slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { _ = reg.Close() })
+
+	// The connections are attempted in the background.
+	assert.Less(t, time.Since(start), 5*time.Second)
 }
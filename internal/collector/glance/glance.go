@@ -1,9 +1,11 @@
 package glance
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
@@ -13,20 +15,30 @@ const (
 	Subsystem = "glance"
 )
 
-func RegisterCollectors(registry *prometheus.Registry, databaseURL string, logger *slog.Logger) {
-	if databaseURL == "" {
+// Collectors lists the names of the collectors that can be enabled or
+// disabled individually.
+var Collectors = []string{
+	"images",
+}
+
+func RegisterCollectors(registry prometheus.Registerer, database db.Config, enabled util.CollectorFilter, projectResolver *project.Resolver, logger *slog.Logger) {
+	if database.URL == "" {
 		logger.Info("Collector not loaded", "service", "glance", "reason", "database URL not configured")
 		return
 	}
-
-	conn, err := db.Connect(databaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to database", "service", "glance", "error", err)
-		registry.MustRegister(util.NewDownCollector(Namespace, Subsystem))
+	if !enabled.Any(Collectors) {
+		logger.Info("Collector not loaded", "service", "glance", "reason", "all collectors disabled")
 		return
 	}
 
-	registry.MustRegister(NewImagesCollector(conn, logger))
+	util.RegisterWhenConnected(registry, Namespace, Subsystem, "glance", func(ctx context.Context) ([]prometheus.Collector, error) {
+		conn, err := db.ConnectContext(ctx, database)
+		if err != nil {
+			return nil, err
+		}
 
-	logger.Info("Registered collectors", "service", "glance")
+		return enabled.Select(map[string]prometheus.Collector{
+			"images": NewImagesCollector(conn, logger, projectResolver),
+		}), nil
+	}, logger)
 }
@@ -6,6 +6,8 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	glancedb "github.com/vexxhost/openstack_database_exporter/internal/db/glance"
 )
 
@@ -24,6 +26,8 @@ var (
 			"id",
 			"name",
 			"tenant_id",
+			"tenant",
+			"domain_id",
 		},
 		nil,
 	)
@@ -35,6 +39,8 @@ var (
 			"id",
 			"name",
 			"tenant_id",
+			"tenant",
+			"domain_id",
 			"visibility",
 			"hidden",
 			"status",
@@ -51,12 +57,13 @@ var (
 )
 
 type ImagesCollector struct {
-	db      *sql.DB
-	queries *glancedb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *glancedb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewImagesCollector(db *sql.DB, logger *slog.Logger) *ImagesCollector {
+func NewImagesCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *ImagesCollector {
 	return &ImagesCollector{
 		db:      db,
 		queries: glancedb.New(db),
@@ -65,6 +72,7 @@ func NewImagesCollector(db *sql.DB, logger *slog.Logger) *ImagesCollector {
 			"subsystem", Subsystem,
 			"collector", "images",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -76,9 +84,13 @@ func (c *ImagesCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *ImagesCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *ImagesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "glance", c.queries)
 
-	images, err := c.queries.GetAllImages(ctx)
+	images, err := queries.GetAllImages(ctx)
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(imagesUpDesc, prometheus.GaugeValue, 0)
 
@@ -87,6 +99,8 @@ func (c *ImagesCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 
 	for _, image := range images {
+		projectName, domainID := c.projectResolver.Resolve(image.Owner.String)
+
 		sizeBytes := float64(0)
 		if image.Size.Valid {
 			sizeBytes = float64(image.Size.Int64)
@@ -99,6 +113,8 @@ func (c *ImagesCollector) Collect(ch chan<- prometheus.Metric) {
 			image.ID,
 			image.Name.String,
 			image.Owner.String,
+			projectName,
+			domainID,
 		)
 
 		// Convert boolean to string for hidden label
@@ -114,6 +130,8 @@ func (c *ImagesCollector) Collect(ch chan<- prometheus.Metric) {
 			image.ID,
 			image.Name.String,
 			image.Owner.String,
+			projectName,
+			domainID,
 			string(image.Visibility),
 			hiddenStr,
 			string(image.Status),
@@ -2,11 +2,13 @@ package glance
 
 import (
 	"database/sql"
+	"log/slog"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	glancedb "github.com/vexxhost/openstack_database_exporter/internal/db/glance"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -38,12 +40,12 @@ func TestImagesCollector(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_glance_image_bytes image_bytes
 # TYPE openstack_glance_image_bytes gauge
-openstack_glance_image_bytes{id="1bea47ed-f6a9-463b-b423-14b9cca9ad27",name="cirros-0.3.2-x86_64-disk",tenant_id="5ef70662f8b34079a6eddb8da9d75fe8"} 1.3167616e+07
-openstack_glance_image_bytes{id="781b3762-9469-4cec-b58d-3349e5de4e9c",name="F17-x86_64-cfntools",tenant_id="5ef70662f8b34079a6eddb8da9d75fe8"} 4.76704768e+08
+openstack_glance_image_bytes{domain_id="",id="1bea47ed-f6a9-463b-b423-14b9cca9ad27",name="cirros-0.3.2-x86_64-disk",tenant="5ef70662f8b34079a6eddb8da9d75fe8",tenant_id="5ef70662f8b34079a6eddb8da9d75fe8"} 1.3167616e+07
+openstack_glance_image_bytes{domain_id="",id="781b3762-9469-4cec-b58d-3349e5de4e9c",name="F17-x86_64-cfntools",tenant="5ef70662f8b34079a6eddb8da9d75fe8",tenant_id="5ef70662f8b34079a6eddb8da9d75fe8"} 4.76704768e+08
 # HELP openstack_glance_image_created_at image_created_at
 # TYPE openstack_glance_image_created_at gauge
-openstack_glance_image_created_at{hidden="false",id="1bea47ed-f6a9-463b-b423-14b9cca9ad27",name="cirros-0.3.2-x86_64-disk",status="active",tenant_id="5ef70662f8b34079a6eddb8da9d75fe8",visibility="public"} 1.6725312e+09
-openstack_glance_image_created_at{hidden="false",id="781b3762-9469-4cec-b58d-3349e5de4e9c",name="F17-x86_64-cfntools",status="active",tenant_id="5ef70662f8b34079a6eddb8da9d75fe8",visibility="public"} 1.6725312e+09
+openstack_glance_image_created_at{domain_id="",hidden="false",id="1bea47ed-f6a9-463b-b423-14b9cca9ad27",name="cirros-0.3.2-x86_64-disk",status="active",tenant="5ef70662f8b34079a6eddb8da9d75fe8",tenant_id="5ef70662f8b34079a6eddb8da9d75fe8",visibility="public"} 1.6725312e+09
+openstack_glance_image_created_at{domain_id="",hidden="false",id="781b3762-9469-4cec-b58d-3349e5de4e9c",name="F17-x86_64-cfntools",status="active",tenant="5ef70662f8b34079a6eddb8da9d75fe8",tenant_id="5ef70662f8b34079a6eddb8da9d75fe8",visibility="public"} 1.6725312e+09
 # HELP openstack_glance_images images
 # TYPE openstack_glance_images gauge
 openstack_glance_images 2
@@ -92,10 +94,10 @@ openstack_glance_up 1
 			},
 			ExpectedMetrics: `# HELP openstack_glance_image_bytes image_bytes
 # TYPE openstack_glance_image_bytes gauge
-openstack_glance_image_bytes{id="image-with-nulls",name="",tenant_id=""} 0
+openstack_glance_image_bytes{domain_id="",id="image-with-nulls",name="",tenant="",tenant_id=""} 0
 # HELP openstack_glance_image_created_at image_created_at
 # TYPE openstack_glance_image_created_at gauge
-openstack_glance_image_created_at{hidden="false",id="image-with-nulls",name="",status="active",tenant_id="",visibility="private"} 1.6725312e+09
+openstack_glance_image_created_at{domain_id="",hidden="false",id="image-with-nulls",name="",status="active",tenant="",tenant_id="",visibility="private"} 1.6725312e+09
 # HELP openstack_glance_images images
 # TYPE openstack_glance_images gauge
 openstack_glance_images 1
@@ -116,5 +118,7 @@ openstack_glance_up 0
 		},
 	}
 
-	testutil.RunCollectorTests(t, tests, NewImagesCollector)
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *ImagesCollector {
+		return NewImagesCollector(db, logger, project.NewResolver(logger, nil, 0))
+	})
 }
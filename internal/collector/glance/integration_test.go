@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	itest "github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
 
@@ -19,7 +20,7 @@ func TestIntegration_ImagesCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewImagesCollector(db, logger)
+		collector := NewImagesCollector(db, logger, project.NewResolver(logger, nil, 0))
 		expected := `# HELP openstack_glance_images images
 # TYPE openstack_glance_images gauge
 openstack_glance_images 0
@@ -44,7 +45,7 @@ openstack_glance_up 1
 			('img-004', NULL, NULL, 'queued', '2024-04-10 12:00:00', 0, 0, 0, 'community', 1, NULL)`,
 		)
 
-		collector := NewImagesCollector(db, logger)
+		collector := NewImagesCollector(db, logger, project.NewResolver(logger, nil, 0))
 
 		// deleted=1 images should be filtered out, so only 3 images
 		expected := `# HELP openstack_glance_images images
@@ -74,3 +75,38 @@ openstack_glance_up 1
 		}
 	})
 }
+
+func TestIntegration_ImagesCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "glance", "../../../sql/glance/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO images (id, name, size, status, created_at, deleted, min_disk, min_ram, visibility, os_hidden, owner) VALUES
+		('img-001', 'ubuntu-22.04', 2147483648, 'active', '2024-01-15 10:30:00', false, 0, 0, 'public', false, 'admin-proj'),
+		('img-002', 'deleted-image', 1000000, 'deleted', '2024-03-01 08:00:00', true, 0, 0, 'private', false, 'other-proj'),
+		('img-003', NULL, NULL, 'queued', '2024-04-10 12:00:00', false, 0, 0, 'community', true, NULL)`,
+	)
+
+	collector := NewImagesCollector(db, logger, project.NewResolver(logger, nil, 0))
+
+	expected := `# HELP openstack_glance_images images
+# TYPE openstack_glance_images gauge
+openstack_glance_images 2
+# HELP openstack_glance_up up
+# TYPE openstack_glance_up gauge
+openstack_glance_up 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"openstack_glance_images", "openstack_glance_up",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	count := testutil.CollectAndCount(collector, "openstack_glance_image_created_at")
+	if count != 2 {
+		t.Fatalf("expected 2 image_created_at metrics, got %d", count)
+	}
+}
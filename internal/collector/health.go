@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/redact"
+)
+
+// readyTimeout bounds the database pings of a readiness check.
+const readyTimeout = 5 * time.Second
+
+// ServiceHealth is the readiness of the databases of one service.
+type ServiceHealth struct {
+	Target  string `json:"target,omitempty"`
+	Service string `json:"service"`
+	// Up tells whether every database of the service answered a ping.
+	Up    bool   `json:"up"`
+	Error string `json:"error,omitempty"`
+	// LastSuccess is the time of the last collection of the service in
+	// which every query succeeded, and LastError the error of the last
+	// failed collection.
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// Readiness is the body of the readiness endpoint.
+type Readiness struct {
+	Ready    bool            `json:"ready"`
+	Services []ServiceHealth `json:"services"`
+}
+
+// collectionStatus records the outcome of the collections of every service.
+type collectionStatus struct {
+	mu       sync.Mutex
+	services map[string]*serviceStatus
+}
+
+type serviceStatus struct {
+	lastSuccess time.Time
+	lastError   string
+}
+
+func (s *collectionStatus) get(service string) *serviceStatus {
+	if s.services == nil {
+		s.services = make(map[string]*serviceStatus)
+	}
+	status, ok := s.services[service]
+	if !ok {
+		status = &serviceStatus{}
+		s.services[service] = status
+	}
+	return status
+}
+
+func (s *collectionStatus) success(service string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.get(service).lastSuccess = time.Now()
+}
+
+func (s *collectionStatus) failure(service, collector string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.get(service).lastError = collector + ": " + redact.String(err.Error())
+}
+
+// Health pings the databases of every service of the registry. Services whose
+// database could never be connected to are down.
+func (r *Registry) Health(ctx context.Context) []ServiceHealth {
+	r.mu.RLock()
+	services := make(map[string]bool)
+	for _, sc := range r.collectors {
+		if sc.service != "" {
+			services[sc.service] = true
+		}
+	}
+	r.mu.RUnlock()
+
+	pings := r.pools.Ping(ctx)
+
+	r.status.mu.Lock()
+	defer r.status.mu.Unlock()
+
+	health := make([]ServiceHealth, 0, len(services))
+	for _, service := range slices.Sorted(maps.Keys(services)) {
+		h := ServiceHealth{Service: service}
+
+		err, ok := pings[service]
+		if !ok {
+			err = errors.New("not connected to the database")
+		}
+		h.Up = err == nil
+		if err != nil {
+			h.Error = redact.String(err.Error())
+		}
+
+		if status, ok := r.status.services[service]; ok {
+			if !status.lastSuccess.IsZero() {
+				lastSuccess := status.lastSuccess
+				h.LastSuccess = &lastSuccess
+			}
+			h.LastError = status.lastError
+		}
+		health = append(health, h)
+	}
+	return health
+}
+
+// ReadyHandler returns an HTTP handler reporting the health of the services
+// of every target as JSON. The exporter is ready unless the databases of all
+// its services are down.
+func (r *Reloader) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.serve(w, req, r.ready)
+	})
+}
+
+// ready writes the readiness of the services of the current registries.
+func (r *Reloader) ready(w http.ResponseWriter, req *http.Request, loaded *loadedRegistry) {
+	ctx, cancel := context.WithTimeout(req.Context(), readyTimeout)
+	defer cancel()
+
+	readiness := Readiness{Services: loaded.registry.Health(ctx)}
+	for _, name := range slices.Sorted(maps.Keys(loaded.targets)) {
+		for _, h := range loaded.targets[name].Health(ctx) {
+			h.Target = name
+			readiness.Services = append(readiness.Services, h)
+		}
+	}
+
+	readiness.Ready = len(readiness.Services) == 0
+	for _, h := range readiness.Services {
+		readiness.Ready = readiness.Ready || h.Up
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !readiness.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(readiness); err != nil {
+		r.logger.Error("Failed to write readiness", "error", err)
+	}
+}
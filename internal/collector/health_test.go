@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/util"
+)
+
+func TestRegistry_Health(t *testing.T) {
+	reg := newRegistry(0)
+	reg.Service("keystone").MustRegister(util.Named("identity", prometheus.NewGauge(prometheus.GaugeOpts{Name: "openstack_identity_test", Help: "keystone"})))
+	reg.Service("nova").MustRegister(util.Named("servers", panicCollector{}))
+
+	_, err := reg.Gather()
+	require.NoError(t, err)
+
+	health := reg.Health(context.Background())
+	require.Len(t, health, 2)
+
+	assert.Equal(t, "keystone", health[0].Service)
+	assert.False(t, health[0].Up)
+	assert.Equal(t, "not connected to the database", health[0].Error)
+	assert.NotNil(t, health[0].LastSuccess)
+	assert.Empty(t, health[0].LastError)
+
+	assert.Equal(t, "nova", health[1].Service)
+	assert.Nil(t, health[1].LastSuccess)
+	assert.Equal(t, "servers: collector panicked", health[1].LastError)
+}
+
+func TestCollectionStatus_RedactsErrors(t *testing.T) {
+	var status collectionStatus
+	status.failure("nova", "servers", errors.New("dial mysql://nova:s3cret@db/nova: refused"))
+	assert.NotContains(t, status.services["nova"].lastError, "s3cret")
+}
+
+func TestReloader_ReadyHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		register bool
+		expected int
+	}{
+		{name: "no services", expected: http.StatusOK},
+		{name: "all services down", register: true, expected: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load := func() (Config, error) { return Config{}, nil }
+			r, err := NewReloader(load, promhttp.HandlerOpts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			require.NoError(t, err)
+			defer func() { _ = r.Close() }()
+
+			if tt.register {
+				r.current.Load().registry.Service("nova").MustRegister(&deadlineCollector{})
+			}
+
+			rec := httptest.NewRecorder()
+			r.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/ready", nil))
+			assert.Equal(t, tt.expected, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var readiness Readiness
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &readiness))
+			assert.Equal(t, tt.expected == http.StatusOK, readiness.Ready)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package heat
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -13,20 +14,30 @@ const (
 	Subsystem = "heat"
 )
 
-func RegisterCollectors(registry *prometheus.Registry, databaseURL string, logger *slog.Logger) {
-	if databaseURL == "" {
+// Collectors lists the names of the collectors that can be enabled or
+// disabled individually.
+var Collectors = []string{
+	"stacks",
+}
+
+func RegisterCollectors(registry prometheus.Registerer, database db.Config, enabled util.CollectorFilter, logger *slog.Logger) {
+	if database.URL == "" {
 		logger.Info("Collector not loaded", "service", "heat", "reason", "database URL not configured")
 		return
 	}
-
-	conn, err := db.Connect(databaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to database", "service", "heat", "error", err)
-		registry.MustRegister(util.NewDownCollector(Namespace, Subsystem))
+	if !enabled.Any(Collectors) {
+		logger.Info("Collector not loaded", "service", "heat", "reason", "all collectors disabled")
 		return
 	}
 
-	registry.MustRegister(NewStacksCollector(conn, logger))
+	util.RegisterWhenConnected(registry, Namespace, Subsystem, "heat", func(ctx context.Context) ([]prometheus.Collector, error) {
+		conn, err := db.ConnectContext(ctx, database)
+		if err != nil {
+			return nil, err
+		}
 
-	logger.Info("Registered collectors", "service", "heat")
+		return enabled.Select(map[string]prometheus.Collector{
+			"stacks": NewStacksCollector(conn, logger),
+		}), nil
+	}, logger)
 }
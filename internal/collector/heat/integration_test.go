@@ -0,0 +1,73 @@
+//go:build integration
+
+package heat
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	itest "github.com/vexxhost/openstack_database_exporter/internal/testutil"
+)
+
+func TestIntegration_StacksCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "heat", "../../../sql/heat/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO stack (id, name, action, status, tenant, raw_template_id, disable_rollback, deleted_at) VALUES
+		('stack-1', 'my-stack', 'CREATE', 'CREATE_COMPLETE', 'tenant-1', 1, false, NULL),
+		('stack-2', 'other-stack', 'CREATE', 'CREATE_COMPLETE', 'tenant-2', 2, false, NULL),
+		('stack-3', 'failed-stack', 'CREATE', 'CREATE_FAILED', 'tenant-1', 3, false, NULL),
+		('stack-4', NULL, NULL, NULL, NULL, 4, false, NULL),
+		('stack-5', 'deleted-stack', 'DELETE', 'CREATE_COMPLETE', 'tenant-1', 5, false, now())`,
+	)
+
+	collector := NewStacksCollector(db, logger)
+
+	// Deleted stacks and stacks without status are not counted.
+	expected := `# HELP openstack_heat_stack_status_counter stack_status_counter
+# TYPE openstack_heat_stack_status_counter gauge
+openstack_heat_stack_status_counter{status="ADOPT_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="ADOPT_FAILED"} 0
+openstack_heat_stack_status_counter{status="ADOPT_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="CHECK_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="CHECK_FAILED"} 0
+openstack_heat_stack_status_counter{status="CHECK_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="CREATE_COMPLETE"} 2
+openstack_heat_stack_status_counter{status="CREATE_FAILED"} 1
+openstack_heat_stack_status_counter{status="CREATE_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="DELETE_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="DELETE_FAILED"} 0
+openstack_heat_stack_status_counter{status="DELETE_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="INIT_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="INIT_FAILED"} 0
+openstack_heat_stack_status_counter{status="INIT_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="RESUME_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="RESUME_FAILED"} 0
+openstack_heat_stack_status_counter{status="RESUME_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="ROLLBACK_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="ROLLBACK_FAILED"} 0
+openstack_heat_stack_status_counter{status="ROLLBACK_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="SNAPSHOT_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="SNAPSHOT_FAILED"} 0
+openstack_heat_stack_status_counter{status="SNAPSHOT_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="SUSPEND_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="SUSPEND_FAILED"} 0
+openstack_heat_stack_status_counter{status="SUSPEND_IN_PROGRESS"} 0
+openstack_heat_stack_status_counter{status="UPDATE_COMPLETE"} 0
+openstack_heat_stack_status_counter{status="UPDATE_FAILED"} 0
+openstack_heat_stack_status_counter{status="UPDATE_IN_PROGRESS"} 0
+# HELP openstack_heat_up up
+# TYPE openstack_heat_up gauge
+openstack_heat_up 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
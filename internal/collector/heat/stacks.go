@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	heatdb "github.com/vexxhost/openstack_database_exporter/internal/db/heat"
 )
 
@@ -83,9 +84,13 @@ func (c *StacksCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *StacksCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *StacksCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "heat", c.queries)
 
-	stacks, err := c.queries.GetStackMetrics(ctx)
+	stacks, err := queries.GetStackMetrics(ctx)
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(stacksUpDesc, prometheus.GaugeValue, 0)
 		c.logger.Error("failed to query stacks", "error", err)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	ironicdb "github.com/vexxhost/openstack_database_exporter/internal/db/ironic"
 )
 
@@ -46,10 +47,14 @@ func (c *BaremetalCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *BaremetalCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *BaremetalCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "ironic", c.queries)
 
 	// Query node metrics once and reuse for the nodes sub-collector
-	nodes, err := c.queries.GetNodeMetrics(ctx)
+	nodes, err := queries.GetNodeMetrics(ctx)
 	if err != nil {
 		c.logger.Error("failed to query Ironic database", "error", err)
 		ch <- prometheus.MustNewConstMetric(c.upMetric, prometheus.GaugeValue, 0)
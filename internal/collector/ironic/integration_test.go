@@ -0,0 +1,45 @@
+//go:build integration
+
+package ironic
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	itest "github.com/vexxhost/openstack_database_exporter/internal/testutil"
+)
+
+func TestIntegration_BaremetalCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "ironic", "../../../sql/ironic/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO nodes (uuid, name, power_state, provision_state, maintenance, resource_class, console_enabled, retired, retired_reason) VALUES
+		('aaa-bbb-ccc', 'node-active', 'power on', 'active', false, 'baremetal', true, false, NULL),
+		('ggg-hhh-iii', 'node-retired', 'power off', 'manageable', true, 'baremetal', false, true, 'end of life'),
+		('jjj-kkk-lll', NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL),
+		('xxx-yyy-zzz', 'node-deleted', 'power off', 'deleted', false, 'baremetal', false, false, NULL)`,
+	)
+
+	collector := NewBaremetalCollector(db, logger)
+
+	// Deleted nodes are left out.
+	expected := `# HELP openstack_ironic_node Ironic node status
+# TYPE openstack_ironic_node gauge
+openstack_ironic_node{console_enabled="false",id="ggg-hhh-iii",maintenance="true",name="node-retired",power_state="power off",provision_state="manageable",resource_class="baremetal",retired="true",retired_reason="end of life"} 1
+openstack_ironic_node{console_enabled="false",id="jjj-kkk-lll",maintenance="false",name="",power_state="unknown",provision_state="unknown",resource_class="unknown",retired="false",retired_reason=""} 1
+openstack_ironic_node{console_enabled="true",id="aaa-bbb-ccc",maintenance="false",name="node-active",power_state="power on",provision_state="active",resource_class="baremetal",retired="false",retired_reason=""} 1
+# HELP openstack_ironic_up Whether the Ironic baremetal service is up
+# TYPE openstack_ironic_up gauge
+openstack_ironic_up 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
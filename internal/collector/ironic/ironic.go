@@ -1,6 +1,7 @@
 package ironic
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -13,20 +14,30 @@ const (
 	Subsystem = "ironic"
 )
 
-func RegisterCollectors(registry *prometheus.Registry, databaseURL string, logger *slog.Logger) {
-	if databaseURL == "" {
+// Collectors lists the names of the collectors that can be enabled or
+// disabled individually.
+var Collectors = []string{
+	"baremetal",
+}
+
+func RegisterCollectors(registry prometheus.Registerer, database db.Config, enabled util.CollectorFilter, logger *slog.Logger) {
+	if database.URL == "" {
 		logger.Info("Collector not loaded", "service", "ironic", "reason", "database URL not configured")
 		return
 	}
-
-	conn, err := db.Connect(databaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to database", "service", "ironic", "error", err)
-		registry.MustRegister(util.NewDownCollector(Namespace, Subsystem))
+	if !enabled.Any(Collectors) {
+		logger.Info("Collector not loaded", "service", "ironic", "reason", "all collectors disabled")
 		return
 	}
 
-	registry.MustRegister(NewBaremetalCollector(conn, logger))
+	util.RegisterWhenConnected(registry, Namespace, Subsystem, "ironic", func(ctx context.Context) ([]prometheus.Collector, error) {
+		conn, err := db.ConnectContext(ctx, database)
+		if err != nil {
+			return nil, err
+		}
 
-	logger.Info("Registered collectors", "service", "ironic")
+		return enabled.Select(map[string]prometheus.Collector{
+			"baremetal": NewBaremetalCollector(conn, logger),
+		}), nil
+	}, logger)
 }
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
 )
 
@@ -53,10 +54,10 @@ func (c *DomainsCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- domainsInfoDesc
 }
 
-func (c *DomainsCollector) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
+func (c *DomainsCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	queries := db.InSnapshot(ctx, "keystone", c.queries)
 
-	domains, err := c.queries.GetDomainMetrics(ctx)
+	domains, err := queries.GetDomainMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to query domains", "error", err)
 		return err
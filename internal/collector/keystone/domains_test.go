@@ -1,6 +1,7 @@
 package keystone
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -104,5 +105,5 @@ type testDomainsCollector struct {
 }
 
 func (t *testDomainsCollector) Collect(ch chan<- prometheus.Metric) {
-	_ = t.DomainsCollector.Collect(ch)
+	_ = t.DomainsCollector.Collect(context.Background(), ch)
 }
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
 )
 
@@ -40,10 +41,10 @@ func (c *GroupsCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- groupsCountDesc
 }
 
-func (c *GroupsCollector) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
+func (c *GroupsCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	queries := db.InSnapshot(ctx, "keystone", c.queries)
 
-	groups, err := c.queries.GetGroupMetrics(ctx)
+	groups, err := queries.GetGroupMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to query groups", "error", err)
 		return err
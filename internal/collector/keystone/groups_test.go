@@ -1,6 +1,7 @@
 package keystone
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -65,5 +66,5 @@ type testGroupsCollector struct {
 }
 
 func (t *testGroupsCollector) Collect(ch chan<- prometheus.Metric) {
-	_ = t.GroupsCollector.Collect(ch)
+	_ = t.GroupsCollector.Collect(context.Background(), ch)
 }
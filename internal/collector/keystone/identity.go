@@ -1,10 +1,13 @@
 package keystone
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
 
 var (
@@ -17,60 +20,68 @@ var (
 )
 
 type IdentityCollector struct {
-	db                *sql.DB
-	logger            *slog.Logger
-	domainsCollector  *DomainsCollector
-	projectsCollector *ProjectsCollector
-	groupsCollector   *GroupsCollector
-	regionsCollector  *RegionsCollector
-	usersCollector    *UsersCollector
+	db            *sql.DB
+	logger        *slog.Logger
+	subCollectors []subCollector
 }
 
-func NewIdentityCollector(db *sql.DB, logger *slog.Logger) *IdentityCollector {
-	return &IdentityCollector{
-		db:                db,
-		logger:            logger,
-		domainsCollector:  NewDomainsCollector(db, logger),
-		projectsCollector: NewProjectsCollector(db, logger),
-		groupsCollector:   NewGroupsCollector(db, logger),
-		regionsCollector:  NewRegionsCollector(db, logger),
-		usersCollector:    NewUsersCollector(db, logger),
+// subCollector is implemented by the collectors making up IdentityCollector.
+type subCollector interface {
+	Describe(ch chan<- *prometheus.Desc)
+	Collect(ctx context.Context, ch chan<- prometheus.Metric) error
+}
+
+type namedSubCollector struct {
+	name      string
+	collector subCollector
+}
+
+// NewIdentityCollector creates the keystone collector. Sub-collectors that
+// are not enabled are left out; a nil filter enables all of them.
+func NewIdentityCollector(db *sql.DB, logger *slog.Logger, enabled util.CollectorFilter, projectResolver *project.Resolver) *IdentityCollector {
+	c := &IdentityCollector{
+		db:     db,
+		logger: logger,
 	}
+
+	for _, sc := range []namedSubCollector{
+		{"domains", NewDomainsCollector(db, logger)},
+		{"projects", NewProjectsCollector(db, logger)},
+		{"groups", NewGroupsCollector(db, logger)},
+		{"regions", NewRegionsCollector(db, logger)},
+		{"users", NewUsersCollector(db, logger)},
+		{"user_info", NewUserInfoCollector(logger, projectResolver)},
+	} {
+		if enabled.Enabled(sc.name) {
+			c.subCollectors = append(c.subCollectors, sc.collector)
+		}
+	}
+
+	return c
 }
 
 func (c *IdentityCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- keystoneUpDesc
-	c.domainsCollector.Describe(ch)
-	c.projectsCollector.Describe(ch)
-	c.groupsCollector.Describe(ch)
-	c.regionsCollector.Describe(ch)
-	c.usersCollector.Describe(ch)
+	for _, sc := range c.subCollectors {
+		sc.Describe(ch)
+	}
 }
 
 func (c *IdentityCollector) Collect(ch chan<- prometheus.Metric) {
-	// Track if any sub-collector fails
-	var hasError bool
+	c.CollectContext(context.Background(), ch)
+}
 
-	// Collect metrics from all sub-collectors
-	if err := c.domainsCollector.Collect(ch); err != nil {
-		hasError = true
-	}
-	if err := c.projectsCollector.Collect(ch); err != nil {
-		hasError = true
-	}
-	if err := c.groupsCollector.Collect(ch); err != nil {
-		hasError = true
-	}
-	if err := c.regionsCollector.Collect(ch); err != nil {
-		hasError = true
-	}
-	if err := c.usersCollector.Collect(ch); err != nil {
-		hasError = true
+func (c *IdentityCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	// Collect metrics from all sub-collectors concurrently
+	fns := make([]func(context.Context) error, 0, len(c.subCollectors))
+	for _, sc := range c.subCollectors {
+		fns = append(fns, func(ctx context.Context) error { return sc.Collect(ctx, ch) })
 	}
+	err := util.Parallel(ctx, fns...)
 
 	// Emit single up metric based on overall success/failure
 	upValue := float64(1)
-	if hasError {
+	if err != nil {
 		upValue = 0
 	}
 	ch <- prometheus.MustNewConstMetric(
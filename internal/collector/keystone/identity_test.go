@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -16,6 +17,9 @@ func TestIdentityCollector(t *testing.T) {
 		{
 			Name: "successful collection with all sub-collectors working",
 			SetupMock: func(mock sqlmock.Sqlmock) {
+				// Sub-collectors run concurrently
+				mock.MatchExpectationsInOrder(false)
+
 				// Setup domain metrics query
 				domainRows := sqlmock.NewRows([]string{
 					"id", "name", "description", "enabled",
@@ -95,6 +99,9 @@ openstack_identity_users 2
 		{
 			Name: "domain collector fails, up metric should be 0",
 			SetupMock: func(mock sqlmock.Sqlmock) {
+				// Sub-collectors run concurrently
+				mock.MatchExpectationsInOrder(false)
+
 				// Domain query fails
 				mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetDomainMetrics)).WillReturnError(sql.ErrConnDone)
 
@@ -150,6 +157,9 @@ openstack_identity_users 1
 		{
 			Name: "all collectors fail, only up metric with value 0",
 			SetupMock: func(mock sqlmock.Sqlmock) {
+				// Sub-collectors run concurrently
+				mock.MatchExpectationsInOrder(false)
+
 				mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetDomainMetrics)).WillReturnError(sql.ErrConnDone)
 				mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetProjectMetrics)).WillReturnError(sql.ErrConnDone)
 				mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetGroupMetrics)).WillReturnError(sql.ErrConnDone)
@@ -164,6 +174,33 @@ openstack_identity_up 0
 	}
 
 	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *IdentityCollector {
-		return NewIdentityCollector(db, logger)
+		return NewIdentityCollector(db, logger, nil, project.NewResolver(logger, nil, 0))
+	})
+}
+
+func TestIdentityCollector_Filtered(t *testing.T) {
+	tests := []testutil.CollectorTestCase{
+		{
+			Name: "only the regions collector is enabled",
+			SetupMock: func(mock sqlmock.Sqlmock) {
+				regionRows := sqlmock.NewRows([]string{
+					"id", "description", "parent_region_id",
+				}).AddRow(
+					"RegionOne", "", "",
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetRegionMetrics)).WillReturnRows(regionRows)
+			},
+			ExpectedMetrics: `# HELP openstack_identity_regions regions
+# TYPE openstack_identity_regions gauge
+openstack_identity_regions 1
+# HELP openstack_identity_up up
+# TYPE openstack_identity_up gauge
+openstack_identity_up 1
+`,
+		},
+	}
+
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *IdentityCollector {
+		return NewIdentityCollector(db, logger, func(name string) bool { return name == "regions" }, project.NewResolver(logger, nil, 0))
 	})
 }
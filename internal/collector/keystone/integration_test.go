@@ -7,8 +7,11 @@ import (
 	"log/slog"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
 	itest "github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
 
@@ -19,7 +22,7 @@ func TestIntegration_IdentityCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewIdentityCollector(db, logger)
+		collector := NewIdentityCollector(db, logger, nil, project.NewResolver(logger, nil, 0))
 
 		expected := `# HELP openstack_identity_domains domains
 # TYPE openstack_identity_domains gauge
@@ -65,6 +68,13 @@ openstack_identity_users 0
 			('user-001', 1, 'domain-001', NOW()),
 			('user-002', 0, 'domain-002', NOW()),
 			('user-003', 1, 'domain-001', NOW())`,
+			// Name the users after a local, an LDAP and a federated identity
+			`INSERT INTO local_user (user_id, domain_id, name) VALUES
+			('user-001', 'domain-001', 'alice')`,
+			`INSERT INTO nonlocal_user (domain_id, name, user_id) VALUES
+			('domain-002', 'bob', 'user-002')`,
+			`INSERT INTO federated_user (user_id, idp_id, protocol_id, unique_id, display_name) VALUES
+			('user-003', 'idp', 'saml2', 'carol@example.com', 'Carol')`,
 			// Insert regions
 			`INSERT INTO region (id, description) VALUES
 			('RegionOne', 'Primary region'),
@@ -74,7 +84,7 @@ openstack_identity_users 0
 			('grp-001', 'domain-001', 'admins')`,
 		)
 
-		collector := NewIdentityCollector(db, logger)
+		collector := NewIdentityCollector(db, logger, nil, project.NewResolver(logger, nil, 0))
 
 		// Verify counts
 		expected := `# HELP openstack_identity_domains domains
@@ -119,4 +129,76 @@ openstack_identity_users 3
 			t.Fatalf("expected 2 project_info metrics, got %d", projectInfoCount)
 		}
 	})
+
+	t.Run("user info", func(t *testing.T) {
+		resolver := project.NewResolver(logger, keystonedb.New(db), time.Hour)
+		defer resolver.Close()
+		collector := NewIdentityCollector(db, logger, nil, resolver)
+
+		expected := `# HELP openstack_identity_user_info user_info
+# TYPE openstack_identity_user_info gauge
+openstack_identity_user_info{domain_id="domain-001",domain_name="TestDomain",user_id="user-001",user_name="alice"} 1
+openstack_identity_user_info{domain_id="domain-001",domain_name="TestDomain",user_id="user-003",user_name="Carol"} 1
+openstack_identity_user_info{domain_id="domain-002",domain_name="default",user_id="user-002",user_name="bob"} 1
+`
+		err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "openstack_identity_user_info")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestIntegration_IdentityCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "keystone", "../../../sql/keystone/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO project (id, name, enabled, domain_id, is_domain) VALUES
+		('domain-001', 'default', true, 'domain-001', true)`,
+		`INSERT INTO project (id, name, enabled, domain_id, parent_id, is_domain) VALUES
+		('proj-001', 'test-project-1', true, 'domain-001', 'domain-001', false)`,
+		`INSERT INTO project_tag (project_id, name) VALUES
+		('proj-001', 'env:prod'),
+		('proj-001', 'team:infra')`,
+		`INSERT INTO "user" (id, enabled, domain_id, created_at) VALUES
+		('user-001', true, 'domain-001', now()),
+		('user-002', false, 'domain-001', now())`,
+		`INSERT INTO region (id, description) VALUES ('RegionOne', 'Primary region')`,
+		`INSERT INTO "group" (id, domain_id, name) VALUES ('grp-001', 'domain-001', 'admins')`,
+	)
+
+	collector := NewIdentityCollector(db, logger, nil, project.NewResolver(logger, nil, 0))
+
+	expected := `# HELP openstack_identity_domains domains
+# TYPE openstack_identity_domains gauge
+openstack_identity_domains 1
+# HELP openstack_identity_groups groups
+# TYPE openstack_identity_groups gauge
+openstack_identity_groups 1
+# HELP openstack_identity_projects projects
+# TYPE openstack_identity_projects gauge
+openstack_identity_projects 1
+# HELP openstack_identity_regions regions
+# TYPE openstack_identity_regions gauge
+openstack_identity_regions 1
+# HELP openstack_identity_up up
+# TYPE openstack_identity_up gauge
+openstack_identity_up 1
+# HELP openstack_identity_users users
+# TYPE openstack_identity_users gauge
+openstack_identity_users 2
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"openstack_identity_domains",
+		"openstack_identity_projects",
+		"openstack_identity_users",
+		"openstack_identity_regions",
+		"openstack_identity_groups",
+		"openstack_identity_up",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
 )
 
@@ -57,10 +58,10 @@ func (c *ProjectsCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- projectsInfoDesc
 }
 
-func (c *ProjectsCollector) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
+func (c *ProjectsCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	queries := db.InSnapshot(ctx, "keystone", c.queries)
 
-	projects, err := c.queries.GetProjectMetrics(ctx)
+	projects, err := queries.GetProjectMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to query projects", "error", err)
 		return err
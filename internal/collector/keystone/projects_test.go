@@ -1,6 +1,7 @@
 package keystone
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -110,5 +111,5 @@ type testProjectsCollector struct {
 }
 
 func (t *testProjectsCollector) Collect(ch chan<- prometheus.Metric) {
-	_ = t.ProjectsCollector.Collect(ch)
+	_ = t.ProjectsCollector.Collect(context.Background(), ch)
 }
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
 )
 
@@ -40,10 +41,10 @@ func (c *RegionsCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- regionsCountDesc
 }
 
-func (c *RegionsCollector) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
+func (c *RegionsCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	queries := db.InSnapshot(ctx, "keystone", c.queries)
 
-	regions, err := c.queries.GetRegionMetrics(ctx)
+	regions, err := queries.GetRegionMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to query regions", "error", err)
 		return err
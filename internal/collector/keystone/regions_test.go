@@ -1,6 +1,7 @@
 package keystone
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -63,5 +64,5 @@ type testRegionsCollector struct {
 }
 
 func (t *testRegionsCollector) Collect(ch chan<- prometheus.Metric) {
-	_ = t.RegionsCollector.Collect(ch)
+	_ = t.RegionsCollector.Collect(context.Background(), ch)
 }
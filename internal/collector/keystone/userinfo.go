@@ -0,0 +1,68 @@
+package keystone
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+)
+
+var (
+	// userInfoDesc names the labels after the user_id label of the other
+	// services, e.g. nova_server_status, so that their series can be
+	// joined with it to get user names.
+	userInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, Subsystem, "user_info"),
+		"user_info",
+		[]string{
+			"user_id",
+			"user_name",
+			"domain_id",
+			"domain_name",
+		},
+		nil,
+	)
+)
+
+// UserInfoCollector exports the users cached by the project resolver, so
+// that the users are not read from keystone on every scrape.
+type UserInfoCollector struct {
+	projectResolver *project.Resolver
+	logger          *slog.Logger
+}
+
+func NewUserInfoCollector(logger *slog.Logger, projectResolver *project.Resolver) *UserInfoCollector {
+	return &UserInfoCollector{
+		projectResolver: projectResolver,
+		logger: logger.With(
+			"namespace", Namespace,
+			"subsystem", Subsystem,
+			"collector", "user_info",
+		),
+	}
+}
+
+func (c *UserInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- userInfoDesc
+}
+
+func (c *UserInfoCollector) Collect(_ context.Context, ch chan<- prometheus.Metric) error {
+	users := c.projectResolver.AllUsers()
+	for _, id := range slices.Sorted(maps.Keys(users)) {
+		user := users[id]
+		ch <- prometheus.MustNewConstMetric(
+			userInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			id,
+			user.Name,
+			user.DomainID,
+			c.projectResolver.ResolveDomain(user.DomainID),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package keystone
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
+)
+
+func TestUserInfoCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetProjectMetrics)).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "name", "description", "enabled", "domain_id", "parent_id", "is_domain", "tags",
+	}))
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetDomainMetrics)).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "name", "description", "enabled",
+	}).AddRow(
+		"default", "Default", "", true,
+	))
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetUserNames)).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "domain_id", "name",
+	}).AddRow(
+		"user-1", "default", "admin",
+	).AddRow(
+		"user-2", "federated", "jdoe@example.com",
+	))
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resolver := project.NewResolver(logger, keystonedb.New(db), time.Hour)
+	defer resolver.Close()
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// The domain of user-2 is unknown and keeps its ID.
+	expected := `# HELP openstack_identity_user_info user_info
+# TYPE openstack_identity_user_info gauge
+openstack_identity_user_info{domain_id="default",domain_name="Default",user_id="user-1",user_name="admin"} 1
+openstack_identity_user_info{domain_id="federated",domain_name="federated",user_id="user-2",user_name="jdoe@example.com"} 1
+`
+	collector := &testUserInfoCollector{NewUserInfoCollector(logger, resolver)}
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
+
+type testUserInfoCollector struct {
+	*UserInfoCollector
+}
+
+func (t *testUserInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	_ = t.UserInfoCollector.Collect(context.Background(), ch)
+}
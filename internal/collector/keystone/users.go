@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
 )
 
@@ -40,10 +41,10 @@ func (c *UsersCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- usersCountDesc
 }
 
-func (c *UsersCollector) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
+func (c *UsersCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	queries := db.InSnapshot(ctx, "keystone", c.queries)
 
-	users, err := c.queries.GetUserMetrics(ctx)
+	users, err := queries.GetUserMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to query users", "error", err)
 		return err
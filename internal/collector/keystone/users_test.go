@@ -1,6 +1,7 @@
 package keystone
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -65,5 +66,5 @@ type testUsersCollector struct {
 }
 
 func (t *testUsersCollector) Collect(ch chan<- prometheus.Metric) {
-	_ = t.UsersCollector.Collect(ch)
+	_ = t.UsersCollector.Collect(context.Background(), ch)
 }
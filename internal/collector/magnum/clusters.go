@@ -7,6 +7,8 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	magnumdb "github.com/vexxhost/openstack_database_exporter/internal/db/magnum"
 )
 
@@ -44,6 +46,8 @@ var (
 			"node_count",
 			"master_count",
 			"project_id",
+			"tenant",
+			"domain_id",
 		},
 		nil,
 	)
@@ -57,12 +61,13 @@ var (
 )
 
 type ClustersCollector struct {
-	db      *sql.DB
-	queries *magnumdb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *magnumdb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewClustersCollector(db *sql.DB, logger *slog.Logger) *ClustersCollector {
+func NewClustersCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *ClustersCollector {
 	return &ClustersCollector{
 		db:      db,
 		queries: magnumdb.New(db),
@@ -71,6 +76,7 @@ func NewClustersCollector(db *sql.DB, logger *slog.Logger) *ClustersCollector {
 			"subsystem", Subsystem,
 			"collector", "clusters",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -80,9 +86,13 @@ func (c *ClustersCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *ClustersCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *ClustersCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "magnum", c.queries)
 
-	clusters, err := c.queries.GetClusterMetrics(ctx)
+	clusters, err := queries.GetClusterMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to get cluster metrics", "error", err)
 		return
@@ -111,6 +121,7 @@ func (c *ClustersCollector) Collect(ch chan<- prometheus.Metric) {
 		if cluster.ProjectID.Valid {
 			projectID = cluster.ProjectID.String
 		}
+		projectName, domainID := c.projectResolver.Resolve(projectID)
 
 		// Convert int64 to int for counts
 		masterCount := int(cluster.MasterCount)
@@ -132,6 +143,8 @@ func (c *ClustersCollector) Collect(ch chan<- prometheus.Metric) {
 			nodeCountStr,
 			masterCountStr,
 			projectID,
+			projectName,
+			domainID,
 		)
 	}
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	magnumdb "github.com/vexxhost/openstack_database_exporter/internal/db/magnum"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -27,7 +28,7 @@ func TestClustersCollector(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_status cluster_status
 # TYPE openstack_container_infra_cluster_status gauge
-openstack_container_infra_cluster_status{master_count="1",name="k8s",node_count="1",project_id="0cbd49cbf76d405d9c86562e1d579bd3",stack_id="31c1ee6c-081e-4f39-9f0f-f1d87a7defa1",status="CREATE_FAILED",uuid="273c39d5-fa17-4372-b6b1-93a572de2cef"} 1
+openstack_container_infra_cluster_status{domain_id="",master_count="1",name="k8s",node_count="1",project_id="0cbd49cbf76d405d9c86562e1d579bd3",stack_id="31c1ee6c-081e-4f39-9f0f-f1d87a7defa1",status="CREATE_FAILED",tenant="0cbd49cbf76d405d9c86562e1d579bd3",uuid="273c39d5-fa17-4372-b6b1-93a572de2cef"} 1
 # HELP openstack_container_infra_total_clusters total_clusters
 # TYPE openstack_container_infra_total_clusters gauge
 openstack_container_infra_total_clusters 1
@@ -50,9 +51,9 @@ openstack_container_infra_total_clusters 1
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_status cluster_status
 # TYPE openstack_container_infra_cluster_status gauge
-openstack_container_infra_cluster_status{master_count="3",name="test-cluster-1",node_count="5",project_id="project-1",stack_id="stack-1",status="CREATE_COMPLETE",uuid="cluster-1"} 0
-openstack_container_infra_cluster_status{master_count="1",name="test-cluster-2",node_count="2",project_id="project-2",stack_id="stack-2",status="UPDATE_IN_PROGRESS",uuid="cluster-2"} 3
-openstack_container_infra_cluster_status{master_count="2",name="test-cluster-3",node_count="3",project_id="project-1",stack_id="stack-3",status="DELETE_FAILED",uuid="cluster-3"} 7
+openstack_container_infra_cluster_status{domain_id="",master_count="3",name="test-cluster-1",node_count="5",project_id="project-1",stack_id="stack-1",status="CREATE_COMPLETE",tenant="project-1",uuid="cluster-1"} 0
+openstack_container_infra_cluster_status{domain_id="",master_count="1",name="test-cluster-2",node_count="2",project_id="project-2",stack_id="stack-2",status="UPDATE_IN_PROGRESS",tenant="project-2",uuid="cluster-2"} 3
+openstack_container_infra_cluster_status{domain_id="",master_count="2",name="test-cluster-3",node_count="3",project_id="project-1",stack_id="stack-3",status="DELETE_FAILED",tenant="project-1",uuid="cluster-3"} 7
 # HELP openstack_container_infra_total_clusters total_clusters
 # TYPE openstack_container_infra_total_clusters gauge
 openstack_container_infra_total_clusters 3
@@ -85,7 +86,7 @@ openstack_container_infra_total_clusters 0
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_status cluster_status
 # TYPE openstack_container_infra_cluster_status gauge
-openstack_container_infra_cluster_status{master_count="0",name="",node_count="0",project_id="",stack_id="",status="UNKNOWN_STATUS",uuid=""} -1
+openstack_container_infra_cluster_status{domain_id="",master_count="0",name="",node_count="0",project_id="",stack_id="",status="UNKNOWN_STATUS",tenant="",uuid=""} -1
 # HELP openstack_container_infra_total_clusters total_clusters
 # TYPE openstack_container_infra_total_clusters gauge
 openstack_container_infra_total_clusters 1
@@ -101,7 +102,7 @@ openstack_container_infra_total_clusters 1
 	}
 
 	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) prometheus.Collector {
-		return &testClustersCollector{NewClustersCollector(db, logger)}
+		return &testClustersCollector{NewClustersCollector(db, logger, project.NewResolver(logger, nil, 0))}
 	})
 }
 
@@ -7,6 +7,8 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	magnumdb "github.com/vexxhost/openstack_database_exporter/internal/db/magnum"
 )
 
@@ -23,12 +25,13 @@ var (
 // and emits all magnum/container_infra metrics: total_clusters, cluster_status,
 // cluster_masters, and cluster_nodes.
 type ContainerInfraCollector struct {
-	db      *sql.DB
-	queries *magnumdb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *magnumdb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewContainerInfraCollector(db *sql.DB, logger *slog.Logger) *ContainerInfraCollector {
+func NewContainerInfraCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *ContainerInfraCollector {
 	return &ContainerInfraCollector{
 		db:      db,
 		queries: magnumdb.New(db),
@@ -37,6 +40,7 @@ func NewContainerInfraCollector(db *sql.DB, logger *slog.Logger) *ContainerInfra
 			"subsystem", Subsystem,
 			"collector", "container_infra",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -49,9 +53,13 @@ func (c *ContainerInfraCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *ContainerInfraCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *ContainerInfraCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "magnum", c.queries)
 
-	clusters, err := c.queries.GetClusterMetrics(ctx)
+	clusters, err := queries.GetClusterMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to get cluster metrics", "error", err)
 		ch <- prometheus.MustNewConstMetric(containerInfraUpDesc, prometheus.GaugeValue, 0)
@@ -82,6 +90,7 @@ func (c *ContainerInfraCollector) Collect(ch chan<- prometheus.Metric) {
 		if cluster.ProjectID.Valid {
 			projectID = cluster.ProjectID.String
 		}
+		projectName, domainID := c.projectResolver.Resolve(projectID)
 
 		masterCount := int(cluster.MasterCount)
 		nodeCount := int(cluster.NodeCount)
@@ -102,6 +111,8 @@ func (c *ContainerInfraCollector) Collect(ch chan<- prometheus.Metric) {
 			nodeCountStr,
 			masterCountStr,
 			projectID,
+			projectName,
+			domainID,
 		)
 
 		// cluster_masters metric
@@ -115,6 +126,8 @@ func (c *ContainerInfraCollector) Collect(ch chan<- prometheus.Metric) {
 			cluster.Status,
 			nodeCountStr,
 			projectID,
+			projectName,
+			domainID,
 		)
 
 		// cluster_nodes metric
@@ -128,6 +141,8 @@ func (c *ContainerInfraCollector) Collect(ch chan<- prometheus.Metric) {
 			cluster.Status,
 			masterCountStr,
 			projectID,
+			projectName,
+			domainID,
 		)
 	}
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	itest "github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
 
@@ -19,7 +20,7 @@ func TestIntegration_ContainerInfraCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewContainerInfraCollector(db, logger)
+		collector := NewContainerInfraCollector(db, logger, project.NewResolver(logger, nil, 0))
 
 		expected := `# HELP openstack_container_infra_total_clusters total_clusters
 # TYPE openstack_container_infra_total_clusters gauge
@@ -49,7 +50,7 @@ openstack_container_infra_up 1
 			('ng-004', 'worker-dev', 'clust-002', 'proj-002', 'worker', 2, 1)`,
 		)
 
-		collector := NewContainerInfraCollector(db, logger)
+		collector := NewContainerInfraCollector(db, logger, project.NewResolver(logger, nil, 0))
 
 		// 1 up + 1 total_clusters + 2 clusters × 3 (status + masters + nodes) = 8
 		count := testutil.CollectAndCount(collector)
@@ -69,3 +70,45 @@ openstack_container_infra_total_clusters 2
 		}
 	})
 }
+
+func TestIntegration_ContainerInfraCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "magnum", "../../../sql/magnum/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO cluster (uuid, name, stack_id, project_id, status) VALUES
+		('clust-001', 'prod-cluster', 'stack-001', 'proj-001', 'CREATE_COMPLETE'),
+		('clust-002', 'dev-cluster', NULL, 'proj-002', 'CREATE_IN_PROGRESS')`,
+		`INSERT INTO nodegroup (uuid, name, cluster_id, project_id, role, node_count, is_default) VALUES
+		('ng-001', 'master-prod', 'clust-001', 'proj-001', 'master', 3, true),
+		('ng-002', 'worker-prod', 'clust-001', 'proj-001', 'worker', 5, true),
+		('ng-003', 'worker-prod-2', 'clust-001', 'proj-001', 'worker', 2, false),
+		('ng-004', 'worker-dev', 'clust-002', 'proj-002', 'worker', 2, true)`,
+	)
+
+	collector := NewContainerInfraCollector(db, logger, project.NewResolver(logger, nil, 0))
+
+	// The node counts of the node groups of a role are summed, and a
+	// cluster without master node group has none.
+	expected := `# HELP openstack_container_infra_cluster_masters cluster_masters
+# TYPE openstack_container_infra_cluster_masters gauge
+openstack_container_infra_cluster_masters{domain_id="",name="dev-cluster",node_count="2",project_id="proj-002",stack_id="",status="CREATE_IN_PROGRESS",tenant="proj-002",uuid="clust-002"} 0
+openstack_container_infra_cluster_masters{domain_id="",name="prod-cluster",node_count="7",project_id="proj-001",stack_id="stack-001",status="CREATE_COMPLETE",tenant="proj-001",uuid="clust-001"} 3
+# HELP openstack_container_infra_total_clusters total_clusters
+# TYPE openstack_container_infra_total_clusters gauge
+openstack_container_infra_total_clusters 2
+# HELP openstack_container_infra_up up
+# TYPE openstack_container_infra_up gauge
+openstack_container_infra_up 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"openstack_container_infra_cluster_masters",
+		"openstack_container_infra_total_clusters",
+		"openstack_container_infra_up",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
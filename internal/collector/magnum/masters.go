@@ -7,6 +7,8 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	magnumdb "github.com/vexxhost/openstack_database_exporter/internal/db/magnum"
 )
 
@@ -21,18 +23,21 @@ var (
 			"status",
 			"node_count",
 			"project_id",
+			"tenant",
+			"domain_id",
 		},
 		nil,
 	)
 )
 
 type MastersCollector struct {
-	db      *sql.DB
-	queries *magnumdb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *magnumdb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewMastersCollector(db *sql.DB, logger *slog.Logger) *MastersCollector {
+func NewMastersCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *MastersCollector {
 	return &MastersCollector{
 		db:      db,
 		queries: magnumdb.New(db),
@@ -41,6 +46,7 @@ func NewMastersCollector(db *sql.DB, logger *slog.Logger) *MastersCollector {
 			"subsystem", Subsystem,
 			"collector", "masters",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -49,9 +55,13 @@ func (c *MastersCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *MastersCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *MastersCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "magnum", c.queries)
 
-	clusters, err := c.queries.GetClusterMetrics(ctx)
+	clusters, err := queries.GetClusterMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to get cluster metrics for masters", "error", err)
 		return
@@ -73,6 +83,7 @@ func (c *MastersCollector) Collect(ch chan<- prometheus.Metric) {
 		if cluster.ProjectID.Valid {
 			projectID = cluster.ProjectID.String
 		}
+		projectName, domainID := c.projectResolver.Resolve(projectID)
 
 		// Convert int64 to int for counts
 		masterCount := int(cluster.MasterCount)
@@ -91,6 +102,8 @@ func (c *MastersCollector) Collect(ch chan<- prometheus.Metric) {
 			cluster.Status,
 			nodeCountStr,
 			projectID,
+			projectName,
+			domainID,
 		)
 	}
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	magnumdb "github.com/vexxhost/openstack_database_exporter/internal/db/magnum"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -27,7 +28,7 @@ func TestMastersCollector(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_masters cluster_masters
 # TYPE openstack_container_infra_cluster_masters gauge
-openstack_container_infra_cluster_masters{name="k8s",node_count="1",project_id="0cbd49cbf76d405d9c86562e1d579bd3",stack_id="31c1ee6c-081e-4f39-9f0f-f1d87a7defa1",status="CREATE_FAILED",uuid="273c39d5-fa17-4372-b6b1-93a572de2cef"} 1
+openstack_container_infra_cluster_masters{domain_id="",name="k8s",node_count="1",project_id="0cbd49cbf76d405d9c86562e1d579bd3",stack_id="31c1ee6c-081e-4f39-9f0f-f1d87a7defa1",status="CREATE_FAILED",tenant="0cbd49cbf76d405d9c86562e1d579bd3",uuid="273c39d5-fa17-4372-b6b1-93a572de2cef"} 1
 `,
 		},
 		{
@@ -45,8 +46,8 @@ openstack_container_infra_cluster_masters{name="k8s",node_count="1",project_id="
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_masters cluster_masters
 # TYPE openstack_container_infra_cluster_masters gauge
-openstack_container_infra_cluster_masters{name="test-cluster-1",node_count="5",project_id="project-1",stack_id="stack-1",status="CREATE_COMPLETE",uuid="cluster-1"} 3
-openstack_container_infra_cluster_masters{name="test-cluster-2",node_count="2",project_id="project-2",stack_id="stack-2",status="UPDATE_IN_PROGRESS",uuid="cluster-2"} 1
+openstack_container_infra_cluster_masters{domain_id="",name="test-cluster-1",node_count="5",project_id="project-1",stack_id="stack-1",status="CREATE_COMPLETE",tenant="project-1",uuid="cluster-1"} 3
+openstack_container_infra_cluster_masters{domain_id="",name="test-cluster-2",node_count="2",project_id="project-2",stack_id="stack-2",status="UPDATE_IN_PROGRESS",tenant="project-2",uuid="cluster-2"} 1
 `,
 		},
 		{
@@ -73,7 +74,7 @@ openstack_container_infra_cluster_masters{name="test-cluster-2",node_count="2",p
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_masters cluster_masters
 # TYPE openstack_container_infra_cluster_masters gauge
-openstack_container_infra_cluster_masters{name="",node_count="0",project_id="",stack_id="",status="UNKNOWN_STATUS",uuid=""} 0
+openstack_container_infra_cluster_masters{domain_id="",name="",node_count="0",project_id="",stack_id="",status="UNKNOWN_STATUS",tenant="",uuid=""} 0
 `,
 		},
 		{
@@ -86,7 +87,7 @@ openstack_container_infra_cluster_masters{name="",node_count="0",project_id="",s
 	}
 
 	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) prometheus.Collector {
-		return &testMastersCollector{NewMastersCollector(db, logger)}
+		return &testMastersCollector{NewMastersCollector(db, logger, project.NewResolver(logger, nil, 0))}
 	})
 }
 
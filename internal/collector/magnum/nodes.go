@@ -7,6 +7,8 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	magnumdb "github.com/vexxhost/openstack_database_exporter/internal/db/magnum"
 )
 
@@ -21,18 +23,21 @@ var (
 			"status",
 			"master_count",
 			"project_id",
+			"tenant",
+			"domain_id",
 		},
 		nil,
 	)
 )
 
 type NodesCollector struct {
-	db      *sql.DB
-	queries *magnumdb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *magnumdb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewNodesCollector(db *sql.DB, logger *slog.Logger) *NodesCollector {
+func NewNodesCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *NodesCollector {
 	return &NodesCollector{
 		db:      db,
 		queries: magnumdb.New(db),
@@ -41,6 +46,7 @@ func NewNodesCollector(db *sql.DB, logger *slog.Logger) *NodesCollector {
 			"subsystem", Subsystem,
 			"collector", "nodes",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -49,9 +55,13 @@ func (c *NodesCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *NodesCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *NodesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "magnum", c.queries)
 
-	clusters, err := c.queries.GetClusterMetrics(ctx)
+	clusters, err := queries.GetClusterMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to get cluster metrics for nodes", "error", err)
 		return
@@ -73,6 +83,7 @@ func (c *NodesCollector) Collect(ch chan<- prometheus.Metric) {
 		if cluster.ProjectID.Valid {
 			projectID = cluster.ProjectID.String
 		}
+		projectName, domainID := c.projectResolver.Resolve(projectID)
 
 		// Convert int64 to int for counts
 		masterCount := int(cluster.MasterCount)
@@ -91,6 +102,8 @@ func (c *NodesCollector) Collect(ch chan<- prometheus.Metric) {
 			cluster.Status,
 			masterCountStr,
 			projectID,
+			projectName,
+			domainID,
 		)
 	}
 }
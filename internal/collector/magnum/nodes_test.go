@@ -8,6 +8,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	magnumdb "github.com/vexxhost/openstack_database_exporter/internal/db/magnum"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -27,7 +28,7 @@ func TestNodesCollector(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_nodes cluster_nodes
 # TYPE openstack_container_infra_cluster_nodes gauge
-openstack_container_infra_cluster_nodes{master_count="1",name="k8s",project_id="0cbd49cbf76d405d9c86562e1d579bd3",stack_id="31c1ee6c-081e-4f39-9f0f-f1d87a7defa1",status="CREATE_FAILED",uuid="273c39d5-fa17-4372-b6b1-93a572de2cef"} 1
+openstack_container_infra_cluster_nodes{domain_id="",master_count="1",name="k8s",project_id="0cbd49cbf76d405d9c86562e1d579bd3",stack_id="31c1ee6c-081e-4f39-9f0f-f1d87a7defa1",status="CREATE_FAILED",tenant="0cbd49cbf76d405d9c86562e1d579bd3",uuid="273c39d5-fa17-4372-b6b1-93a572de2cef"} 1
 `,
 		},
 		{
@@ -45,8 +46,8 @@ openstack_container_infra_cluster_nodes{master_count="1",name="k8s",project_id="
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_nodes cluster_nodes
 # TYPE openstack_container_infra_cluster_nodes gauge
-openstack_container_infra_cluster_nodes{master_count="3",name="test-cluster-1",project_id="project-1",stack_id="stack-1",status="CREATE_COMPLETE",uuid="cluster-1"} 5
-openstack_container_infra_cluster_nodes{master_count="1",name="test-cluster-2",project_id="project-2",stack_id="stack-2",status="UPDATE_IN_PROGRESS",uuid="cluster-2"} 2
+openstack_container_infra_cluster_nodes{domain_id="",master_count="3",name="test-cluster-1",project_id="project-1",stack_id="stack-1",status="CREATE_COMPLETE",tenant="project-1",uuid="cluster-1"} 5
+openstack_container_infra_cluster_nodes{domain_id="",master_count="1",name="test-cluster-2",project_id="project-2",stack_id="stack-2",status="UPDATE_IN_PROGRESS",tenant="project-2",uuid="cluster-2"} 2
 `,
 		},
 		{
@@ -73,7 +74,7 @@ openstack_container_infra_cluster_nodes{master_count="1",name="test-cluster-2",p
 			},
 			ExpectedMetrics: `# HELP openstack_container_infra_cluster_nodes cluster_nodes
 # TYPE openstack_container_infra_cluster_nodes gauge
-openstack_container_infra_cluster_nodes{master_count="0",name="",project_id="",stack_id="",status="UNKNOWN_STATUS",uuid=""} 0
+openstack_container_infra_cluster_nodes{domain_id="",master_count="0",name="",project_id="",stack_id="",status="UNKNOWN_STATUS",tenant="",uuid=""} 0
 `,
 		},
 		{
@@ -86,7 +87,7 @@ openstack_container_infra_cluster_nodes{master_count="0",name="",project_id="",s
 	}
 
 	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) prometheus.Collector {
-		return &testNodesCollector{NewNodesCollector(db, logger)}
+		return &testNodesCollector{NewNodesCollector(db, logger, project.NewResolver(logger, nil, 0))}
 	})
 }
 
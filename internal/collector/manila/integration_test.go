@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	itest "github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
 
@@ -23,7 +24,7 @@ func TestIntegration_SharesCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewSharesCollector(db, logger)
+		collector := NewSharesCollector(db, logger, project.NewResolver(logger, nil, 0))
 
 		// Should emit: up=1, shares_counter=0, and 19 status_counter metrics
 		count := testutil.CollectAndCount(collector)
@@ -69,7 +70,7 @@ openstack_sharev2_up 1
 			('si-002', 'share-002', 'creating', 'stype-002', 'az-002', 'False', 0)`,
 		)
 
-		collector := NewSharesCollector(db, logger)
+		collector := NewSharesCollector(db, logger, project.NewResolver(logger, nil, 0))
 
 		// 2 active shares × 2 (share_gb + share_status) = 4
 		// + 1 up + 1 shares_counter + 19 status_counter = 25
@@ -90,3 +91,52 @@ openstack_sharev2_shares_counter 2
 		}
 	})
 }
+
+func TestIntegration_SharesCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	// prereqs.sql creates the share_groups stub table required by shares FK
+	db := itest.NewPostgreSQLContainer(t, "manila",
+		"../../../sql/manila/postgresql/prereqs.sql",
+		"../../../sql/manila/postgresql/schema.sql",
+	)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO availability_zones (id, name, deleted) VALUES
+		('az-001', 'nova', 'False'),
+		('az-002', 'az2', 'False')`,
+		`INSERT INTO share_types (id, name, deleted) VALUES
+		('stype-001', 'default_share_type', 'False'),
+		('stype-002', 'premium', 'False')`,
+		`INSERT INTO shares (id, display_name, project_id, size, share_proto, deleted, created_at) VALUES
+		('share-001', 'my-share', 'proj-001', 100, 'NFS', 'False', now()),
+		('share-002', 'data-share', 'proj-002', 500, 'CIFS', 'False', now()),
+		('share-003', 'deleted-share', 'proj-001', 50, 'NFS', 'True', now())`,
+		`INSERT INTO share_instances (id, share_id, status, share_type_id, availability_zone_id, deleted, cast_rules_to_readonly) VALUES
+		('si-001', 'share-001', 'available', 'stype-001', 'az-001', 'False', false),
+		('si-002', 'share-002', 'creating', 'stype-002', 'az-002', 'False', false)`,
+	)
+
+	collector := NewSharesCollector(db, logger, project.NewResolver(logger, nil, 0))
+
+	expected := `# HELP openstack_sharev2_share_gb share_gb
+# TYPE openstack_sharev2_share_gb gauge
+openstack_sharev2_share_gb{availability_zone="az2",domain_id="",id="share-002",name="data-share",project_id="proj-002",share_proto="CIFS",share_type="stype-002",share_type_name="premium",status="creating",tenant="proj-002"} 500
+openstack_sharev2_share_gb{availability_zone="nova",domain_id="",id="share-001",name="my-share",project_id="proj-001",share_proto="NFS",share_type="stype-001",share_type_name="default_share_type",status="available",tenant="proj-001"} 100
+# HELP openstack_sharev2_shares_counter shares_counter
+# TYPE openstack_sharev2_shares_counter gauge
+openstack_sharev2_shares_counter 2
+# HELP openstack_sharev2_up up
+# TYPE openstack_sharev2_up gauge
+openstack_sharev2_up 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"openstack_sharev2_share_gb",
+		"openstack_sharev2_shares_counter",
+		"openstack_sharev2_up",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
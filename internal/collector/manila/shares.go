@@ -7,6 +7,8 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	maniladb "github.com/vexxhost/openstack_database_exporter/internal/db/manila"
 	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
@@ -63,6 +65,8 @@ var (
 			"share_proto",
 			"share_type_name",
 			"project_id",
+			"tenant",
+			"domain_id",
 		},
 		nil,
 	)
@@ -79,6 +83,8 @@ var (
 			"share_proto",
 			"share_type_name",
 			"project_id",
+			"tenant",
+			"domain_id",
 		},
 		nil,
 	)
@@ -101,12 +107,13 @@ var (
 )
 
 type SharesCollector struct {
-	db      *sql.DB
-	queries *maniladb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *maniladb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewSharesCollector(db *sql.DB, logger *slog.Logger) *SharesCollector {
+func NewSharesCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *SharesCollector {
 	return &SharesCollector{
 		db:      db,
 		queries: maniladb.New(db),
@@ -115,6 +122,7 @@ func NewSharesCollector(db *sql.DB, logger *slog.Logger) *SharesCollector {
 			"subsystem", Subsystem,
 			"collector", "shares",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -127,9 +135,13 @@ func (c *SharesCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *SharesCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *SharesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "manila", c.queries)
 
-	shares, err := c.queries.GetShareMetrics(ctx)
+	shares, err := queries.GetShareMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to collect manila shares", "error", err)
 		ch <- prometheus.MustNewConstMetric(manilaUpDesc, prometheus.GaugeValue, 0)
@@ -157,6 +169,7 @@ func (c *SharesCollector) Collect(ch chan<- prometheus.Metric) {
 		if share.ProjectID.Valid {
 			projectID = share.ProjectID.String
 		}
+		projectName, domainID := c.projectResolver.Resolve(projectID)
 		size := int32(0)
 		if share.Size.Valid {
 			size = share.Size.Int32
@@ -181,7 +194,8 @@ func (c *SharesCollector) Collect(ch chan<- prometheus.Metric) {
 		}
 
 		// share_gb metric - size in GB per share
-		// Label order matches upstream: id, name, status, availability_zone, share_type, share_proto, share_type_name, project_id
+		// Label order matches upstream: id, name, status, availability_zone, share_type, share_proto, share_type_name, project_id,
+		// then tenant and domain_id
 		ch <- prometheus.MustNewConstMetric(
 			shareGbDesc,
 			prometheus.GaugeValue,
@@ -194,10 +208,13 @@ func (c *SharesCollector) Collect(ch chan<- prometheus.Metric) {
 			shareProto,
 			shareTypeName,
 			projectID,
+			projectName,
+			domainID,
 		)
 
 		// share_status metric - uses mapVolumeStatus like upstream openstack-exporter
-		// Label order matches upstream: id, name, status, size, share_type, share_proto, share_type_name, project_id
+		// Label order matches upstream: id, name, status, size, share_type, share_proto, share_type_name, project_id,
+		// then tenant and domain_id
 		sizeStr := "0"
 		if share.Size.Valid {
 			sizeStr = fmt.Sprintf("%d", share.Size.Int32)
@@ -215,6 +232,8 @@ func (c *SharesCollector) Collect(ch chan<- prometheus.Metric) {
 			shareProto,
 			shareTypeName,
 			projectID,
+			projectName,
+			domainID,
 		)
 	}
 
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	maniladb "github.com/vexxhost/openstack_database_exporter/internal/db/manila"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -26,10 +27,10 @@ func TestSharesCollector(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_sharev2_share_gb share_gb
 # TYPE openstack_sharev2_share_gb gauge
-openstack_sharev2_share_gb{availability_zone="az1",id="4be93e2e-ffff-ffff-ffff-603e3ec2a5d6",name="share-test",project_id="ffff8fa0ca1a468db8ad00970c1effff",share_proto="NFS",share_type="az1",share_type_name="",status="available"} 1
+openstack_sharev2_share_gb{availability_zone="az1",domain_id="",id="4be93e2e-ffff-ffff-ffff-603e3ec2a5d6",name="share-test",project_id="ffff8fa0ca1a468db8ad00970c1effff",share_proto="NFS",share_type="az1",share_type_name="",status="available",tenant="ffff8fa0ca1a468db8ad00970c1effff"} 1
 # HELP openstack_sharev2_share_status share_status
 # TYPE openstack_sharev2_share_status gauge
-openstack_sharev2_share_status{id="4be93e2e-ffff-ffff-ffff-603e3ec2a5d6",name="share-test",project_id="ffff8fa0ca1a468db8ad00970c1effff",share_proto="NFS",share_type="az1",share_type_name="",size="1",status="available"} 1
+openstack_sharev2_share_status{domain_id="",id="4be93e2e-ffff-ffff-ffff-603e3ec2a5d6",name="share-test",project_id="ffff8fa0ca1a468db8ad00970c1effff",share_proto="NFS",share_type="az1",share_type_name="",size="1",status="available",tenant="ffff8fa0ca1a468db8ad00970c1effff"} 1
 # HELP openstack_sharev2_share_status_counter share_status_counter
 # TYPE openstack_sharev2_share_status_counter gauge
 openstack_sharev2_share_status_counter{status="available"} 1
@@ -76,14 +77,14 @@ openstack_sharev2_up 1
 			},
 			ExpectedMetrics: `# HELP openstack_sharev2_share_gb share_gb
 # TYPE openstack_sharev2_share_gb gauge
-openstack_sharev2_share_gb{availability_zone="nova",id="share-1",name="test-share-1",project_id="project-1",share_proto="NFS",share_type="type-uuid-1",share_type_name="default",status="available"} 10
-openstack_sharev2_share_gb{availability_zone="nova",id="share-2",name="test-share-2",project_id="project-2",share_proto="CIFS",share_type="type-uuid-2",share_type_name="ssd",status="creating"} 20
-openstack_sharev2_share_gb{availability_zone="nova",id="share-3",name="test-share-3",project_id="project-1",share_proto="NFS",share_type="type-uuid-1",share_type_name="default",status="error"} 5
+openstack_sharev2_share_gb{availability_zone="nova",domain_id="",id="share-1",name="test-share-1",project_id="project-1",share_proto="NFS",share_type="type-uuid-1",share_type_name="default",status="available",tenant="project-1"} 10
+openstack_sharev2_share_gb{availability_zone="nova",domain_id="",id="share-2",name="test-share-2",project_id="project-2",share_proto="CIFS",share_type="type-uuid-2",share_type_name="ssd",status="creating",tenant="project-2"} 20
+openstack_sharev2_share_gb{availability_zone="nova",domain_id="",id="share-3",name="test-share-3",project_id="project-1",share_proto="NFS",share_type="type-uuid-1",share_type_name="default",status="error",tenant="project-1"} 5
 # HELP openstack_sharev2_share_status share_status
 # TYPE openstack_sharev2_share_status gauge
-openstack_sharev2_share_status{id="share-1",name="test-share-1",project_id="project-1",share_proto="NFS",share_type="type-uuid-1",share_type_name="default",size="10",status="available"} 1
-openstack_sharev2_share_status{id="share-2",name="test-share-2",project_id="project-2",share_proto="CIFS",share_type="type-uuid-2",share_type_name="ssd",size="20",status="creating"} 0
-openstack_sharev2_share_status{id="share-3",name="test-share-3",project_id="project-1",share_proto="NFS",share_type="type-uuid-1",share_type_name="default",size="5",status="error"} 8
+openstack_sharev2_share_status{domain_id="",id="share-1",name="test-share-1",project_id="project-1",share_proto="NFS",share_type="type-uuid-1",share_type_name="default",size="10",status="available",tenant="project-1"} 1
+openstack_sharev2_share_status{domain_id="",id="share-2",name="test-share-2",project_id="project-2",share_proto="CIFS",share_type="type-uuid-2",share_type_name="ssd",size="20",status="creating",tenant="project-2"} 0
+openstack_sharev2_share_status{domain_id="",id="share-3",name="test-share-3",project_id="project-1",share_proto="NFS",share_type="type-uuid-1",share_type_name="default",size="5",status="error",tenant="project-1"} 8
 # HELP openstack_sharev2_share_status_counter share_status_counter
 # TYPE openstack_sharev2_share_status_counter gauge
 openstack_sharev2_share_status_counter{status="available"} 1
@@ -164,10 +165,10 @@ openstack_sharev2_up 1
 			},
 			ExpectedMetrics: `# HELP openstack_sharev2_share_gb share_gb
 # TYPE openstack_sharev2_share_gb gauge
-openstack_sharev2_share_gb{availability_zone="",id="share-null",name="",project_id="",share_proto="",share_type="",share_type_name="",status=""} 0
+openstack_sharev2_share_gb{availability_zone="",domain_id="",id="share-null",name="",project_id="",share_proto="",share_type="",share_type_name="",status="",tenant=""} 0
 # HELP openstack_sharev2_share_status share_status
 # TYPE openstack_sharev2_share_status gauge
-openstack_sharev2_share_status{id="share-null",name="",project_id="",share_proto="",share_type="",share_type_name="",size="0",status=""} -1
+openstack_sharev2_share_status{domain_id="",id="share-null",name="",project_id="",share_proto="",share_type="",share_type_name="",size="0",status="",tenant=""} -1
 # HELP openstack_sharev2_share_status_counter share_status_counter
 # TYPE openstack_sharev2_share_status_counter gauge
 openstack_sharev2_share_status_counter{status="available"} 0
@@ -210,6 +211,6 @@ openstack_sharev2_up 0
 	}
 
 	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *SharesCollector {
-		return NewSharesCollector(db, logger)
+		return NewSharesCollector(db, logger, project.NewResolver(logger, nil, 0))
 	})
 }
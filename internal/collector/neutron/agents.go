@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 )
 
@@ -47,9 +48,13 @@ func (c *AgentsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *AgentsCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *AgentsCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
 
-	agents, err := c.queries.GetAgents(ctx)
+	agents, err := queries.GetAgents(ctx)
 	if err != nil {
 		c.logger.Error("failed to query agents", "error", err)
 		return
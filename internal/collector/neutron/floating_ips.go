@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"log/slog"
+	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 )
 
@@ -14,12 +17,14 @@ var (
 		prometheus.BuildFQName(Namespace, Subsystem, "floating_ip"),
 		"floating_ip",
 		[]string{
+			"domain_id",
 			"floating_ip_address",
 			"floating_network_id",
 			"id",
 			"project_id",
 			"router_id",
 			"status",
+			"tenant",
 		},
 		nil,
 	)
@@ -37,36 +42,71 @@ var (
 		nil,
 		nil,
 	)
+
+	// floatingIPCountDesc replaces floating_ip in aggregate mode.
+	floatingIPCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, Subsystem, "floating_ip_count"),
+		"floating_ip_count",
+		[]string{
+			"associated",
+			"domain_id",
+			"floating_network_id",
+			"project_id",
+			"status",
+			"tenant",
+		},
+		nil,
+	)
 )
 
+// FloatingIPCollector exports one series per floating IP, or in aggregate
+// mode the number of floating IPs per project, network, status and whether
+// they are associated with a router.
 type FloatingIPCollector struct {
-	db      *sql.DB
-	queries *neutrondb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *neutrondb.Queries
+	logger          *slog.Logger
+	aggregate       bool
+	projectResolver *project.Resolver
 }
 
-func NewFloatingIPCollector(db *sql.DB, logger *slog.Logger) *FloatingIPCollector {
+func NewFloatingIPCollector(db *sql.DB, logger *slog.Logger, aggregate bool, projectResolver *project.Resolver) *FloatingIPCollector {
 	return &FloatingIPCollector{
-		db:      db,
-		queries: neutrondb.New(db),
+		db:        db,
+		queries:   neutrondb.New(db),
+		aggregate: aggregate,
 		logger: logger.With(
 			"namespace", Namespace,
 			"subsystem", Subsystem,
 			"collector", "floating_ips",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
 func (c *FloatingIPCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- floatingIPDesc
+	if c.aggregate {
+		ch <- floatingIPCountDesc
+	} else {
+		ch <- floatingIPDesc
+	}
 	ch <- floatingIPsDesc
 	ch <- floatingIPsAssociatedNotActiveDesc
 }
 
 func (c *FloatingIPCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
 
-	fips, err := c.queries.GetFloatingIPs(ctx)
+func (c *FloatingIPCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	if c.aggregate {
+		c.collectAggregates(ctx, ch)
+		return
+	}
+
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
+
+	fips, err := queries.GetFloatingIPs(ctx)
 	if err != nil {
 		c.logger.Error("failed to query floating IPs", "error", err)
 		return
@@ -74,16 +114,19 @@ func (c *FloatingIPCollector) Collect(ch chan<- prometheus.Metric) {
 
 	associatedNotActive := 0
 	for _, fip := range fips {
+		projectName, domainID := c.projectResolver.Resolve(fip.ProjectID.String)
 		ch <- prometheus.MustNewConstMetric(
 			floatingIPDesc,
 			prometheus.GaugeValue,
 			1,
+			domainID,
 			fip.FloatingIpAddress,
 			fip.FloatingNetworkID,
 			fip.ID,
 			fip.ProjectID.String,
 			fip.RouterID.String,
 			fip.Status.String,
+			projectName,
 		)
 
 		if fip.RouterID.Valid && fip.RouterID.String != "" && fip.Status.String != "ACTIVE" {
@@ -94,3 +137,37 @@ func (c *FloatingIPCollector) Collect(ch chan<- prometheus.Metric) {
 	ch <- prometheus.MustNewConstMetric(floatingIPsDesc, prometheus.GaugeValue, float64(len(fips)))
 	ch <- prometheus.MustNewConstMetric(floatingIPsAssociatedNotActiveDesc, prometheus.GaugeValue, float64(associatedNotActive))
 }
+
+func (c *FloatingIPCollector) collectAggregates(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
+
+	aggregates, err := queries.GetFloatingIPAggregates(ctx)
+	if err != nil {
+		c.logger.Error("failed to query floating IP aggregates", "error", err)
+		return
+	}
+
+	var total, associatedNotActive int64
+	for _, aggregate := range aggregates {
+		projectName, domainID := c.projectResolver.Resolve(aggregate.ProjectID.String)
+		ch <- prometheus.MustNewConstMetric(
+			floatingIPCountDesc,
+			prometheus.GaugeValue,
+			float64(aggregate.Cnt),
+			strconv.FormatBool(aggregate.Associated),
+			domainID,
+			aggregate.FloatingNetworkID,
+			aggregate.ProjectID.String,
+			aggregate.Status.String,
+			projectName,
+		)
+
+		total += aggregate.Cnt
+		if aggregate.Associated && aggregate.Status.String != "ACTIVE" {
+			associatedNotActive += aggregate.Cnt
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(floatingIPsDesc, prometheus.GaugeValue, float64(total))
+	ch <- prometheus.MustNewConstMetric(floatingIPsAssociatedNotActiveDesc, prometheus.GaugeValue, float64(associatedNotActive))
+}
@@ -2,10 +2,12 @@ package neutron
 
 import (
 	"database/sql"
+	"log/slog"
 	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -40,8 +42,8 @@ func TestFloatingIPCollector(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_neutron_floating_ip floating_ip
 # TYPE openstack_neutron_floating_ip gauge
-openstack_neutron_floating_ip{floating_ip_address="10.13.55.227",floating_network_id="6c0ae7af-cdef-4450-b607-0c3f4c9bb10a",id="ce919300-9f7e-4f93-98e1-78236fb0f916",project_id="7a96a68dc8264f3d84fafd95a72265c5",router_id="",status="DOWN"} 1
-openstack_neutron_floating_ip{floating_ip_address="10.13.55.238",floating_network_id="6c0ae7af-cdef-4450-b607-0c3f4c9bb10a",id="d0af13f7-c404-4dc7-8453-8f8b4d667b74",project_id="7a96a68dc8264f3d84fafd95a72265c5",router_id="ede5fa94-ba7d-4902-8395-20feabb6146e",status="ACTIVE"} 1
+openstack_neutron_floating_ip{domain_id="",floating_ip_address="10.13.55.227",floating_network_id="6c0ae7af-cdef-4450-b607-0c3f4c9bb10a",id="ce919300-9f7e-4f93-98e1-78236fb0f916",project_id="7a96a68dc8264f3d84fafd95a72265c5",router_id="",status="DOWN",tenant="7a96a68dc8264f3d84fafd95a72265c5"} 1
+openstack_neutron_floating_ip{domain_id="",floating_ip_address="10.13.55.238",floating_network_id="6c0ae7af-cdef-4450-b607-0c3f4c9bb10a",id="d0af13f7-c404-4dc7-8453-8f8b4d667b74",project_id="7a96a68dc8264f3d84fafd95a72265c5",router_id="ede5fa94-ba7d-4902-8395-20feabb6146e",status="ACTIVE",tenant="7a96a68dc8264f3d84fafd95a72265c5"} 1
 # HELP openstack_neutron_floating_ips floating_ips
 # TYPE openstack_neutron_floating_ips gauge
 openstack_neutron_floating_ips 2
@@ -65,7 +67,7 @@ openstack_neutron_floating_ips_associated_not_active 0
 			},
 			ExpectedMetrics: `# HELP openstack_neutron_floating_ip floating_ip
 # TYPE openstack_neutron_floating_ip gauge
-openstack_neutron_floating_ip{floating_ip_address="10.0.0.1",floating_network_id="net-1",id="fip-1",project_id="proj-1",router_id="router-1",status="DOWN"} 1
+openstack_neutron_floating_ip{domain_id="",floating_ip_address="10.0.0.1",floating_network_id="net-1",id="fip-1",project_id="proj-1",router_id="router-1",status="DOWN",tenant="proj-1"} 1
 # HELP openstack_neutron_floating_ips floating_ips
 # TYPE openstack_neutron_floating_ips gauge
 openstack_neutron_floating_ips 1
@@ -100,5 +102,50 @@ openstack_neutron_floating_ips_associated_not_active 0
 		},
 	}
 
-	testutil.RunCollectorTests(t, tests, NewFloatingIPCollector)
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *FloatingIPCollector {
+		return NewFloatingIPCollector(db, logger, false, project.NewResolver(logger, nil, 0))
+	})
+}
+
+func TestFloatingIPCollector_Aggregate(t *testing.T) {
+	tests := []testutil.CollectorTestCase{
+		{
+			Name: "successful collection with aggregates",
+			SetupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{
+					"project_id", "floating_network_id", "status", "associated", "cnt",
+				}).AddRow(
+					"7a96a68dc8264f3d84fafd95a72265c5", "6c0ae7af-cdef-4450-b607-0c3f4c9bb10a", "ACTIVE", true, 5,
+				).AddRow(
+					"7a96a68dc8264f3d84fafd95a72265c5", "6c0ae7af-cdef-4450-b607-0c3f4c9bb10a", "DOWN", true, 2,
+				).AddRow(
+					"7a96a68dc8264f3d84fafd95a72265c5", "6c0ae7af-cdef-4450-b607-0c3f4c9bb10a", "DOWN", false, 3,
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(neutrondb.GetFloatingIPAggregates)).WillReturnRows(rows)
+			},
+			ExpectedMetrics: `# HELP openstack_neutron_floating_ip_count floating_ip_count
+# TYPE openstack_neutron_floating_ip_count gauge
+openstack_neutron_floating_ip_count{associated="false",domain_id="",floating_network_id="6c0ae7af-cdef-4450-b607-0c3f4c9bb10a",project_id="7a96a68dc8264f3d84fafd95a72265c5",status="DOWN",tenant="7a96a68dc8264f3d84fafd95a72265c5"} 3
+openstack_neutron_floating_ip_count{associated="true",domain_id="",floating_network_id="6c0ae7af-cdef-4450-b607-0c3f4c9bb10a",project_id="7a96a68dc8264f3d84fafd95a72265c5",status="ACTIVE",tenant="7a96a68dc8264f3d84fafd95a72265c5"} 5
+openstack_neutron_floating_ip_count{associated="true",domain_id="",floating_network_id="6c0ae7af-cdef-4450-b607-0c3f4c9bb10a",project_id="7a96a68dc8264f3d84fafd95a72265c5",status="DOWN",tenant="7a96a68dc8264f3d84fafd95a72265c5"} 2
+# HELP openstack_neutron_floating_ips floating_ips
+# TYPE openstack_neutron_floating_ips gauge
+openstack_neutron_floating_ips 10
+# HELP openstack_neutron_floating_ips_associated_not_active floating_ips_associated_not_active
+# TYPE openstack_neutron_floating_ips_associated_not_active gauge
+openstack_neutron_floating_ips_associated_not_active 2
+`,
+		},
+		{
+			Name: "query error",
+			SetupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(neutrondb.GetFloatingIPAggregates)).WillReturnError(sql.ErrConnDone)
+			},
+			ExpectedMetrics: "",
+		},
+	}
+
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *FloatingIPCollector {
+		return NewFloatingIPCollector(db, logger, true, project.NewResolver(logger, nil, 0))
+	})
 }
@@ -87,7 +87,7 @@ openstack_neutron_up 1
 		now := time.Now().Format("2006-01-02 15:04:05")
 
 		itest.SeedSQL(t, db,
-			fmt.Sprintf(`INSERT INTO agents (id, agent_type, ` + "`binary`" + `, topic, host, admin_state_up, created_at, started_at, heartbeat_timestamp, configurations) VALUES
+			fmt.Sprintf(`INSERT INTO agents (id, agent_type, `+"`binary`"+`, topic, host, admin_state_up, created_at, started_at, heartbeat_timestamp, configurations) VALUES
 			('agent-001', 'L3 agent', 'neutron-l3-agent', 'l3_agent', 'ctrl-01', 1, '%s', '%s', '%s', '{}')`, now, now, now),
 			`INSERT INTO ha_router_agent_port_bindings (port_id, router_id, l3_agent_id, state) VALUES
 			('port-001', 'router-001', 'agent-001', 'active'),
@@ -118,7 +118,7 @@ func TestIntegration_FloatingIPCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewFloatingIPCollector(db, logger)
+		collector := NewFloatingIPCollector(db, logger, false, project.NewResolver(logger, nil, 0))
 		expected := `# HELP openstack_neutron_floating_ips floating_ips
 # TYPE openstack_neutron_floating_ips gauge
 openstack_neutron_floating_ips 0
@@ -141,7 +141,7 @@ openstack_neutron_floating_ips_associated_not_active 0
 			('fip-003', '203.0.113.12', 'ext-net-001', 'fport-003', NULL, 'DOWN', 'proj-001', 102)`,
 		)
 
-		collector := NewFloatingIPCollector(db, logger)
+		collector := NewFloatingIPCollector(db, logger, false, project.NewResolver(logger, nil, 0))
 
 		err := testutil.CollectAndCompare(collector, strings.NewReader(`# HELP openstack_neutron_floating_ips floating_ips
 # TYPE openstack_neutron_floating_ips gauge
@@ -159,6 +159,26 @@ openstack_neutron_floating_ips_associated_not_active 1
 			t.Fatalf("expected 3 floating_ip metrics, got %d", count)
 		}
 	})
+
+	t.Run("aggregates", func(t *testing.T) {
+		collector := NewFloatingIPCollector(db, logger, true, project.NewResolver(logger, nil, 0))
+
+		err := testutil.CollectAndCompare(collector, strings.NewReader(`# HELP openstack_neutron_floating_ip_count floating_ip_count
+# TYPE openstack_neutron_floating_ip_count gauge
+openstack_neutron_floating_ip_count{associated="false",domain_id="",floating_network_id="ext-net-001",project_id="proj-001",status="DOWN",tenant="proj-001"} 1
+openstack_neutron_floating_ip_count{associated="true",domain_id="",floating_network_id="ext-net-001",project_id="proj-001",status="ACTIVE",tenant="proj-001"} 1
+openstack_neutron_floating_ip_count{associated="true",domain_id="",floating_network_id="ext-net-001",project_id="proj-001",status="DOWN",tenant="proj-001"} 1
+# HELP openstack_neutron_floating_ips floating_ips
+# TYPE openstack_neutron_floating_ips gauge
+openstack_neutron_floating_ips 3
+# HELP openstack_neutron_floating_ips_associated_not_active floating_ips_associated_not_active
+# TYPE openstack_neutron_floating_ips_associated_not_active gauge
+openstack_neutron_floating_ips_associated_not_active 1
+`), "openstack_neutron_floating_ip_count", "openstack_neutron_floating_ip", "openstack_neutron_floating_ips", "openstack_neutron_floating_ips_associated_not_active")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
 }
 
 func TestIntegration_RouterCollector(t *testing.T) {
@@ -274,7 +294,7 @@ func TestIntegration_PortCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewPortCollector(db, logger)
+		collector := NewPortCollector(db, logger, project.NewResolver(logger, nil, 0))
 		expected := `# HELP openstack_neutron_ports ports
 # TYPE openstack_neutron_ports gauge
 openstack_neutron_ports 0
@@ -307,7 +327,7 @@ openstack_neutron_ports_no_ips 0
 			('port-p01', '10.0.0.1', 'sub-001', 'pnet-001')`,
 		)
 
-		collector := NewPortCollector(db, logger)
+		collector := NewPortCollector(db, logger, project.NewResolver(logger, nil, 0))
 
 		err := testutil.CollectAndCompare(collector, strings.NewReader(`# HELP openstack_neutron_ports ports
 # TYPE openstack_neutron_ports gauge
@@ -550,3 +570,29 @@ openstack_neutron_quota_router{tenant="proj-001",type="used"} 1
 		}
 	})
 }
+
+func TestIntegration_AgentsCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "neutron", "../../../sql/neutron/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	now := "(now() AT TIME ZONE 'UTC')"
+	itest.SeedSQL(t, db,
+		`INSERT INTO agents (id, agent_type, "binary", topic, host, admin_state_up, created_at, started_at, heartbeat_timestamp, configurations, availability_zone) VALUES`+
+			" ('ag-001', 'L3 agent', 'neutron-l3-agent', 'l3_agent', 'ctrl-01', true, "+now+", "+now+", "+now+", '{}', NULL),"+
+			" ('ag-002', 'DHCP agent', 'neutron-dhcp-agent', 'dhcp_agent', 'ctrl-02', false, "+now+", "+now+", "+now+" - INTERVAL '5 minutes', '{}', 'nova')",
+	)
+
+	collector := NewAgentsCollector(db, logger)
+
+	expected := `# HELP openstack_neutron_agent_state agent_state
+# TYPE openstack_neutron_agent_state gauge
+openstack_neutron_agent_state{adminState="enabled",hostname="ctrl-01",id="ag-001",service="neutron-l3-agent",zone=""} 1
+openstack_neutron_agent_state{adminState="disabled",hostname="ctrl-02",id="ag-002",service="neutron-dhcp-agent",zone="nova"} 0
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "openstack_neutron_agent_state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
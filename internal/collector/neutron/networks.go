@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 )
 
@@ -63,9 +64,13 @@ func (c *NetworkCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *NetworkCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *NetworkCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
 
-	networks, err := c.queries.GetNetworks(ctx)
+	networks, err := queries.GetNetworks(ctx)
 	if err != nil {
 		c.logger.Error("failed to query networks", "error", err)
 		return
@@ -1,6 +1,7 @@
 package neutron
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,28 +15,46 @@ const (
 	Subsystem = "neutron"
 )
 
-func RegisterCollectors(registry *prometheus.Registry, databaseURL string, projectResolver *project.Resolver, logger *slog.Logger) {
-	if databaseURL == "" {
+// Collectors lists the names of the collectors that can be enabled or
+// disabled individually.
+var Collectors = []string{
+	"agents",
+	"ha_router_agent_port_bindings",
+	"floating_ips",
+	"networks",
+	"ports",
+	"routers",
+	"security_groups",
+	"subnets",
+	"quotas",
+}
+
+func RegisterCollectors(registry prometheus.Registerer, database db.Config, enabled util.CollectorFilter, aggregate bool, projectResolver *project.Resolver, logger *slog.Logger) {
+	if database.URL == "" {
 		logger.Info("Collector not loaded", "service", "neutron", "reason", "database URL not configured")
 		return
 	}
-
-	conn, err := db.Connect(databaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to database", "service", "neutron", "error", err)
-		registry.MustRegister(util.NewDownCollector(Namespace, Subsystem))
+	if !enabled.Any(Collectors) {
+		logger.Info("Collector not loaded", "service", "neutron", "reason", "all collectors disabled")
 		return
 	}
 
-	registry.MustRegister(NewAgentsCollector(conn, logger))
-	registry.MustRegister(NewHARouterAgentPortBindingCollector(conn, logger))
-	registry.MustRegister(NewFloatingIPCollector(conn, logger))
-	registry.MustRegister(NewNetworkCollector(conn, logger))
-	registry.MustRegister(NewPortCollector(conn, logger))
-	registry.MustRegister(NewRouterCollector(conn, logger))
-	registry.MustRegister(NewSecurityGroupCollector(conn, logger))
-	registry.MustRegister(NewSubnetCollector(conn, logger))
-	registry.MustRegister(NewQuotaCollector(conn, logger, projectResolver))
+	util.RegisterWhenConnected(registry, Namespace, Subsystem, "neutron", func(ctx context.Context) ([]prometheus.Collector, error) {
+		conn, err := db.ConnectContext(ctx, database)
+		if err != nil {
+			return nil, err
+		}
 
-	logger.Info("Registered collectors", "service", "neutron")
+		return enabled.Select(map[string]prometheus.Collector{
+			"agents":                        NewAgentsCollector(conn, logger),
+			"ha_router_agent_port_bindings": NewHARouterAgentPortBindingCollector(conn, logger),
+			"floating_ips":                  NewFloatingIPCollector(conn, logger, aggregate, projectResolver),
+			"networks":                      NewNetworkCollector(conn, logger),
+			"ports":                         NewPortCollector(conn, logger, projectResolver),
+			"routers":                       NewRouterCollector(conn, logger),
+			"security_groups":               NewSecurityGroupCollector(conn, logger),
+			"subnets":                       NewSubnetCollector(conn, logger),
+			"quotas":                        NewQuotaCollector(conn, logger, projectResolver),
+		}), nil
+	}, logger)
 }
@@ -7,6 +7,8 @@ import (
 	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 )
 
@@ -18,10 +20,13 @@ var (
 			"admin_state_up",
 			"binding_vif_type",
 			"device_owner",
+			"domain_id",
 			"fixed_ips",
 			"mac_address",
 			"network_id",
+			"project_id",
 			"status",
+			"tenant",
 			"uuid",
 		},
 		nil,
@@ -50,12 +55,13 @@ var (
 )
 
 type PortCollector struct {
-	db      *sql.DB
-	queries *neutrondb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *neutrondb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewPortCollector(db *sql.DB, logger *slog.Logger) *PortCollector {
+func NewPortCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *PortCollector {
 	return &PortCollector{
 		db:      db,
 		queries: neutrondb.New(db),
@@ -64,6 +70,7 @@ func NewPortCollector(db *sql.DB, logger *slog.Logger) *PortCollector {
 			"subsystem", Subsystem,
 			"collector", "ports",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -75,9 +82,13 @@ func (c *PortCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *PortCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *PortCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
 
-	ports, err := c.queries.GetPorts(ctx)
+	ports, err := queries.GetPorts(ctx)
 	if err != nil {
 		c.logger.Error("failed to query ports", "error", err)
 		return
@@ -87,6 +98,7 @@ func (c *PortCollector) Collect(ch chan<- prometheus.Metric) {
 	noIPs := 0
 	for _, p := range ports {
 		fixedIPs := dbString(p.FixedIps)
+		projectName, domainID := c.projectResolver.Resolve(p.ProjectID.String)
 
 		ch <- prometheus.MustNewConstMetric(
 			portDesc,
@@ -95,10 +107,13 @@ func (c *PortCollector) Collect(ch chan<- prometheus.Metric) {
 			strconv.FormatBool(p.AdminStateUp),
 			p.BindingVifType.String,
 			p.DeviceOwner,
+			domainID,
 			fixedIPs,
 			p.MacAddress,
 			p.NetworkID,
+			p.ProjectID.String,
 			p.Status,
+			projectName,
 			p.ID,
 		)
 
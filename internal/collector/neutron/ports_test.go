@@ -2,10 +2,12 @@ package neutron
 
 import (
 	"database/sql"
+	"log/slog"
 	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -16,11 +18,12 @@ func TestPortCollector(t *testing.T) {
 			Name: "successful collection with ports",
 			SetupMock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"id", "mac_address", "device_owner", "status",
+					"id", "project_id", "mac_address", "device_owner", "status",
 					"network_id", "admin_state_up", "ip_allocation",
 					"binding_vif_type", "fixed_ips",
 				}).AddRow(
 					"883f060a-60a2-48af-aba8-88c45a4b0b58",
+					"proj-1",
 					"fa:16:3e:2d:97:08",
 					"compute:nova",
 					"ACTIVE",
@@ -31,6 +34,7 @@ func TestPortCollector(t *testing.T) {
 					[]byte("10.13.18.143"),
 				).AddRow(
 					"10e61c4b-cefc-4a38-a374-bf241d9411b5",
+					"proj-1",
 					"fa:16:3e:9d:fa:55",
 					"Octavia",
 					"DOWN",
@@ -45,8 +49,8 @@ func TestPortCollector(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_neutron_port port
 # TYPE openstack_neutron_port gauge
-openstack_neutron_port{admin_state_up="true",binding_vif_type="ovs",device_owner="compute:nova",fixed_ips="10.13.18.143",mac_address="fa:16:3e:2d:97:08",network_id="74917853-7529-46fc-8545-ed70fe691f03",status="ACTIVE",uuid="883f060a-60a2-48af-aba8-88c45a4b0b58"} 1
-openstack_neutron_port{admin_state_up="false",binding_vif_type="unbound",device_owner="Octavia",fixed_ips="10.16.0.90",mac_address="fa:16:3e:9d:fa:55",network_id="74917853-7529-46fc-8545-ed70fe691f03",status="DOWN",uuid="10e61c4b-cefc-4a38-a374-bf241d9411b5"} 1
+openstack_neutron_port{admin_state_up="true",binding_vif_type="ovs",device_owner="compute:nova",domain_id="",fixed_ips="10.13.18.143",mac_address="fa:16:3e:2d:97:08",network_id="74917853-7529-46fc-8545-ed70fe691f03",project_id="proj-1",status="ACTIVE",tenant="proj-1",uuid="883f060a-60a2-48af-aba8-88c45a4b0b58"} 1
+openstack_neutron_port{admin_state_up="false",binding_vif_type="unbound",device_owner="Octavia",domain_id="",fixed_ips="10.16.0.90",mac_address="fa:16:3e:9d:fa:55",network_id="74917853-7529-46fc-8545-ed70fe691f03",project_id="proj-1",status="DOWN",tenant="proj-1",uuid="10e61c4b-cefc-4a38-a374-bf241d9411b5"} 1
 # HELP openstack_neutron_ports ports
 # TYPE openstack_neutron_ports gauge
 openstack_neutron_ports 2
@@ -62,15 +66,15 @@ openstack_neutron_ports_no_ips 0
 			Name: "LB port not active and port with no IPs",
 			SetupMock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"id", "mac_address", "device_owner", "status",
+					"id", "project_id", "mac_address", "device_owner", "status",
 					"network_id", "admin_state_up", "ip_allocation",
 					"binding_vif_type", "fixed_ips",
 				}).AddRow(
-					"port-1", "aa:bb:cc:dd:ee:ff",
+					"port-1", "proj-1", "aa:bb:cc:dd:ee:ff",
 					"neutron:LOADBALANCERV2", "DOWN",
 					"net-1", true, nil, "ovs", []byte("10.0.0.1"),
 				).AddRow(
-					"port-2", "11:22:33:44:55:66",
+					"port-2", "proj-1", "11:22:33:44:55:66",
 					"", "DOWN",
 					"net-1", true, nil, "unbound", []byte(""),
 				)
@@ -79,8 +83,8 @@ openstack_neutron_ports_no_ips 0
 			},
 			ExpectedMetrics: `# HELP openstack_neutron_port port
 # TYPE openstack_neutron_port gauge
-openstack_neutron_port{admin_state_up="true",binding_vif_type="ovs",device_owner="neutron:LOADBALANCERV2",fixed_ips="10.0.0.1",mac_address="aa:bb:cc:dd:ee:ff",network_id="net-1",status="DOWN",uuid="port-1"} 1
-openstack_neutron_port{admin_state_up="true",binding_vif_type="unbound",device_owner="",fixed_ips="",mac_address="11:22:33:44:55:66",network_id="net-1",status="DOWN",uuid="port-2"} 1
+openstack_neutron_port{admin_state_up="true",binding_vif_type="ovs",device_owner="neutron:LOADBALANCERV2",domain_id="",fixed_ips="10.0.0.1",mac_address="aa:bb:cc:dd:ee:ff",network_id="net-1",project_id="proj-1",status="DOWN",tenant="proj-1",uuid="port-1"} 1
+openstack_neutron_port{admin_state_up="true",binding_vif_type="unbound",device_owner="",domain_id="",fixed_ips="",mac_address="11:22:33:44:55:66",network_id="net-1",project_id="proj-1",status="DOWN",tenant="proj-1",uuid="port-2"} 1
 # HELP openstack_neutron_ports ports
 # TYPE openstack_neutron_ports gauge
 openstack_neutron_ports 2
@@ -96,11 +100,11 @@ openstack_neutron_ports_no_ips 1
 			Name: "port with ip_allocation none excluded from no_ips count",
 			SetupMock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"id", "mac_address", "device_owner", "status",
+					"id", "project_id", "mac_address", "device_owner", "status",
 					"network_id", "admin_state_up", "ip_allocation",
 					"binding_vif_type", "fixed_ips",
 				}).AddRow(
-					"port-1", "aa:bb:cc:dd:ee:ff",
+					"port-1", "proj-1", "aa:bb:cc:dd:ee:ff",
 					"network:distributed", "DOWN",
 					"net-1", true, "none", "unbound", []byte(""),
 				)
@@ -109,7 +113,7 @@ openstack_neutron_ports_no_ips 1
 			},
 			ExpectedMetrics: `# HELP openstack_neutron_port port
 # TYPE openstack_neutron_port gauge
-openstack_neutron_port{admin_state_up="true",binding_vif_type="unbound",device_owner="network:distributed",fixed_ips="",mac_address="aa:bb:cc:dd:ee:ff",network_id="net-1",status="DOWN",uuid="port-1"} 1
+openstack_neutron_port{admin_state_up="true",binding_vif_type="unbound",device_owner="network:distributed",domain_id="",fixed_ips="",mac_address="aa:bb:cc:dd:ee:ff",network_id="net-1",project_id="proj-1",status="DOWN",tenant="proj-1",uuid="port-1"} 1
 # HELP openstack_neutron_ports ports
 # TYPE openstack_neutron_ports gauge
 openstack_neutron_ports 1
@@ -130,5 +134,7 @@ openstack_neutron_ports_no_ips 0
 		},
 	}
 
-	testutil.RunCollectorTests(t, tests, NewPortCollector)
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *PortCollector {
+		return NewPortCollector(db, logger, project.NewResolver(logger, nil, 0))
+	})
 }
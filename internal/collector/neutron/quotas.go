@@ -7,6 +7,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 )
 
@@ -77,17 +78,21 @@ func (c *QuotaCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *QuotaCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *QuotaCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
 
 	// Get explicit quota limits from DB
-	quotaLimits, err := c.queries.GetQuotas(ctx)
+	quotaLimits, err := queries.GetQuotas(ctx)
 	if err != nil {
 		c.logger.Error("failed to query quotas", "error", err)
 		return
 	}
 
 	// Get resource counts per project
-	resourceCounts, err := c.queries.GetResourceCountsByProject(ctx)
+	resourceCounts, err := queries.GetResourceCountsByProject(ctx)
 	if err != nil {
 		c.logger.Error("failed to query resource counts", "error", err)
 		return
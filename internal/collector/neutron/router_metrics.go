@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 )
 
@@ -65,9 +66,13 @@ func (c *RouterCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *RouterCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *RouterCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
 
-	routers, err := c.queries.GetRouters(ctx)
+	routers, err := queries.GetRouters(ctx)
 	if err != nil {
 		c.logger.Error("failed to query routers", "error", err)
 		return
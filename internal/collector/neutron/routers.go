@@ -9,6 +9,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cast"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 )
 
@@ -59,9 +60,13 @@ func (c *HARouterAgentPortBindingCollector) Describe(ch chan<- *prometheus.Desc)
 }
 
 func (c *HARouterAgentPortBindingCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *HARouterAgentPortBindingCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
 
-	bindings, err := c.queries.GetHARouterAgentPortBindingsWithAgents(ctx)
+	bindings, err := queries.GetHARouterAgentPortBindingsWithAgents(ctx)
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(neutronUpDesc, prometheus.GaugeValue, 0)
 
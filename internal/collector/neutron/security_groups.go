@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 )
 
@@ -41,9 +42,13 @@ func (c *SecurityGroupCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *SecurityGroupCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *SecurityGroupCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
 
-	count, err := c.queries.GetSecurityGroupCount(ctx)
+	count, err := queries.GetSecurityGroupCount(ctx)
 	if err != nil {
 		c.logger.Error("failed to query security group count", "error", err)
 		return
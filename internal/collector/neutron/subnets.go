@@ -12,6 +12,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cast"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	neutrondb "github.com/vexxhost/openstack_database_exporter/internal/db/neutron"
 	"go4.org/netipx"
 )
@@ -141,15 +142,19 @@ func (c *SubnetCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *SubnetCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
 
+func (c *SubnetCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	c.collectSubnets(ctx, ch)
 	c.collectIPAvailabilities(ctx, ch)
 	c.collectSubnetPools(ctx, ch)
 }
 
 func (c *SubnetCollector) collectSubnets(ctx context.Context, ch chan<- prometheus.Metric) {
-	subnets, err := c.queries.GetSubnets(ctx)
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
+
+	subnets, err := queries.GetSubnets(ctx)
 	if err != nil {
 		c.logger.Error("failed to query subnets", "error", err)
 		return
@@ -176,8 +181,10 @@ func (c *SubnetCollector) collectSubnets(ctx context.Context, ch chan<- promethe
 }
 
 func (c *SubnetCollector) collectIPAvailabilities(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
+
 	// Collect "used" (allocation counts per subnet)
-	used, err := c.queries.GetNetworkIPAvailabilitiesUsed(ctx)
+	used, err := queries.GetNetworkIPAvailabilitiesUsed(ctx)
 	if err != nil {
 		c.logger.Error("failed to query IP availability used", "error", err)
 		return
@@ -198,7 +205,7 @@ func (c *SubnetCollector) collectIPAvailabilities(ctx context.Context, ch chan<-
 	}
 
 	// Collect "total" (sum of allocation pool ranges per subnet)
-	total, err := c.queries.GetNetworkIPAvailabilitiesTotal(ctx)
+	total, err := queries.GetNetworkIPAvailabilitiesTotal(ctx)
 	if err != nil {
 		c.logger.Error("failed to query IP availability total", "error", err)
 		return
@@ -367,13 +374,15 @@ func calculateUsedSubnets(subnets []netip.Prefix, ipPrefix netip.Prefix, prefixL
 }
 
 func (c *SubnetCollector) collectSubnetPools(ctx context.Context, ch chan<- prometheus.Metric) {
-	subnets, err := c.queries.GetSubnets(ctx)
+	queries := db.InSnapshot(ctx, "neutron", c.queries)
+
+	subnets, err := queries.GetSubnets(ctx)
 	if err != nil {
 		c.logger.Error("failed to query subnets for subnet pools", "error", err)
 		return
 	}
 
-	pools, err := c.queries.GetSubnetPools(ctx)
+	pools, err := queries.GetSubnetPools(ctx)
 	if err != nil {
 		c.logger.Error("failed to query subnet pools", "error", err)
 		return
@@ -1,6 +1,7 @@
 package nova
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 
@@ -9,6 +10,7 @@ import (
 	novadb "github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	novaapidb "github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 	placementdb "github.com/vexxhost/openstack_database_exporter/internal/db/placement"
+	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
 
 var (
@@ -21,82 +23,76 @@ var (
 )
 
 type ComputeCollector struct {
-	novaDB                *sql.DB
-	novaApiDB             *sql.DB
-	logger                *slog.Logger
-	servicesCollector      *ServicesCollector
-	flavorsCollector       *FlavorsCollector
-	quotasCollector        *QuotasCollector
-	limitsCollector        *LimitsCollector
-	computeNodesCollector  *ComputeNodesCollector
-	serverCollector        *ServerCollector
+	novaDB        *sql.DB
+	novaApiDB     *sql.DB
+	logger        *slog.Logger
+	subCollectors []namedSubCollector
 }
 
-func NewComputeCollector(novaDB, novaApiDB *sql.DB, placementDB *placementdb.Queries, projectResolver *project.Resolver, logger *slog.Logger) *ComputeCollector {
-	novaQueries := novadb.New(novaDB)
-	novaApiQueries := novaapidb.New(novaApiDB)
-
-	return &ComputeCollector{
-		novaDB:                novaDB,
-		novaApiDB:             novaApiDB,
-		logger:                logger,
-		servicesCollector:      NewServicesCollector(logger, novaQueries, novaApiQueries),
-		flavorsCollector:       NewFlavorsCollector(logger, novaQueries, novaApiQueries),
-		quotasCollector:        NewQuotasCollector(logger, novaQueries, novaApiQueries, placementDB, projectResolver),
-		limitsCollector:        NewLimitsCollector(logger, novaQueries, novaApiQueries, placementDB, projectResolver),
-		computeNodesCollector:  NewComputeNodesCollector(logger, novaQueries, novaApiQueries),
-		serverCollector:        NewServerCollector(logger, novaQueries, novaApiQueries),
-	}
+// subCollector is implemented by the collectors making up ComputeCollector.
+type subCollector interface {
+	Describe(ch chan<- *prometheus.Desc)
+	Collect(ctx context.Context, ch chan<- prometheus.Metric) error
 }
 
-func (c *ComputeCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- novaUpDesc
-	c.servicesCollector.Describe(ch)
-	c.flavorsCollector.Describe(ch)
-	c.quotasCollector.Describe(ch)
-	c.limitsCollector.Describe(ch)
-	c.computeNodesCollector.Describe(ch)
-	c.serverCollector.Describe(ch)
+type namedSubCollector struct {
+	name      string
+	collector subCollector
 }
 
-func (c *ComputeCollector) Collect(ch chan<- prometheus.Metric) {
-	// Track if any sub-collector fails
-	var hasError bool
+// NewComputeCollector creates the nova collector. Sub-collectors that are
+// not enabled are left out; a nil filter enables all of them. aggregate
+// replaces the per-server metrics with per-project aggregates.
+func NewComputeCollector(novaDB, novaApiDB *sql.DB, placementDB *placementdb.Queries, projectResolver *project.Resolver, enabled util.CollectorFilter, aggregate bool, logger *slog.Logger) *ComputeCollector {
+	novaQueries := novadb.New(novaDB)
+	novaApiQueries := novaapidb.New(novaApiDB)
 
-	// Collect metrics from all sub-collectors
-	if err := c.servicesCollector.Collect(ch); err != nil {
-		c.logger.Error("Services collector failed", "error", err)
-		hasError = true
+	c := &ComputeCollector{
+		novaDB:    novaDB,
+		novaApiDB: novaApiDB,
+		logger:    logger,
 	}
 
-	if err := c.flavorsCollector.Collect(ch); err != nil {
-		c.logger.Error("Flavors collector failed", "error", err)
-		hasError = true
+	for _, sc := range []namedSubCollector{
+		{"services", NewServicesCollector(logger, novaQueries, novaApiQueries)},
+		{"flavors", NewFlavorsCollector(logger, novaQueries, novaApiQueries)},
+		{"quotas", NewQuotasCollector(logger, novaQueries, novaApiQueries, placementDB, projectResolver)},
+		{"limits", NewLimitsCollector(logger, novaQueries, novaApiQueries, placementDB, projectResolver)},
+		{"compute_nodes", NewComputeNodesCollector(logger, novaQueries, novaApiQueries)},
+		{"server", NewServerCollector(logger, novaQueries, novaApiQueries, projectResolver, aggregate)},
+	} {
+		if enabled.Enabled(sc.name) {
+			c.subCollectors = append(c.subCollectors, sc)
+		}
 	}
 
-	if err := c.quotasCollector.Collect(ch); err != nil {
-		c.logger.Error("Quotas collector failed", "error", err)
-		hasError = true
-	}
+	return c
+}
 
-	if err := c.limitsCollector.Collect(ch); err != nil {
-		c.logger.Error("Limits collector failed", "error", err)
-		hasError = true
+func (c *ComputeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- novaUpDesc
+	for _, sc := range c.subCollectors {
+		sc.collector.Describe(ch)
 	}
+}
 
-	if err := c.computeNodesCollector.Collect(ch); err != nil {
-		c.logger.Error("Compute nodes collector failed", "error", err)
-		hasError = true
-	}
+func (c *ComputeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
 
-	if err := c.serverCollector.Collect(ch); err != nil {
-		c.logger.Error("Server collector failed", "error", err)
-		hasError = true
+func (c *ComputeCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	// Collect metrics from all sub-collectors concurrently
+	fns := make([]func(context.Context) error, 0, len(c.subCollectors))
+	for _, sc := range c.subCollectors {
+		fns = append(fns, func(ctx context.Context) error {
+			return c.logError(sc.name, sc.collector.Collect(ctx, ch))
+		})
 	}
+	err := util.Parallel(ctx, fns...)
 
 	// Emit single up metric based on overall success/failure
 	upValue := float64(1)
-	if hasError {
+	if err != nil {
 		upValue = 0
 	}
 	ch <- prometheus.MustNewConstMetric(
@@ -105,3 +101,10 @@ func (c *ComputeCollector) Collect(ch chan<- prometheus.Metric) {
 		upValue,
 	)
 }
+
+func (c *ComputeCollector) logError(name string, err error) error {
+	if err != nil {
+		c.logger.Error("Sub-collector failed", "collector", name, "error", err)
+	}
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 )
@@ -95,18 +96,18 @@ func (c *ComputeNodesCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the prometheus.Collector interface
-func (c *ComputeNodesCollector) Collect(ch chan<- prometheus.Metric) error {
-	return c.collectComputeNodeMetrics(ch)
+func (c *ComputeNodesCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return c.collectComputeNodeMetrics(ctx, ch)
 }
 
-func (c *ComputeNodesCollector) collectComputeNodeMetrics(ch chan<- prometheus.Metric) error {
-	computeNodes, err := c.novaDB.GetComputeNodes(context.Background())
+func (c *ComputeNodesCollector) collectComputeNodeMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
+	computeNodes, err := db.InSnapshot(ctx, "nova", c.novaDB).GetComputeNodes(ctx)
 	if err != nil {
 		return err
 	}
 
 	// Get aggregates info for compute nodes
-	aggregates, err := c.novaAPIDB.GetAggregateHosts(context.Background())
+	aggregates, err := db.InSnapshot(ctx, "nova_api", c.novaAPIDB).GetAggregateHosts(ctx)
 	if err != nil {
 		c.logger.Error("Failed to get aggregate hosts", "error", err)
 	}
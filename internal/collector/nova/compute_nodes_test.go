@@ -1,6 +1,7 @@
 package nova
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"testing"
@@ -84,5 +85,5 @@ type computeNodesCollectorWrapper struct {
 }
 
 func (w *computeNodesCollectorWrapper) Collect(ch chan<- prometheus.Metric) {
-	_ = w.ComputeNodesCollector.Collect(ch)
+	_ = w.ComputeNodesCollector.Collect(context.Background(), ch)
 }
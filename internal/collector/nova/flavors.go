@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 )
@@ -67,14 +68,12 @@ func (c *FlavorsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the prometheus.Collector interface
-func (c *FlavorsCollector) Collect(ch chan<- prometheus.Metric) error {
-	return c.collectFlavorMetrics(ch)
+func (c *FlavorsCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return c.collectFlavorMetrics(ctx, ch)
 }
 
-func (c *FlavorsCollector) collectFlavorMetrics(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
-
-	flavors, err := c.novaAPIDB.GetFlavors(ctx)
+func (c *FlavorsCollector) collectFlavorMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
+	flavors, err := db.InSnapshot(ctx, "nova_api", c.novaAPIDB).GetFlavors(ctx)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package nova
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -60,5 +61,5 @@ type flavorsCollectorWrapper struct {
 }
 
 func (w *flavorsCollectorWrapper) Collect(ch chan<- prometheus.Metric) {
-	_ = w.FlavorsCollector.Collect(ch)
+	_ = w.FlavorsCollector.Collect(context.Background(), ch)
 }
@@ -3,14 +3,22 @@
 package nova
 
 import (
+	"context"
 	"database/sql"
 	"io"
 	"log/slog"
+	"net"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/expfmt"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	novadb "github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	novaapidb "github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 	itest "github.com/vexxhost/openstack_database_exporter/internal/testutil"
@@ -29,7 +37,7 @@ func TestIntegration_ServerCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewServerCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB))
+		collector := NewServerCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB), project.NewResolver(logger, nil, 0), false)
 		wrapper := &serverCollectorWrapper{collector}
 
 		// Empty DB should emit total_vms=0 and availability_zones=0
@@ -65,7 +73,7 @@ openstack_nova_availability_zones 0
 			(5, 'uuid-error',   'srv-error',   'user-1', 'proj-1', 'compute-2', 'nova', 'error',    1, NULL, 2048, 1, 20, 0, NOW(), NULL, 1, 0)`,
 		)
 
-		collector := NewServerCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB))
+		collector := NewServerCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB), project.NewResolver(logger, nil, 0), false)
 		wrapper := &serverCollectorWrapper{collector}
 
 		// Collect all metrics and filter by server_status
@@ -124,7 +132,7 @@ openstack_nova_total_vms 5
 			(12, 'uuid-reverting', 'srv-reverting', 'user-1', 'proj-1', 'compute-1', 'nova', 'resized', 1, 'resize_reverting',2048, 1, 20, 0, NOW(), NULL, 1, 0)`,
 		)
 
-		collector := NewServerCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB))
+		collector := NewServerCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB), project.NewResolver(logger, nil, 0), false)
 		wrapper := &serverCollectorWrapper{collector}
 
 		gathered, err := testutil.CollectAndFormat(wrapper, expfmt.TypeTextPlain, "openstack_nova_server_status")
@@ -154,3 +162,315 @@ openstack_nova_total_vms 5
 		}
 	})
 }
+
+// flakyProxy forwards connections to addr, but drops them until ready is set.
+type flakyProxy struct {
+	listener net.Listener
+	addr     string
+	ready    atomic.Bool
+}
+
+func newFlakyProxy(t *testing.T, addr string) *flakyProxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	p := &flakyProxy{listener: listener, addr: addr}
+	go p.serve()
+	return p
+}
+
+func (p *flakyProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		if !p.ready.Load() {
+			_ = conn.Close()
+			continue
+		}
+		backend, err := net.Dial("tcp", p.addr)
+		if err != nil {
+			_ = conn.Close()
+			continue
+		}
+		go func() {
+			_, _ = io.Copy(backend, conn)
+			_ = backend.Close()
+		}()
+		go func() {
+			_, _ = io.Copy(conn, backend)
+			_ = conn.Close()
+		}()
+	}
+}
+
+func TestIntegration_RegisterCollectorsReconnect(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	novaRes := itest.NewMySQLContainerWithURL(t, "nova", "../../../sql/nova/schema.sql", "../../../sql/nova/indexes.sql")
+	novaAPIRes := itest.NewMySQLContainerWithURL(t, "nova-api", "../../../sql/nova_api/schema.sql", "../../../sql/nova_api/indexes.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// The nova API database fails the first connection of the service.
+	novaAPIURL, err := url.Parse(novaAPIRes.URL)
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+	proxy := newFlakyProxy(t, novaAPIURL.Host)
+	novaAPIURL.Host = proxy.listener.Addr().String()
+
+	pools := db.NewPools("openstack")
+	t.Cleanup(func() { _ = pools.Close() })
+	database := func(schema, url string) db.Config {
+		return db.Config{URL: url, Pools: pools, Service: "nova", Schema: schema}
+	}
+
+	reg := prometheus.NewRegistry()
+	RegisterCollectors(reg, database("nova", novaRes.URL), database("nova_api", novaAPIURL.String()), database("placement", ""), func(string) bool { return true }, false, project.NewResolver(logger, nil, 0), logger)
+	// The first scrape waits for the connection attempted in the background.
+	if _, err := reg.Gather(); err != nil {
+		t.Fatalf("failed to gather: %v", err)
+	}
+	if errs := pools.Ping(context.Background()); len(errs) != 0 {
+		t.Fatalf("expected no tracked pools after the failed connect, got %v", errs)
+	}
+
+	// Scrapes retry the connection once the backoff has elapsed.
+	proxy.ready.Store(true)
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := reg.Gather(); err != nil {
+		t.Fatalf("failed to gather: %v", err)
+	}
+
+	errs := pools.Ping(context.Background())
+	if len(errs) != 1 {
+		t.Fatalf("expected the nova pools to be tracked, got %v", errs)
+	}
+	if err := errs["nova"]; err != nil {
+		t.Fatalf("expected nova to be reachable, got %v", err)
+	}
+}
+
+func novaPostgreSQLDBs(t *testing.T) (*sql.DB, *sql.DB) {
+	novaDB := itest.NewPostgreSQLContainer(t, "nova", "../../../sql/nova/postgresql/schema.sql")
+	novaAPIDB := itest.NewPostgreSQLContainer(t, "nova_api", "../../../sql/nova_api/postgresql/schema.sql")
+	return novaDB, novaAPIDB
+}
+
+func TestIntegration_ServerCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	novaDB, novaAPIDB := novaPostgreSQLDBs(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, novaAPIDB,
+		`INSERT INTO flavors (id, flavorid, name, vcpus, memory_mb, swap, root_gb, ephemeral_gb, is_public) VALUES
+		(1, 'flavor-uuid-1', 'm1.small', 1, 2048, 0, 20, 0, true)`,
+	)
+	itest.SeedSQL(t, novaDB,
+		`INSERT INTO instances (id, uuid, display_name, user_id, project_id, host,
+			availability_zone, vm_state, power_state, task_state,
+			memory_mb, vcpus, root_gb, ephemeral_gb,
+			launched_at, terminated_at, instance_type_id, deleted) VALUES
+		(1, 'uuid-active-1', 'srv-active-1', 'user-1', 'proj-1', 'compute-1', 'nova', 'active',  1, NULL, 2048, 1, 20, 0, now(), NULL, 1, 0),
+		(2, 'uuid-active-2', 'srv-active-2', 'user-1', 'proj-1', 'compute-2', 'nova', 'active',  1, NULL, 2048, 1, 20, 0, now(), NULL, 1, 0),
+		(3, 'uuid-stopped',  'srv-stopped',  'user-2', 'proj-2', 'compute-1', 'az-2', 'stopped', 4, NULL, 2048, 1, 40, 0, now(), NULL, 1, 0),
+		(4, 'uuid-deleted',  'srv-deleted',  'user-2', 'proj-2', 'compute-1', 'az-2', 'deleted', 0, NULL, 2048, 1, 20, 0, now(), now(), 1, 4)`,
+	)
+
+	t.Run("per server", func(t *testing.T) {
+		collector := NewServerCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB), project.NewResolver(logger, nil, 0), false)
+		wrapper := &serverCollectorWrapper{collector}
+
+		err := testutil.CollectAndCompare(wrapper, strings.NewReader(`# HELP openstack_nova_availability_zones availability_zones
+# TYPE openstack_nova_availability_zones gauge
+openstack_nova_availability_zones 2
+# HELP openstack_nova_server_local_gb server_local_gb
+# TYPE openstack_nova_server_local_gb gauge
+openstack_nova_server_local_gb{domain_id="",id="uuid-active-1",name="srv-active-1",tenant="proj-1",tenant_id="proj-1"} 20
+openstack_nova_server_local_gb{domain_id="",id="uuid-active-2",name="srv-active-2",tenant="proj-1",tenant_id="proj-1"} 20
+openstack_nova_server_local_gb{domain_id="",id="uuid-stopped",name="srv-stopped",tenant="proj-2",tenant_id="proj-2"} 40
+# HELP openstack_nova_total_vms total_vms
+# TYPE openstack_nova_total_vms gauge
+openstack_nova_total_vms 3
+`), "openstack_nova_availability_zones", "openstack_nova_server_local_gb", "openstack_nova_total_vms")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		count := testutil.CollectAndCount(wrapper, "openstack_nova_server_status")
+		if count != 3 {
+			t.Fatalf("expected 3 server_status metrics, got %d", count)
+		}
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		collector := NewServerCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB), project.NewResolver(logger, nil, 0), true)
+		wrapper := &serverCollectorWrapper{collector}
+
+		err := testutil.CollectAndCompare(wrapper, strings.NewReader(`# HELP openstack_nova_availability_zones availability_zones
+# TYPE openstack_nova_availability_zones gauge
+openstack_nova_availability_zones 2
+# HELP openstack_nova_server_count server_count
+# TYPE openstack_nova_server_count gauge
+openstack_nova_server_count{availability_zone="az-2",domain_id="",flavor_id="flavor-uuid-1",status="SHUTOFF",tenant="proj-2",tenant_id="proj-2"} 1
+openstack_nova_server_count{availability_zone="nova",domain_id="",flavor_id="flavor-uuid-1",status="ACTIVE",tenant="proj-1",tenant_id="proj-1"} 2
+# HELP openstack_nova_servers_local_gb servers_local_gb
+# TYPE openstack_nova_servers_local_gb gauge
+openstack_nova_servers_local_gb{availability_zone="az-2",domain_id="",flavor_id="flavor-uuid-1",status="SHUTOFF",tenant="proj-2",tenant_id="proj-2"} 40
+openstack_nova_servers_local_gb{availability_zone="nova",domain_id="",flavor_id="flavor-uuid-1",status="ACTIVE",tenant="proj-1",tenant_id="proj-1"} 40
+# HELP openstack_nova_total_vms total_vms
+# TYPE openstack_nova_total_vms gauge
+openstack_nova_total_vms 3
+`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestIntegration_ServicesCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	novaDB, novaAPIDB := novaPostgreSQLDBs(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, novaDB,
+		`INSERT INTO services (host, "binary", topic, report_count, disabled, disabled_reason, last_seen_up, forced_down, deleted, uuid) VALUES
+		('compute-1', 'nova-compute',   'compute',   10, false, NULL,          now(), false, 0, 'svc-001'),
+		('ctl-1',     'nova-scheduler', 'scheduler', 5,  true,  'maintenance', now(), false, 0, 'svc-002'),
+		('ctl-2',     'nova-conductor', 'conductor', 1,  false, NULL,          NULL,  false, 0, 'svc-003')`,
+	)
+
+	collector := NewServicesCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB))
+
+	// Services that were never seen up are left out.
+	err := testutil.CollectAndCompare(&servicesCollectorWrapper{collector}, strings.NewReader(`# HELP openstack_nova_agent_state agent_state
+# TYPE openstack_nova_agent_state gauge
+openstack_nova_agent_state{adminState="disabled",disabledReason="maintenance",hostname="ctl-1",id="svc-002",service="nova-scheduler",zone="internal"} 0
+openstack_nova_agent_state{adminState="enabled",disabledReason="",hostname="compute-1",id="svc-001",service="nova-compute",zone="nova"} 1
+`), "openstack_nova_agent_state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestIntegration_ComputeNodesCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	novaDB, novaAPIDB := novaPostgreSQLDBs(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, novaDB,
+		`INSERT INTO compute_nodes (uuid, host, hypervisor_hostname, hypervisor_type, hypervisor_version, cpu_info,
+			vcpus, vcpus_used, memory_mb, memory_mb_used, local_gb, local_gb_used, running_vms, deleted) VALUES
+		('node-001', 'compute-1', 'compute-1', 'QEMU', 8002000, '{}', 32, 4, 65536, 8192, 500, 100, 2, 0),
+		('node-002', 'compute-2', 'compute-2', 'QEMU', 8002000, '{}', 16, 0, 32768, 0, 250, 0, 0, 1)`,
+	)
+	itest.SeedSQL(t, novaAPIDB,
+		`INSERT INTO aggregates (id, uuid, name) VALUES (1, 'agg-uuid-1', 'agg-1')`,
+		`INSERT INTO aggregate_hosts (host, aggregate_id) VALUES ('compute-1', 1)`,
+	)
+
+	collector := NewComputeNodesCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB))
+
+	err := testutil.CollectAndCompare(&computeNodesCollectorWrapper{collector}, strings.NewReader(`# HELP openstack_nova_running_vms running_vms
+# TYPE openstack_nova_running_vms gauge
+openstack_nova_running_vms{aggregates="agg-1",availability_zone="",hostname="compute-1"} 2
+# HELP openstack_nova_vcpus_available vcpus_available
+# TYPE openstack_nova_vcpus_available gauge
+openstack_nova_vcpus_available{aggregates="agg-1",availability_zone="",hostname="compute-1"} 28
+# HELP openstack_nova_vcpus_used vcpus_used
+# TYPE openstack_nova_vcpus_used gauge
+openstack_nova_vcpus_used{aggregates="agg-1",availability_zone="",hostname="compute-1"} 4
+`), "openstack_nova_running_vms", "openstack_nova_vcpus_available", "openstack_nova_vcpus_used")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestIntegration_FlavorsCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	novaDB, novaAPIDB := novaPostgreSQLDBs(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, novaAPIDB,
+		`INSERT INTO flavors (id, flavorid, name, vcpus, memory_mb, swap, root_gb, ephemeral_gb, rxtx_factor, disabled, is_public) VALUES
+		(1, 'flavor-uuid-1', 'm1.small', 1, 2048, 0, 20, 0, 1.0, false, true),
+		(2, 'flavor-uuid-2', 'm1.large', 4, 8192, 0, 80, 0, 1.0, false, false)`,
+	)
+
+	collector := NewFlavorsCollector(logger, novadb.New(novaDB), novaapidb.New(novaAPIDB))
+
+	err := testutil.CollectAndCompare(&flavorsCollectorWrapper{collector}, strings.NewReader(`# HELP openstack_nova_flavor flavor
+# TYPE openstack_nova_flavor gauge
+openstack_nova_flavor{disk="20",id="flavor-uuid-1",is_public="true",name="m1.small",ram="2048",vcpus="1"} 1
+openstack_nova_flavor{disk="80",id="flavor-uuid-2",is_public="false",name="m1.large",ram="8192",vcpus="4"} 1
+# HELP openstack_nova_flavors flavors
+# TYPE openstack_nova_flavors gauge
+openstack_nova_flavors 2
+`), "openstack_nova_flavor", "openstack_nova_flavors")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestIntegration_NovaAPIQueries_PostgreSQL runs the nova API queries whose
+// results are only exported per keystone project.
+func TestIntegration_NovaAPIQueries_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	_, novaAPIDB := novaPostgreSQLDBs(t)
+	ctx := context.Background()
+
+	itest.SeedSQL(t, novaAPIDB,
+		`INSERT INTO quotas (project_id, resource, hard_limit) VALUES
+		('proj-1', 'cores', 40),
+		('proj-1', 'instances', 20)`,
+		`INSERT INTO quota_classes (class_name, resource, hard_limit) VALUES
+		('default', 'cores', 30),
+		('other', 'cores', 50)`,
+		`INSERT INTO quota_usages (project_id, user_id, resource, in_use, reserved) VALUES
+		('proj-1', 'user-1', 'cores', 4, 0)`,
+		`INSERT INTO aggregates (id, uuid, name, created_at) VALUES (1, 'agg-uuid-1', 'agg-1', now())`,
+	)
+
+	queries := novaapidb.New(novaAPIDB)
+
+	quotas, err := queries.GetQuotas(ctx)
+	if err != nil {
+		t.Fatalf("GetQuotas failed: %v", err)
+	}
+	if len(quotas) != 2 {
+		t.Fatalf("expected 2 quotas, got %d", len(quotas))
+	}
+
+	defaults, err := queries.GetQuotaClassDefaults(ctx)
+	if err != nil {
+		t.Fatalf("GetQuotaClassDefaults failed: %v", err)
+	}
+	if len(defaults) != 1 || defaults[0].Resource.String != "cores" || defaults[0].HardLimit.Int32 != 30 {
+		t.Fatalf("expected the default cores quota of 30, got %+v", defaults)
+	}
+
+	usages, err := queries.GetQuotaUsages(ctx)
+	if err != nil {
+		t.Fatalf("GetQuotaUsages failed: %v", err)
+	}
+	if len(usages) != 1 || usages[0].InUse != 4 {
+		t.Fatalf("expected 4 cores in use, got %+v", usages)
+	}
+
+	aggregates, err := queries.GetAggregates(ctx)
+	if err != nil {
+		t.Fatalf("GetAggregates failed: %v", err)
+	}
+	if len(aggregates) != 1 || aggregates[0].Name.String != "agg-1" || !aggregates[0].CreatedAt.Valid {
+		t.Fatalf("expected aggregate agg-1, got %+v", aggregates)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/placement"
@@ -84,22 +85,20 @@ func (c *LimitsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the prometheus.Collector interface
-func (c *LimitsCollector) Collect(ch chan<- prometheus.Metric) error {
-	return c.collectLimitsMetrics(ch)
+func (c *LimitsCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return c.collectLimitsMetrics(ctx, ch)
 }
 
-func (c *LimitsCollector) collectLimitsMetrics(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
-
+func (c *LimitsCollector) collectLimitsMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
 	// Get quotas (limits) from Nova API DB
-	quotas, err := c.novaAPIDB.GetQuotas(ctx)
+	quotas, err := db.InSnapshot(ctx, "nova_api", c.novaAPIDB).GetQuotas(ctx)
 	if err != nil {
 		return err
 	}
 
 	// Get default quota class overrides from DB (class_name = 'default')
 	dbDefaults := make(map[string]float64)
-	quotaClassDefaults, err := c.novaAPIDB.GetQuotaClassDefaults(ctx)
+	quotaClassDefaults, err := db.InSnapshot(ctx, "nova_api", c.novaAPIDB).GetQuotaClassDefaults(ctx)
 	if err != nil {
 		c.logger.Error("Failed to get quota class defaults", "error", err)
 	} else {
@@ -149,7 +148,7 @@ func (c *LimitsCollector) collectLimitsMetrics(ch chan<- prometheus.Metric) erro
 
 	if c.placementDB != nil {
 		// Get resource allocations (VCPU, MEMORY_MB) from placement
-		allocations, err := c.placementDB.GetAllocationsByProject(ctx)
+		allocations, err := db.InSnapshot(ctx, "placement", c.placementDB).GetAllocationsByProject(ctx)
 		if err != nil {
 			c.logger.Error("Failed to get allocations from placement", "error", err)
 		} else {
@@ -165,7 +164,7 @@ func (c *LimitsCollector) collectLimitsMetrics(ch chan<- prometheus.Metric) erro
 		}
 
 		// Get instance count (consumer count) from placement
-		consumerCounts, err := c.placementDB.GetConsumerCountByProject(ctx)
+		consumerCounts, err := db.InSnapshot(ctx, "placement", c.placementDB).GetConsumerCountByProject(ctx)
 		if err != nil {
 			c.logger.Error("Failed to get consumer count from placement", "error", err)
 		} else {
@@ -1,6 +1,7 @@
 package nova
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -75,5 +76,5 @@ type limitsCollectorWrapper struct {
 }
 
 func (w *limitsCollectorWrapper) Collect(ch chan<- prometheus.Metric) {
-	_ = w.LimitsCollector.Collect(ch)
+	_ = w.LimitsCollector.Collect(context.Background(), ch)
 }
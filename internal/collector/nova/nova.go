@@ -1,6 +1,8 @@
 package nova
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,39 +17,53 @@ const (
 	Subsystem = "nova"
 )
 
-func RegisterCollectors(registry *prometheus.Registry, novaDatabaseURL, novaApiDatabaseURL, placementDatabaseURL string, projectResolver *project.Resolver, logger *slog.Logger) {
-	if novaDatabaseURL == "" || novaApiDatabaseURL == "" {
+// Collectors lists the names of the collectors that can be enabled or
+// disabled individually.
+var Collectors = []string{
+	"services",
+	"flavors",
+	"quotas",
+	"limits",
+	"compute_nodes",
+	"server",
+}
+
+func RegisterCollectors(registry prometheus.Registerer, novaDatabase, novaApiDatabase, placementDatabase db.Config, enabled util.CollectorFilter, aggregate bool, projectResolver *project.Resolver, logger *slog.Logger) {
+	if novaDatabase.URL == "" || novaApiDatabase.URL == "" {
 		logger.Info("Collector not loaded", "service", "nova", "reason", "database URLs not configured")
 		return
 	}
-
-	novaConn, err := db.Connect(novaDatabaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to nova database", "service", "nova", "error", err)
-		registry.MustRegister(util.NewDownCollector(Namespace, Subsystem))
+	if !enabled.Any(Collectors) {
+		logger.Info("Collector not loaded", "service", "nova", "reason", "all collectors disabled")
 		return
 	}
 
-	novaApiConn, err := db.Connect(novaApiDatabaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to nova_api database", "service", "nova", "error", err)
-		registry.MustRegister(util.NewDownCollector(Namespace, Subsystem))
-		return
-	}
+	util.RegisterWhenConnected(registry, Namespace, Subsystem, "nova", func(ctx context.Context) ([]prometheus.Collector, error) {
+		novaConn, err := db.ConnectContext(ctx, novaDatabase)
+		if err != nil {
+			return nil, fmt.Errorf("nova database: %w", err)
+		}
 
-	var placementQueries *placementdb.Queries
-	if placementDatabaseURL != "" {
-		placementConn, err := db.Connect(placementDatabaseURL)
+		novaApiConn, err := db.ConnectContext(ctx, novaApiDatabase)
 		if err != nil {
-			logger.Warn("Failed to connect to placement database for Nova limits, limits_*_used metrics will be 0", "error", err)
-		} else {
-			placementQueries = placementdb.New(placementConn)
+			_ = novaDatabase.Close(novaConn)
+			return nil, fmt.Errorf("nova_api database: %w", err)
 		}
-	} else {
-		logger.Warn("Placement database URL not configured, Nova limits_*_used metrics will be 0")
-	}
 
-	registry.MustRegister(NewComputeCollector(novaConn, novaApiConn, placementQueries, projectResolver, logger))
+		var placementQueries *placementdb.Queries
+		if placementDatabase.URL != "" {
+			placementConn, err := db.ConnectContext(ctx, placementDatabase)
+			if err != nil {
+				logger.Warn("Failed to connect to placement database for Nova limits, limits_*_used metrics will be 0", "error", err)
+			} else {
+				placementQueries = placementdb.New(placementConn)
+			}
+		} else {
+			logger.Warn("Placement database URL not configured, Nova limits_*_used metrics will be 0")
+		}
 
-	logger.Info("Registered collectors", "service", "nova")
+		return []prometheus.Collector{
+			util.Named("compute", NewComputeCollector(novaConn, novaApiConn, placementQueries, projectResolver, enabled, aggregate, logger)),
+		}, nil
+	}, logger)
 }
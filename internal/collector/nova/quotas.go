@@ -6,6 +6,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/placement"
@@ -130,15 +131,13 @@ func (c *QuotasCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the prometheus.Collector interface
-func (c *QuotasCollector) Collect(ch chan<- prometheus.Metric) error {
-	return c.collectQuotaMetrics(ch)
+func (c *QuotasCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return c.collectQuotaMetrics(ctx, ch)
 }
 
-func (c *QuotasCollector) collectQuotaMetrics(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
-
+func (c *QuotasCollector) collectQuotaMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
 	// Get quotas (hard limits)
-	quotas, err := c.novaAPIDB.GetQuotas(ctx)
+	quotas, err := db.InSnapshot(ctx, "nova_api", c.novaAPIDB).GetQuotas(ctx)
 	if err != nil {
 		return err
 	}
@@ -151,7 +150,7 @@ func (c *QuotasCollector) collectQuotaMetrics(ch chan<- prometheus.Metric) error
 	diskUsedByProject := make(map[string]float64)
 
 	if c.placementDB != nil {
-		allocations, err := c.placementDB.GetAllocationsByProject(ctx)
+		allocations, err := db.InSnapshot(ctx, "placement", c.placementDB).GetAllocationsByProject(ctx)
 		if err != nil {
 			c.logger.Error("Failed to get allocations from placement for quotas", "error", err)
 		} else {
@@ -168,7 +167,7 @@ func (c *QuotasCollector) collectQuotaMetrics(ch chan<- prometheus.Metric) error
 			}
 		}
 
-		consumerCounts, err := c.placementDB.GetConsumerCountByProject(ctx)
+		consumerCounts, err := db.InSnapshot(ctx, "placement", c.placementDB).GetConsumerCountByProject(ctx)
 		if err != nil {
 			c.logger.Error("Failed to get consumer count from placement for quotas", "error", err)
 		} else {
@@ -196,7 +195,7 @@ func (c *QuotasCollector) collectQuotaMetrics(ch chan<- prometheus.Metric) error
 
 	// Get default quota class overrides from DB (class_name = 'default')
 	dbDefaults := make(map[string]float64)
-	quotaClassDefaults, err := c.novaAPIDB.GetQuotaClassDefaults(ctx)
+	quotaClassDefaults, err := db.InSnapshot(ctx, "nova_api", c.novaAPIDB).GetQuotaClassDefaults(ctx)
 	if err != nil {
 		c.logger.Error("Failed to get quota class defaults", "error", err)
 	} else {
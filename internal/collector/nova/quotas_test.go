@@ -1,6 +1,7 @@
 package nova
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -71,5 +72,5 @@ type quotasCollectorWrapper struct {
 }
 
 func (w *quotasCollectorWrapper) Collect(ch chan<- prometheus.Metric) {
-	_ = w.QuotasCollector.Collect(ch)
+	_ = w.QuotasCollector.Collect(context.Background(), ch)
 }
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	"github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 )
@@ -61,65 +63,71 @@ var (
 	taskStateOverrides = map[string]map[string]string{
 		"active": {
 			"shelving":                      "SHELVED",
-			"shelving_image_pending_upload":  "SHELVED",
-			"shelving_image_uploading":       "SHELVED",
-			"shelving_offloading":            "SHELVED",
-			"rebuilding":                     "REBUILD",
-			"rebuild_block_device_mapping":   "REBUILD",
-			"rebuild_spawning":               "REBUILD",
-			"migrating":                      "MIGRATING",
-			"resize_prep":                    "RESIZE",
-			"resize_migrating":               "RESIZE",
-			"resize_migrated":                "RESIZE",
-			"resize_finish":                  "RESIZE",
+			"shelving_image_pending_upload": "SHELVED",
+			"shelving_image_uploading":      "SHELVED",
+			"shelving_offloading":           "SHELVED",
+			"rebuilding":                    "REBUILD",
+			"rebuild_block_device_mapping":  "REBUILD",
+			"rebuild_spawning":              "REBUILD",
+			"migrating":                     "MIGRATING",
+			"resize_prep":                   "RESIZE",
+			"resize_migrating":              "RESIZE",
+			"resize_migrated":               "RESIZE",
+			"resize_finish":                 "RESIZE",
 		},
 		"stopped": {
-			"resize_prep":                    "RESIZE",
-			"resize_migrating":               "RESIZE",
-			"resize_migrated":                "RESIZE",
-			"resize_finish":                  "RESIZE",
-			"rebuilding":                     "REBUILD",
-			"rebuild_block_device_mapping":   "REBUILD",
-			"rebuild_spawning":               "REBUILD",
+			"resize_prep":                  "RESIZE",
+			"resize_migrating":             "RESIZE",
+			"resize_migrated":              "RESIZE",
+			"resize_finish":                "RESIZE",
+			"rebuilding":                   "REBUILD",
+			"rebuild_block_device_mapping": "REBUILD",
+			"rebuild_spawning":             "REBUILD",
 		},
 		"resized": {
-			"resize_reverting":               "REVERT_RESIZE",
+			"resize_reverting": "REVERT_RESIZE",
 		},
 		"paused": {
-			"migrating":                      "MIGRATING",
+			"migrating": "MIGRATING",
 		},
 	}
 )
 
 // ServerCollector collects metrics about Nova servers (instances)
 type ServerCollector struct {
-	logger        *slog.Logger
-	novaDB        *nova.Queries
-	novaAPIDB     *nova_api.Queries
-	serverMetrics map[string]*prometheus.Desc
+	logger          *slog.Logger
+	novaDB          *nova.Queries
+	novaAPIDB       *nova_api.Queries
+	projectResolver *project.Resolver
+	aggregate       bool
+	serverMetrics   map[string]*prometheus.Desc
 }
 
-// NewServerCollector creates a new server collector
-func NewServerCollector(logger *slog.Logger, novaDB *nova.Queries, novaAPIDB *nova_api.Queries) *ServerCollector {
-	return &ServerCollector{
+// NewServerCollector creates a new server collector. In aggregate mode it
+// exports the number and local disk of the servers per project, status,
+// flavor and availability zone instead of one series per server.
+func NewServerCollector(logger *slog.Logger, novaDB *nova.Queries, novaAPIDB *nova_api.Queries, projectResolver *project.Resolver, aggregate bool) *ServerCollector {
+	c := &ServerCollector{
 		logger: logger.With(
 			"namespace", Namespace,
 			"subsystem", Subsystem,
 			"collector", "server",
 		),
-		novaDB:    novaDB,
-		novaAPIDB: novaAPIDB,
+		novaDB:          novaDB,
+		novaAPIDB:       novaAPIDB,
+		projectResolver: projectResolver,
+		aggregate:       aggregate,
 		serverMetrics: map[string]*prometheus.Desc{
 			"server_local_gb": prometheus.NewDesc(
 				prometheus.BuildFQName(Namespace, Subsystem, "server_local_gb"),
 				"server_local_gb",
-				[]string{"id", "name", "tenant_id"},
+				[]string{"domain_id", "id", "name", "tenant", "tenant_id"},
 				nil,
 			),
 			"server_status": prometheus.NewDesc(
 				prometheus.BuildFQName(Namespace, Subsystem, "server_status"),
 				"server_status",
-				[]string{"address_ipv4", "address_ipv6", "availability_zone", "flavor_id", "host_id", "hypervisor_hostname", "id", "instance_libvirt", "name", "status", "tenant_id", "user_id", "uuid"},
+				[]string{"address_ipv4", "address_ipv6", "availability_zone", "domain_id", "flavor_id", "host_id", "hypervisor_hostname", "id", "instance_libvirt", "name", "status", "tenant", "tenant_id", "user_id", "uuid"},
 				nil,
 			),
 			"total_vms": prometheus.NewDesc(
@@ -136,6 +144,25 @@ func NewServerCollector(logger *slog.Logger, novaDB *nova.Queries, novaAPIDB *no
 			),
 		},
 	}
+
+	if aggregate {
+		delete(c.serverMetrics, "server_local_gb")
+		delete(c.serverMetrics, "server_status")
+		c.serverMetrics["server_count"] = prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, Subsystem, "server_count"),
+			"server_count",
+			[]string{"availability_zone", "domain_id", "flavor_id", "status", "tenant", "tenant_id"},
+			nil,
+		)
+		c.serverMetrics["servers_local_gb"] = prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, Subsystem, "servers_local_gb"),
+			"servers_local_gb",
+			[]string{"availability_zone", "domain_id", "flavor_id", "status", "tenant", "tenant_id"},
+			nil,
+		)
+	}
+
+	return c
 }
 
 // Describe implements the prometheus.Collector interface
@@ -146,20 +173,21 @@ func (c *ServerCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the prometheus.Collector interface
-func (c *ServerCollector) Collect(ch chan<- prometheus.Metric) error {
-	return c.collectServerMetrics(ch)
+func (c *ServerCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if c.aggregate {
+		return c.collectServerAggregates(ctx, ch)
+	}
+	return c.collectServerMetrics(ctx, ch)
 }
 
-func (c *ServerCollector) collectServerMetrics(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
-
-	instances, err := c.novaDB.GetInstances(ctx)
+func (c *ServerCollector) collectServerMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
+	instances, err := db.InSnapshot(ctx, "nova", c.novaDB).GetInstances(ctx)
 	if err != nil {
 		return err
 	}
 
 	// Build flavor map: integer ID -> flavorid UUID
-	flavors, err := c.novaAPIDB.GetFlavors(ctx)
+	flavors, err := db.InSnapshot(ctx, "nova_api", c.novaAPIDB).GetFlavors(ctx)
 	if err != nil {
 		return err
 	}
@@ -177,13 +205,17 @@ func (c *ServerCollector) collectServerMetrics(ch chan<- prometheus.Metric) erro
 			azSet[instance.AvailabilityZone.String] = true
 		}
 
+		projectName, domainID := c.projectResolver.Resolve(instance.ProjectID.String)
+
 		// Server local GB - using root_gb from instance
 		ch <- prometheus.MustNewConstMetric(
 			c.serverMetrics["server_local_gb"],
 			prometheus.GaugeValue,
 			float64(instance.RootGb.Int32),
+			domainID,
 			instance.Uuid,
 			instance.DisplayName.String,
+			projectName,
 			instance.ProjectID.String,
 		)
 
@@ -217,6 +249,7 @@ func (c *ServerCollector) collectServerMetrics(ch chan<- prometheus.Metric) erro
 			"", // address_ipv4 - would need separate query for fixed IPs
 			"", // address_ipv6 - would need separate query for fixed IPs
 			instance.AvailabilityZone.String,
+			domainID,
 			flavorID,
 			hostID,
 			instance.Host.String, // hypervisor_hostname same as host in simple setups
@@ -224,6 +257,7 @@ func (c *ServerCollector) collectServerMetrics(ch chan<- prometheus.Metric) erro
 			instanceLibvirt,
 			instance.DisplayName.String,
 			apiStatus,
+			projectName,
 			instance.ProjectID.String,
 			instance.UserID.String,
 			instance.Uuid,
@@ -247,6 +281,104 @@ func (c *ServerCollector) collectServerMetrics(ch chan<- prometheus.Metric) erro
 	return nil
 }
 
+// serverGroup holds the labels of the server aggregates. Several vm_state
+// and task_state pairs resolve to the same status, so the groups counted by
+// the database are merged.
+type serverGroup struct {
+	availabilityZone string
+	flavorID         string
+	status           string
+	tenantID         string
+}
+
+type serverTotals struct {
+	count   int64
+	localGB int64
+}
+
+func (c *ServerCollector) collectServerAggregates(ctx context.Context, ch chan<- prometheus.Metric) error {
+	aggregates, err := db.InSnapshot(ctx, "nova", c.novaDB).GetInstanceAggregates(ctx)
+	if err != nil {
+		return err
+	}
+
+	flavors, err := db.InSnapshot(ctx, "nova_api", c.novaAPIDB).GetFlavors(ctx)
+	if err != nil {
+		return err
+	}
+	flavorIDMap := make(map[int32]string, len(flavors))
+	for _, f := range flavors {
+		flavorIDMap[f.ID] = f.Flavorid
+	}
+
+	var totalVMs int64
+	azSet := make(map[string]bool)
+	groups := make(map[serverGroup]serverTotals)
+
+	for _, aggregate := range aggregates {
+		totalVMs += aggregate.Count
+		if aggregate.AvailabilityZone.Valid && aggregate.AvailabilityZone.String != "" {
+			azSet[aggregate.AvailabilityZone.String] = true
+		}
+
+		flavorID := ""
+		if aggregate.InstanceTypeID.Valid {
+			flavorID = flavorIDMap[aggregate.InstanceTypeID.Int32]
+		}
+
+		group := serverGroup{
+			availabilityZone: aggregate.AvailabilityZone.String,
+			flavorID:         flavorID,
+			status:           resolveServerStatus(aggregate.VmState.String, aggregate.TaskState.String),
+			tenantID:         aggregate.ProjectID.String,
+		}
+		totals := groups[group]
+		totals.count += aggregate.Count
+		totals.localGB += aggregate.RootGb
+		groups[group] = totals
+	}
+
+	for group, totals := range groups {
+		projectName, domainID := c.projectResolver.Resolve(group.tenantID)
+		ch <- prometheus.MustNewConstMetric(
+			c.serverMetrics["server_count"],
+			prometheus.GaugeValue,
+			float64(totals.count),
+			group.availabilityZone,
+			domainID,
+			group.flavorID,
+			group.status,
+			projectName,
+			group.tenantID,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.serverMetrics["servers_local_gb"],
+			prometheus.GaugeValue,
+			float64(totals.localGB),
+			group.availabilityZone,
+			domainID,
+			group.flavorID,
+			group.status,
+			projectName,
+			group.tenantID,
+		)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.serverMetrics["total_vms"],
+		prometheus.GaugeValue,
+		float64(totalVMs),
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.serverMetrics["availability_zones"],
+		prometheus.GaugeValue,
+		float64(len(azSet)),
+	)
+
+	return nil
+}
+
 func mapServerStatus(status string) int {
 	for idx, s := range knownServerStatuses {
 		if status == s {
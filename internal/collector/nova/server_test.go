@@ -1,13 +1,16 @@
 package nova
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	novadb "github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	novaapidb "github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
@@ -62,7 +65,68 @@ func TestServerCollector(t *testing.T) {
 	}
 
 	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) prometheus.Collector {
-		collector := NewServerCollector(logger, novadb.New(db), novaapidb.New(db))
+		collector := NewServerCollector(logger, novadb.New(db), novaapidb.New(db), project.NewResolver(logger, nil, 0), false)
+		return &serverCollectorWrapper{collector}
+	})
+}
+
+func TestServerCollector_Aggregate(t *testing.T) {
+	tests := []testutil.CollectorTestCase{
+		{
+			Name: "successful collection with aggregates",
+			SetupMock: func(mock sqlmock.Sqlmock) {
+				aggregates := sqlmock.NewRows([]string{
+					"project_id", "availability_zone", "instance_type_id", "vm_state", "task_state", "count", "root_gb",
+				}).AddRow(
+					"project-1", "nova", 1, "active", nil, 3, 60,
+				).AddRow(
+					"project-1", "nova", 1, "active", "powering_off", 1, 20,
+				).AddRow(
+					"project-1", "nova", 2, "active", "migrating", 1, 40,
+				).AddRow(
+					"project-2", "az-2", 3, "stopped", nil, 2, 0,
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(novadb.GetInstanceAggregates)).WillReturnRows(aggregates)
+
+				flavors := sqlmock.NewRows([]string{
+					"id", "flavorid", "name", "vcpus", "memory_mb", "root_gb",
+					"ephemeral_gb", "swap", "rxtx_factor", "disabled", "is_public",
+				}).AddRow(
+					1, "flavor-small", "m1.small", 1, 2048, 20, 0, 0, 1.0, false, true,
+				).AddRow(
+					2, "flavor-medium", "m1.medium", 2, 4096, 40, 0, 0, 1.0, false, true,
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(novaapidb.GetFlavors)).WillReturnRows(flavors)
+			},
+			ExpectedMetrics: `# HELP openstack_nova_availability_zones availability_zones
+# TYPE openstack_nova_availability_zones gauge
+openstack_nova_availability_zones 2
+# HELP openstack_nova_server_count server_count
+# TYPE openstack_nova_server_count gauge
+openstack_nova_server_count{availability_zone="az-2",domain_id="",flavor_id="",status="SHUTOFF",tenant="project-2",tenant_id="project-2"} 2
+openstack_nova_server_count{availability_zone="nova",domain_id="",flavor_id="flavor-medium",status="MIGRATING",tenant="project-1",tenant_id="project-1"} 1
+openstack_nova_server_count{availability_zone="nova",domain_id="",flavor_id="flavor-small",status="ACTIVE",tenant="project-1",tenant_id="project-1"} 4
+# HELP openstack_nova_servers_local_gb servers_local_gb
+# TYPE openstack_nova_servers_local_gb gauge
+openstack_nova_servers_local_gb{availability_zone="az-2",domain_id="",flavor_id="",status="SHUTOFF",tenant="project-2",tenant_id="project-2"} 0
+openstack_nova_servers_local_gb{availability_zone="nova",domain_id="",flavor_id="flavor-medium",status="MIGRATING",tenant="project-1",tenant_id="project-1"} 40
+openstack_nova_servers_local_gb{availability_zone="nova",domain_id="",flavor_id="flavor-small",status="ACTIVE",tenant="project-1",tenant_id="project-1"} 80
+# HELP openstack_nova_total_vms total_vms
+# TYPE openstack_nova_total_vms gauge
+openstack_nova_total_vms 7
+`,
+		},
+		{
+			Name: "database query error",
+			SetupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(novadb.GetInstanceAggregates)).WillReturnError(sql.ErrConnDone)
+			},
+			ExpectedMetrics: ``,
+		},
+	}
+
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) prometheus.Collector {
+		collector := NewServerCollector(logger, novadb.New(db), novaapidb.New(db), project.NewResolver(logger, nil, 0), true)
 		return &serverCollectorWrapper{collector}
 	})
 }
@@ -72,7 +136,7 @@ type serverCollectorWrapper struct {
 }
 
 func (w *serverCollectorWrapper) Collect(ch chan<- prometheus.Metric) {
-	_ = w.ServerCollector.Collect(ch)
+	_ = w.ServerCollector.Collect(context.Background(), ch)
 }
 
 func TestResolveServerStatus(t *testing.T) {
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	novadb "github.com/vexxhost/openstack_database_exporter/internal/db/nova"
 	novaapidb "github.com/vexxhost/openstack_database_exporter/internal/db/nova_api"
 )
@@ -50,10 +51,8 @@ func (c *ServicesCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- agentStateDesc
 }
 
-func (c *ServicesCollector) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
-
-	services, err := c.novaDB.GetServices(ctx)
+func (c *ServicesCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	services, err := db.InSnapshot(ctx, "nova", c.novaDB).GetServices(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get services: %w", err)
 	}
@@ -1,6 +1,7 @@
 package nova
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"regexp"
@@ -91,5 +92,5 @@ type servicesCollectorWrapper struct {
 }
 
 func (w *servicesCollectorWrapper) Collect(ch chan<- prometheus.Metric) {
-	_ = w.ServicesCollector.Collect(ch)
+	_ = w.ServicesCollector.Collect(context.Background(), ch)
 }
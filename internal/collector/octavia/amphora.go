@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	octaviadb "github.com/vexxhost/openstack_database_exporter/internal/db/octavia"
 	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
@@ -60,9 +61,13 @@ func (c *AmphoraCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *AmphoraCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *AmphoraCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "octavia", c.queries)
 
-	amphorae, err := c.queries.GetAllAmphora(ctx)
+	amphorae, err := queries.GetAllAmphora(ctx)
 	if err != nil {
 		c.logger.Error("failed to query", "error", err)
 		return
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	itest "github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
 
@@ -65,7 +66,7 @@ func TestIntegration_LoadBalancerCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewLoadBalancerCollector(db, logger)
+		collector := NewLoadBalancerCollector(db, logger, project.NewResolver(logger, nil, 0))
 		expected := `# HELP openstack_loadbalancer_total_loadbalancers total_loadbalancers
 # TYPE openstack_loadbalancer_total_loadbalancers gauge
 openstack_loadbalancer_total_loadbalancers 0
@@ -89,13 +90,13 @@ openstack_loadbalancer_up 1
 			('lb-001', '203.0.113.50')`,
 		)
 
-		collector := NewLoadBalancerCollector(db, logger)
+		collector := NewLoadBalancerCollector(db, logger, project.NewResolver(logger, nil, 0))
 
 		// DELETED load balancer should be excluded
 		expected := `# HELP openstack_loadbalancer_loadbalancer_status loadbalancer_status
 # TYPE openstack_loadbalancer_loadbalancer_status gauge
-openstack_loadbalancer_loadbalancer_status{id="lb-001",name="web-lb",operating_status="ONLINE",project_id="proj-abc",provider="octavia",provisioning_status="ACTIVE",vip_address="203.0.113.50"} 0
-openstack_loadbalancer_loadbalancer_status{id="lb-002",name="api-lb",operating_status="DRAINING",project_id="proj-abc",provider="octavia",provisioning_status="ACTIVE",vip_address=""} 1
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-001",name="web-lb",operating_status="ONLINE",project_id="proj-abc",provider="octavia",provisioning_status="ACTIVE",tenant="proj-abc",vip_address="203.0.113.50"} 0
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-002",name="api-lb",operating_status="DRAINING",project_id="proj-abc",provider="octavia",provisioning_status="ACTIVE",tenant="proj-abc",vip_address=""} 1
 # HELP openstack_loadbalancer_total_loadbalancers total_loadbalancers
 # TYPE openstack_loadbalancer_total_loadbalancers gauge
 openstack_loadbalancer_total_loadbalancers 2
@@ -117,7 +118,7 @@ func TestIntegration_PoolCollector(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("empty database", func(t *testing.T) {
-		collector := NewPoolCollector(db, logger)
+		collector := NewPoolCollector(db, logger, project.NewResolver(logger, nil, 0))
 		expected := `# HELP openstack_loadbalancer_total_pools total_pools
 # TYPE openstack_loadbalancer_total_pools gauge
 openstack_loadbalancer_total_pools 0
@@ -136,13 +137,13 @@ openstack_loadbalancer_total_pools 0
 			('pool-del', 'proj-abc', 'deleted-pool', 'TCP', 'ROUND_ROBIN', 'OFFLINE', 0, NULL, 'DELETED', 0)`,
 		)
 
-		collector := NewPoolCollector(db, logger)
+		collector := NewPoolCollector(db, logger, project.NewResolver(logger, nil, 0))
 
 		// DELETED pool should be excluded
 		expected := `# HELP openstack_loadbalancer_pool_status pool_status
 # TYPE openstack_loadbalancer_pool_status gauge
-openstack_loadbalancer_pool_status{id="pool-001",lb_algorithm="ROUND_ROBIN",loadbalancers="lb-001",name="http-pool",operating_status="ONLINE",project_id="proj-abc",protocol="HTTP",provisioning_status="ACTIVE"} 0
-openstack_loadbalancer_pool_status{id="pool-002",lb_algorithm="LEAST_CONNECTIONS",loadbalancers="",name="tcp-pool",operating_status="ERROR",project_id="proj-abc",protocol="TCP",provisioning_status="ERROR"} 1
+openstack_loadbalancer_pool_status{domain_id="",id="pool-001",lb_algorithm="ROUND_ROBIN",loadbalancers="lb-001",name="http-pool",operating_status="ONLINE",project_id="proj-abc",protocol="HTTP",provisioning_status="ACTIVE",tenant="proj-abc"} 0
+openstack_loadbalancer_pool_status{domain_id="",id="pool-002",lb_algorithm="LEAST_CONNECTIONS",loadbalancers="",name="tcp-pool",operating_status="ERROR",project_id="proj-abc",protocol="TCP",provisioning_status="ERROR",tenant="proj-abc"} 1
 # HELP openstack_loadbalancer_total_pools total_pools
 # TYPE openstack_loadbalancer_total_pools gauge
 openstack_loadbalancer_total_pools 2
@@ -153,3 +154,98 @@ openstack_loadbalancer_total_pools 2
 		}
 	})
 }
+
+func TestIntegration_AmphoraCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "octavia", "../../../sql/octavia/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO amphora (id, compute_id, status, load_balancer_id, lb_network_ip, ha_ip, role, cert_expiration, cert_busy) VALUES
+		('amp-001', 'compute-001', 'READY', 'lb-001', '10.0.0.1', '10.0.0.2', 'MASTER', '2025-12-31 23:59:59', false),
+		('amp-003', NULL, 'BOOTING', NULL, NULL, NULL, NULL, NULL, false),
+		('amp-del', 'compute-del', 'DELETED', 'lb-del', '10.0.0.99', NULL, NULL, NULL, false)`,
+	)
+
+	collector := NewAmphoraCollector(db, logger)
+
+	// DELETED amphora should be excluded
+	expected := `# HELP openstack_loadbalancer_amphora_status amphora_status
+# TYPE openstack_loadbalancer_amphora_status gauge
+openstack_loadbalancer_amphora_status{cert_expiration="",compute_id="",ha_ip="",id="amp-003",lb_network_ip="",loadbalancer_id="",role="",status="BOOTING"} 0
+openstack_loadbalancer_amphora_status{cert_expiration="2025-12-31T23:59:59Z",compute_id="compute-001",ha_ip="10.0.0.2",id="amp-001",lb_network_ip="10.0.0.1",loadbalancer_id="lb-001",role="MASTER",status="READY"} 2
+# HELP openstack_loadbalancer_total_amphorae total_amphorae
+# TYPE openstack_loadbalancer_total_amphorae gauge
+openstack_loadbalancer_total_amphorae 2
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestIntegration_LoadBalancerCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "octavia", "../../../sql/octavia/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO load_balancer (id, project_id, name, provisioning_status, operating_status, enabled, provider) VALUES
+		('lb-001', 'proj-abc', 'web-lb', 'ACTIVE', 'ONLINE', true, 'octavia'),
+		('lb-002', 'proj-abc', 'api-lb', 'ACTIVE', 'DRAINING', true, 'octavia'),
+		('lb-del', 'proj-abc', 'deleted-lb', 'DELETED', 'OFFLINE', false, 'octavia')`,
+		`INSERT INTO vip (load_balancer_id, ip_address) VALUES
+		('lb-001', '203.0.113.50')`,
+	)
+
+	collector := NewLoadBalancerCollector(db, logger, project.NewResolver(logger, nil, 0))
+
+	// DELETED load balancer should be excluded
+	expected := `# HELP openstack_loadbalancer_loadbalancer_status loadbalancer_status
+# TYPE openstack_loadbalancer_loadbalancer_status gauge
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-001",name="web-lb",operating_status="ONLINE",project_id="proj-abc",provider="octavia",provisioning_status="ACTIVE",tenant="proj-abc",vip_address="203.0.113.50"} 0
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-002",name="api-lb",operating_status="DRAINING",project_id="proj-abc",provider="octavia",provisioning_status="ACTIVE",tenant="proj-abc",vip_address=""} 1
+# HELP openstack_loadbalancer_total_loadbalancers total_loadbalancers
+# TYPE openstack_loadbalancer_total_loadbalancers gauge
+openstack_loadbalancer_total_loadbalancers 2
+# HELP openstack_loadbalancer_up up
+# TYPE openstack_loadbalancer_up gauge
+openstack_loadbalancer_up 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestIntegration_PoolCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "octavia", "../../../sql/octavia/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO pool (id, project_id, name, protocol, lb_algorithm, operating_status, enabled, load_balancer_id, provisioning_status) VALUES
+		('pool-001', 'proj-abc', 'http-pool', 'HTTP', 'ROUND_ROBIN', 'ONLINE', true, 'lb-001', 'ACTIVE'),
+		('pool-002', 'proj-abc', 'tcp-pool', 'TCP', 'LEAST_CONNECTIONS', 'ERROR', true, NULL, 'ERROR'),
+		('pool-del', 'proj-abc', 'deleted-pool', 'TCP', 'ROUND_ROBIN', 'OFFLINE', false, NULL, 'DELETED')`,
+	)
+
+	collector := NewPoolCollector(db, logger, project.NewResolver(logger, nil, 0))
+
+	// DELETED pool should be excluded
+	expected := `# HELP openstack_loadbalancer_pool_status pool_status
+# TYPE openstack_loadbalancer_pool_status gauge
+openstack_loadbalancer_pool_status{domain_id="",id="pool-001",lb_algorithm="ROUND_ROBIN",loadbalancers="lb-001",name="http-pool",operating_status="ONLINE",project_id="proj-abc",protocol="HTTP",provisioning_status="ACTIVE",tenant="proj-abc"} 0
+openstack_loadbalancer_pool_status{domain_id="",id="pool-002",lb_algorithm="LEAST_CONNECTIONS",loadbalancers="",name="tcp-pool",operating_status="ERROR",project_id="proj-abc",protocol="TCP",provisioning_status="ERROR",tenant="proj-abc"} 1
+# HELP openstack_loadbalancer_total_pools total_pools
+# TYPE openstack_loadbalancer_total_pools gauge
+openstack_loadbalancer_total_pools 2
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
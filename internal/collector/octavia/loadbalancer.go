@@ -6,6 +6,8 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	octaviadb "github.com/vexxhost/openstack_database_exporter/internal/db/octavia"
 	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
@@ -18,6 +20,8 @@ var (
 			"id",
 			"name",
 			"project_id",
+			"tenant",
+			"domain_id",
 			"operating_status",
 			"provisioning_status",
 			"provider",
@@ -42,12 +46,13 @@ var (
 )
 
 type LoadBalancerCollector struct {
-	db      *sql.DB
-	queries *octaviadb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *octaviadb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewLoadBalancerCollector(db *sql.DB, logger *slog.Logger) *LoadBalancerCollector {
+func NewLoadBalancerCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *LoadBalancerCollector {
 	return &LoadBalancerCollector{
 		db:      db,
 		queries: octaviadb.New(db),
@@ -56,6 +61,7 @@ func NewLoadBalancerCollector(db *sql.DB, logger *slog.Logger) *LoadBalancerColl
 			"subsystem", Subsystem,
 			"collector", "loadbalancer",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -66,9 +72,13 @@ func (c *LoadBalancerCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *LoadBalancerCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *LoadBalancerCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "octavia", c.queries)
 
-	loadBalancers, err := c.queries.GetAllLoadBalancersWithVip(ctx)
+	loadBalancers, err := queries.GetAllLoadBalancersWithVip(ctx)
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
 
@@ -77,6 +87,7 @@ func (c *LoadBalancerCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 
 	for _, lb := range loadBalancers {
+		projectName, domainID := c.projectResolver.Resolve(lb.ProjectID.String)
 		ch <- prometheus.MustNewConstMetric(
 			loadBalancerStatusDesc,
 			prometheus.GaugeValue,
@@ -90,6 +101,8 @@ func (c *LoadBalancerCollector) Collect(ch chan<- prometheus.Metric) {
 			lb.ID,
 			lb.Name.String,
 			lb.ProjectID.String,
+			projectName,
+			domainID,
 			lb.OperatingStatus,
 			lb.ProvisioningStatus,
 			lb.Provider.String,
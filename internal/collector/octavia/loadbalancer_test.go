@@ -2,9 +2,11 @@ package octavia
 
 import (
 	"database/sql"
+	"log/slog"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	octaviadb "github.com/vexxhost/openstack_database_exporter/internal/db/octavia"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -28,7 +30,7 @@ func TestLoadBalancerCollector_Collect(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_loadbalancer_loadbalancer_status loadbalancer_status
 # TYPE openstack_loadbalancer_loadbalancer_status gauge
-openstack_loadbalancer_loadbalancer_status{id="607226db-27ef-4d41-ae89-f2a800e9c2db",name="best_load_balancer",operating_status="ONLINE",project_id="e3cd678b11784734bc366148aa37580e",provider="octavia",provisioning_status="ACTIVE",vip_address="203.0.113.50"} 0
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="607226db-27ef-4d41-ae89-f2a800e9c2db",name="best_load_balancer",operating_status="ONLINE",project_id="e3cd678b11784734bc366148aa37580e",provider="octavia",provisioning_status="ACTIVE",tenant="e3cd678b11784734bc366148aa37580e",vip_address="203.0.113.50"} 0
 # HELP openstack_loadbalancer_total_loadbalancers total_loadbalancers
 # TYPE openstack_loadbalancer_total_loadbalancers gauge
 openstack_loadbalancer_total_loadbalancers 1
@@ -62,7 +64,7 @@ openstack_loadbalancer_up 1
 			},
 			ExpectedMetrics: `# HELP openstack_loadbalancer_loadbalancer_status loadbalancer_status
 # TYPE openstack_loadbalancer_loadbalancer_status gauge
-openstack_loadbalancer_loadbalancer_status{id="lb-001",name="",operating_status="OFFLINE",project_id="",provider="",provisioning_status="PENDING_CREATE",vip_address=""} 2
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-001",name="",operating_status="OFFLINE",project_id="",provider="",provisioning_status="PENDING_CREATE",tenant="",vip_address=""} 2
 # HELP openstack_loadbalancer_total_loadbalancers total_loadbalancers
 # TYPE openstack_loadbalancer_total_loadbalancers gauge
 openstack_loadbalancer_total_loadbalancers 1
@@ -85,12 +87,12 @@ openstack_loadbalancer_up 1
 			},
 			ExpectedMetrics: `# HELP openstack_loadbalancer_loadbalancer_status loadbalancer_status
 # TYPE openstack_loadbalancer_loadbalancer_status gauge
-openstack_loadbalancer_loadbalancer_status{id="lb-1",name="",operating_status="ONLINE",project_id="",provider="",provisioning_status="ACTIVE",vip_address=""} 0
-openstack_loadbalancer_loadbalancer_status{id="lb-2",name="",operating_status="DRAINING",project_id="",provider="",provisioning_status="ACTIVE",vip_address=""} 1
-openstack_loadbalancer_loadbalancer_status{id="lb-3",name="",operating_status="OFFLINE",project_id="",provider="",provisioning_status="ACTIVE",vip_address=""} 2
-openstack_loadbalancer_loadbalancer_status{id="lb-4",name="",operating_status="ERROR",project_id="",provider="",provisioning_status="ACTIVE",vip_address=""} 3
-openstack_loadbalancer_loadbalancer_status{id="lb-5",name="",operating_status="NO_MONITOR",project_id="",provider="",provisioning_status="ACTIVE",vip_address=""} 4
-openstack_loadbalancer_loadbalancer_status{id="lb-6",name="",operating_status="UNKNOWN_OP",project_id="",provider="",provisioning_status="ACTIVE",vip_address=""} -1
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-1",name="",operating_status="ONLINE",project_id="",provider="",provisioning_status="ACTIVE",tenant="",vip_address=""} 0
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-2",name="",operating_status="DRAINING",project_id="",provider="",provisioning_status="ACTIVE",tenant="",vip_address=""} 1
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-3",name="",operating_status="OFFLINE",project_id="",provider="",provisioning_status="ACTIVE",tenant="",vip_address=""} 2
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-4",name="",operating_status="ERROR",project_id="",provider="",provisioning_status="ACTIVE",tenant="",vip_address=""} 3
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-5",name="",operating_status="NO_MONITOR",project_id="",provider="",provisioning_status="ACTIVE",tenant="",vip_address=""} 4
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-6",name="",operating_status="UNKNOWN_OP",project_id="",provider="",provisioning_status="ACTIVE",tenant="",vip_address=""} -1
 # HELP openstack_loadbalancer_total_loadbalancers total_loadbalancers
 # TYPE openstack_loadbalancer_total_loadbalancers gauge
 openstack_loadbalancer_total_loadbalancers 6
@@ -110,9 +112,9 @@ openstack_loadbalancer_up 1
 			},
 			ExpectedMetrics: `# HELP openstack_loadbalancer_loadbalancer_status loadbalancer_status
 # TYPE openstack_loadbalancer_loadbalancer_status gauge
-openstack_loadbalancer_loadbalancer_status{id="lb-a",name="web-lb",operating_status="ONLINE",project_id="proj-1",provider="octavia",provisioning_status="ACTIVE",vip_address="10.0.0.1"} 0
-openstack_loadbalancer_loadbalancer_status{id="lb-b",name="api-lb",operating_status="DRAINING",project_id="proj-2",provider="amphora",provisioning_status="PENDING_UPDATE",vip_address="10.0.0.2"} 1
-openstack_loadbalancer_loadbalancer_status{id="lb-c",name="internal-lb",operating_status="ERROR",project_id="proj-1",provider="octavia",provisioning_status="ERROR",vip_address="10.0.0.3"} 3
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-a",name="web-lb",operating_status="ONLINE",project_id="proj-1",provider="octavia",provisioning_status="ACTIVE",tenant="proj-1",vip_address="10.0.0.1"} 0
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-b",name="api-lb",operating_status="DRAINING",project_id="proj-2",provider="amphora",provisioning_status="PENDING_UPDATE",tenant="proj-2",vip_address="10.0.0.2"} 1
+openstack_loadbalancer_loadbalancer_status{domain_id="",id="lb-c",name="internal-lb",operating_status="ERROR",project_id="proj-1",provider="octavia",provisioning_status="ERROR",tenant="proj-1",vip_address="10.0.0.3"} 3
 # HELP openstack_loadbalancer_total_loadbalancers total_loadbalancers
 # TYPE openstack_loadbalancer_total_loadbalancers gauge
 openstack_loadbalancer_total_loadbalancers 3
@@ -133,5 +135,7 @@ openstack_loadbalancer_up 0
 		},
 	}
 
-	testutil.RunCollectorTests(t, tests, NewLoadBalancerCollector)
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *LoadBalancerCollector {
+		return NewLoadBalancerCollector(db, logger, project.NewResolver(logger, nil, 0))
+	})
 }
@@ -1,9 +1,11 @@
 package octavia
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
@@ -13,22 +15,34 @@ const (
 	Subsystem = "loadbalancer"
 )
 
-func RegisterCollectors(registry *prometheus.Registry, databaseURL string, logger *slog.Logger) {
-	if databaseURL == "" {
+// Collectors lists the names of the collectors that can be enabled or
+// disabled individually.
+var Collectors = []string{
+	"amphora",
+	"loadbalancer",
+	"pool",
+}
+
+func RegisterCollectors(registry prometheus.Registerer, database db.Config, enabled util.CollectorFilter, projectResolver *project.Resolver, logger *slog.Logger) {
+	if database.URL == "" {
 		logger.Info("Collector not loaded", "service", "octavia", "reason", "database URL not configured")
 		return
 	}
-
-	conn, err := db.Connect(databaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to database", "service", "octavia", "error", err)
-		registry.MustRegister(util.NewDownCollector(Namespace, Subsystem))
+	if !enabled.Any(Collectors) {
+		logger.Info("Collector not loaded", "service", "octavia", "reason", "all collectors disabled")
 		return
 	}
 
-	registry.MustRegister(NewAmphoraCollector(conn, logger))
-	registry.MustRegister(NewLoadBalancerCollector(conn, logger))
-	registry.MustRegister(NewPoolCollector(conn, logger))
+	util.RegisterWhenConnected(registry, Namespace, Subsystem, "octavia", func(ctx context.Context) ([]prometheus.Collector, error) {
+		conn, err := db.ConnectContext(ctx, database)
+		if err != nil {
+			return nil, err
+		}
 
-	logger.Info("Registered collectors", "service", "octavia")
+		return enabled.Select(map[string]prometheus.Collector{
+			"amphora":      NewAmphoraCollector(conn, logger),
+			"loadbalancer": NewLoadBalancerCollector(conn, logger, projectResolver),
+			"pool":         NewPoolCollector(conn, logger, projectResolver),
+		}), nil
+	}, logger)
 }
@@ -6,6 +6,8 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	octaviadb "github.com/vexxhost/openstack_database_exporter/internal/db/octavia"
 	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
@@ -23,6 +25,8 @@ var (
 			"lb_algorithm",
 			"operating_status",
 			"project_id",
+			"tenant",
+			"domain_id",
 		},
 		nil,
 	)
@@ -36,12 +40,13 @@ var (
 )
 
 type PoolCollector struct {
-	db      *sql.DB
-	queries *octaviadb.Queries
-	logger  *slog.Logger
+	db              *sql.DB
+	queries         *octaviadb.Queries
+	logger          *slog.Logger
+	projectResolver *project.Resolver
 }
 
-func NewPoolCollector(db *sql.DB, logger *slog.Logger) *PoolCollector {
+func NewPoolCollector(db *sql.DB, logger *slog.Logger, projectResolver *project.Resolver) *PoolCollector {
 	return &PoolCollector{
 		db:      db,
 		queries: octaviadb.New(db),
@@ -50,6 +55,7 @@ func NewPoolCollector(db *sql.DB, logger *slog.Logger) *PoolCollector {
 			"subsystem", Subsystem,
 			"collector", "pool",
 		),
+		projectResolver: projectResolver,
 	}
 }
 
@@ -59,15 +65,20 @@ func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *PoolCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "octavia", c.queries)
 
-	pools, err := c.queries.GetAllPools(ctx)
+	pools, err := queries.GetAllPools(ctx)
 	if err != nil {
 		c.logger.Error("failed to query", "error", err)
 		return
 	}
 
 	for _, pool := range pools {
+		projectName, domainID := c.projectResolver.Resolve(pool.ProjectID.String)
 		ch <- prometheus.MustNewConstMetric(
 			poolStatusDesc,
 			prometheus.GaugeValue,
@@ -86,6 +97,8 @@ func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
 			pool.LbAlgorithm,
 			pool.OperatingStatus,
 			pool.ProjectID.String,
+			projectName,
+			domainID,
 		)
 	}
 
@@ -2,9 +2,11 @@ package octavia
 
 import (
 	"database/sql"
+	"log/slog"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
 	octaviadb "github.com/vexxhost/openstack_database_exporter/internal/db/octavia"
 	"github.com/vexxhost/openstack_database_exporter/internal/testutil"
 )
@@ -28,7 +30,7 @@ func TestPoolCollector(t *testing.T) {
 			},
 			ExpectedMetrics: `# HELP openstack_loadbalancer_pool_status pool_status
 # TYPE openstack_loadbalancer_pool_status gauge
-openstack_loadbalancer_pool_status{id="ca00ed86-94e3-440e-95c6-ffa35531081e",lb_algorithm="ROUND_ROBIN",loadbalancers="e7284bb2-f46a-42ca-8c9b-e08671255125",name="my_test_pool",operating_status="ERROR",project_id="8b1632d90bfe407787d9996b7f662fd7",protocol="TCP",provisioning_status="ACTIVE"} 0
+openstack_loadbalancer_pool_status{domain_id="",id="ca00ed86-94e3-440e-95c6-ffa35531081e",lb_algorithm="ROUND_ROBIN",loadbalancers="e7284bb2-f46a-42ca-8c9b-e08671255125",name="my_test_pool",operating_status="ERROR",project_id="8b1632d90bfe407787d9996b7f662fd7",protocol="TCP",provisioning_status="ACTIVE",tenant="8b1632d90bfe407787d9996b7f662fd7"} 0
 # HELP openstack_loadbalancer_total_pools total_pools
 # TYPE openstack_loadbalancer_total_pools gauge
 openstack_loadbalancer_total_pools 1
@@ -56,7 +58,7 @@ openstack_loadbalancer_total_pools 0
 			},
 			ExpectedMetrics: `# HELP openstack_loadbalancer_pool_status pool_status
 # TYPE openstack_loadbalancer_pool_status gauge
-openstack_loadbalancer_pool_status{id="pool-001",lb_algorithm="LEAST_CONNECTIONS",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="HTTP",provisioning_status="PENDING_CREATE"} 2
+openstack_loadbalancer_pool_status{domain_id="",id="pool-001",lb_algorithm="LEAST_CONNECTIONS",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="HTTP",provisioning_status="PENDING_CREATE",tenant=""} 2
 # HELP openstack_loadbalancer_total_pools total_pools
 # TYPE openstack_loadbalancer_total_pools gauge
 openstack_loadbalancer_total_pools 1
@@ -76,12 +78,12 @@ openstack_loadbalancer_total_pools 1
 			},
 			ExpectedMetrics: `# HELP openstack_loadbalancer_pool_status pool_status
 # TYPE openstack_loadbalancer_pool_status gauge
-openstack_loadbalancer_pool_status{id="p-1",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="ACTIVE"} 0
-openstack_loadbalancer_pool_status{id="p-2",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="ERROR"} 1
-openstack_loadbalancer_pool_status{id="p-3",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="PENDING_CREATE"} 2
-openstack_loadbalancer_pool_status{id="p-4",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="PENDING_UPDATE"} 3
-openstack_loadbalancer_pool_status{id="p-5",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="PENDING_DELETE"} 4
-openstack_loadbalancer_pool_status{id="p-6",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="UNKNOWN_PROV"} -1
+openstack_loadbalancer_pool_status{domain_id="",id="p-1",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="ACTIVE",tenant=""} 0
+openstack_loadbalancer_pool_status{domain_id="",id="p-2",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="ERROR",tenant=""} 1
+openstack_loadbalancer_pool_status{domain_id="",id="p-3",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="PENDING_CREATE",tenant=""} 2
+openstack_loadbalancer_pool_status{domain_id="",id="p-4",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="PENDING_UPDATE",tenant=""} 3
+openstack_loadbalancer_pool_status{domain_id="",id="p-5",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="PENDING_DELETE",tenant=""} 4
+openstack_loadbalancer_pool_status{domain_id="",id="p-6",lb_algorithm="ROUND_ROBIN",loadbalancers="",name="",operating_status="ONLINE",project_id="",protocol="TCP",provisioning_status="UNKNOWN_PROV",tenant=""} -1
 # HELP openstack_loadbalancer_total_pools total_pools
 # TYPE openstack_loadbalancer_total_pools gauge
 openstack_loadbalancer_total_pools 6
@@ -98,9 +100,9 @@ openstack_loadbalancer_total_pools 6
 			},
 			ExpectedMetrics: `# HELP openstack_loadbalancer_pool_status pool_status
 # TYPE openstack_loadbalancer_pool_status gauge
-openstack_loadbalancer_pool_status{id="pool-a",lb_algorithm="ROUND_ROBIN",loadbalancers="lb-1",name="http-pool",operating_status="ONLINE",project_id="proj-1",protocol="HTTP",provisioning_status="ACTIVE"} 0
-openstack_loadbalancer_pool_status{id="pool-b",lb_algorithm="LEAST_CONNECTIONS",loadbalancers="lb-1",name="https-pool",operating_status="ERROR",project_id="proj-1",protocol="HTTPS",provisioning_status="ACTIVE"} 0
-openstack_loadbalancer_pool_status{id="pool-c",lb_algorithm="SOURCE_IP",loadbalancers="lb-2",name="tcp-pool",operating_status="OFFLINE",project_id="proj-2",protocol="TCP",provisioning_status="PENDING_UPDATE"} 3
+openstack_loadbalancer_pool_status{domain_id="",id="pool-a",lb_algorithm="ROUND_ROBIN",loadbalancers="lb-1",name="http-pool",operating_status="ONLINE",project_id="proj-1",protocol="HTTP",provisioning_status="ACTIVE",tenant="proj-1"} 0
+openstack_loadbalancer_pool_status{domain_id="",id="pool-b",lb_algorithm="LEAST_CONNECTIONS",loadbalancers="lb-1",name="https-pool",operating_status="ERROR",project_id="proj-1",protocol="HTTPS",provisioning_status="ACTIVE",tenant="proj-1"} 0
+openstack_loadbalancer_pool_status{domain_id="",id="pool-c",lb_algorithm="SOURCE_IP",loadbalancers="lb-2",name="tcp-pool",operating_status="OFFLINE",project_id="proj-2",protocol="TCP",provisioning_status="PENDING_UPDATE",tenant="proj-2"} 3
 # HELP openstack_loadbalancer_total_pools total_pools
 # TYPE openstack_loadbalancer_total_pools gauge
 openstack_loadbalancer_total_pools 3
@@ -116,5 +118,7 @@ openstack_loadbalancer_total_pools 3
 		},
 	}
 
-	testutil.RunCollectorTests(t, tests, NewPoolCollector)
+	testutil.RunCollectorTests(t, tests, func(db *sql.DB, logger *slog.Logger) *PoolCollector {
+		return NewPoolCollector(db, logger, project.NewResolver(logger, nil, 0))
+	})
 }
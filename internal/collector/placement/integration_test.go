@@ -93,3 +93,37 @@ openstack_placement_resource_usage{hostname="compute-002",resourcetype="VCPU"} 8
 		}
 	})
 }
+
+func TestIntegration_ResourcesCollector_PostgreSQL(t *testing.T) {
+	itest.SkipIfNoDocker(t)
+
+	db := itest.NewPostgreSQLContainer(t, "placement", "../../../sql/placement/postgresql/schema.sql")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	itest.SeedSQL(t, db,
+		`INSERT INTO resource_providers (id, uuid, name, generation, root_provider_id) VALUES
+		(1, 'rp-uuid-001', 'compute-001', 1, 1)`,
+		`INSERT INTO resource_classes (id, name) VALUES
+		(1, 'VCPU'),
+		(2, 'MEMORY_MB')`,
+		`INSERT INTO inventories (id, resource_provider_id, resource_class_id, total, reserved, min_unit, max_unit, step_size, allocation_ratio) VALUES
+		(1, 1, 1, 64, 0, 1, 64, 1, 16.0000),
+		(2, 1, 2, 131072, 512, 1, 131072, 1, 1.5000)`,
+		`INSERT INTO allocations (id, resource_provider_id, consumer_id, resource_class_id, used) VALUES
+		(1, 1, 'inst-001', 1, 4),
+		(2, 1, 'inst-001', 2, 8192),
+		(3, 1, 'inst-002', 1, 2)`,
+	)
+
+	collector := NewResourcesCollector(db, logger)
+
+	expected := `# HELP openstack_placement_resource_usage resource_usage
+# TYPE openstack_placement_resource_usage gauge
+openstack_placement_resource_usage{hostname="compute-001",resourcetype="MEMORY_MB"} 8192
+openstack_placement_resource_usage{hostname="compute-001",resourcetype="VCPU"} 6
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "openstack_placement_resource_usage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 	placementdb "github.com/vexxhost/openstack_database_exporter/internal/db/placement"
 )
 
@@ -85,9 +86,13 @@ func (c *ResourcesCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *ResourcesCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *ResourcesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	queries := db.InSnapshot(ctx, "placement", c.queries)
 
-	resources, err := c.queries.GetResourceMetrics(ctx)
+	resources, err := queries.GetResourceMetrics(ctx)
 	if err != nil {
 		c.logger.Error("Failed to collect placement resources", "error", err)
 		ch <- prometheus.MustNewConstMetric(placementUpDesc, prometheus.GaugeValue, 0)
@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	lastSuccessTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, exporterSubsystem, "last_success_timestamp_seconds"),
+		"Unix time of the last background refresh in which the service was up.",
+		[]string{"service"},
+		nil,
+	)
+
+	snapshotAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, exporterSubsystem, "snapshot_age_seconds"),
+		"Age of the metric snapshot served for the service.",
+		[]string{"service"},
+		nil,
+	)
+)
+
+// snapshot is the immutable result of one background refresh of a service.
+type snapshot struct {
+	families    []*dto.MetricFamily
+	collectedAt time.Time
+	lastSuccess time.Time
+}
+
+// poller refreshes the metrics of one service in the background and serves
+// the latest snapshot to scrapes.
+type poller struct {
+	service  string
+	interval time.Duration
+	logger   *slog.Logger
+	registry *Registry
+
+	snapshot atomic.Pointer[snapshot]
+}
+
+func (p *poller) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh collects the service under a deadline of one interval, so that a
+// slow refresh never overlaps the next one.
+func (p *poller) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+
+	families, err := p.registry.gatherService(ctx, p.service)
+	now := time.Now()
+
+	next := &snapshot{families: families, collectedAt: now}
+	if prev := p.snapshot.Load(); prev != nil {
+		next.lastSuccess = prev.lastSuccess
+	}
+
+	switch {
+	case err != nil:
+		p.logger.Error("Background refresh failed", "service", p.service, "error", err)
+	case !serviceUp(families):
+		p.logger.Warn("Background refresh reported service down", "service", p.service)
+	default:
+		next.lastSuccess = now
+	}
+
+	p.snapshot.Store(next)
+}
+
+// Gather returns the families of the latest snapshot. The families are
+// shallow copies, so callers may reorder them without touching the snapshot.
+func (p *poller) Gather() ([]*dto.MetricFamily, error) {
+	s := p.snapshot.Load()
+	if s == nil {
+		return nil, nil
+	}
+	return copyFamilies(s.families), nil
+}
+
+// copyFamilies returns shallow copies of families, so that callers may
+// reorder their metrics without touching families, which may be shared.
+func copyFamilies(families []*dto.MetricFamily) []*dto.MetricFamily {
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		result = append(result, &dto.MetricFamily{
+			Name:   mf.Name,
+			Help:   mf.Help,
+			Type:   mf.Type,
+			Unit:   mf.Unit,
+			Metric: append([]*dto.Metric(nil), mf.Metric...),
+		})
+	}
+	return result
+}
+
+// serviceUp reports whether every _up gauge in families is non-zero.
+func serviceUp(families []*dto.MetricFamily) bool {
+	for _, mf := range families {
+		if !strings.HasSuffix(mf.GetName(), "_up") {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			if m.GetGauge().GetValue() == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pollerMetrics exposes the freshness of every service snapshot.
+type pollerMetrics struct {
+	registry *Registry
+}
+
+func (c *pollerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lastSuccessTimestampDesc
+	ch <- snapshotAgeDesc
+}
+
+func (c *pollerMetrics) Collect(ch chan<- prometheus.Metric) {
+	c.registry.mu.RLock()
+	defer c.registry.mu.RUnlock()
+
+	for _, p := range c.registry.pollers {
+		s := p.snapshot.Load()
+		if s == nil {
+			continue
+		}
+
+		if !s.lastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				lastSuccessTimestampDesc,
+				prometheus.GaugeValue,
+				float64(s.lastSuccess.UnixNano())/1e9,
+				p.service,
+			)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			snapshotAgeDesc,
+			prometheus.GaugeValue,
+			time.Since(s.collectedAt).Seconds(),
+			p.service,
+		)
+	}
+}
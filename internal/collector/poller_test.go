@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCollector reports its number of collections and whether it is up.
+type countingCollector struct {
+	collections atomic.Int32
+	up          atomic.Bool
+}
+
+var (
+	countingUpDesc    = prometheus.NewDesc("openstack_counting_up", "up", nil, nil)
+	countingTotalDesc = prometheus.NewDesc("openstack_counting_collections", "collections", nil, nil)
+)
+
+func (c *countingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- countingUpDesc
+	ch <- countingTotalDesc
+}
+
+func (c *countingCollector) Collect(ch chan<- prometheus.Metric) {
+	n := c.collections.Add(1)
+
+	up := float64(0)
+	if c.up.Load() {
+		up = 1
+	}
+	ch <- prometheus.MustNewConstMetric(countingUpDesc, prometheus.GaugeValue, up)
+	ch <- prometheus.MustNewConstMetric(countingTotalDesc, prometheus.GaugeValue, float64(n))
+}
+
+func gaugeValue(t *testing.T, families []*dto.MetricFamily, name string) (float64, bool) {
+	t.Helper()
+	for _, mf := range families {
+		if mf.GetName() == name {
+			require.Len(t, mf.GetMetric(), 1)
+			return mf.GetMetric()[0].GetGauge().GetValue(), true
+		}
+	}
+	return 0, false
+}
+
+func TestRegistry_PollingServesSnapshot(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	reg := newRegistry(0)
+	c := &countingCollector{}
+	c.up.Store(true)
+	reg.Service("test").MustRegister(c)
+
+	reg.StartPolling(time.Hour, nil, logger)
+	defer func() { require.NoError(t, reg.Close()) }()
+
+	require.Eventually(t, func() bool { return c.collections.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		_, ok := gaugeValue(t, families, "openstack_counting_collections")
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Repeated scrapes are served from the snapshot without collecting again.
+	for range 3 {
+		families, err := reg.Gather()
+		require.NoError(t, err)
+
+		v, _ := gaugeValue(t, families, "openstack_counting_collections")
+		assert.Equal(t, float64(1), v)
+
+		_, ok := gaugeValue(t, families, "openstack_exporter_snapshot_age_seconds")
+		assert.True(t, ok)
+		ts, ok := gaugeValue(t, families, "openstack_exporter_last_success_timestamp_seconds")
+		assert.True(t, ok)
+		assert.InDelta(t, float64(time.Now().Unix()), ts, 60)
+	}
+	assert.Equal(t, int32(1), c.collections.Load())
+}
+
+func TestPoller_KeepsLastSuccessWhenDown(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	reg := newRegistry(0)
+	c := &countingCollector{}
+	c.up.Store(true)
+	reg.Service("test").MustRegister(c)
+
+	p := &poller{service: "test", interval: time.Minute, logger: logger, registry: reg}
+	p.refresh(context.Background())
+	first := p.snapshot.Load()
+	require.False(t, first.lastSuccess.IsZero())
+
+	c.up.Store(false)
+	p.refresh(context.Background())
+	second := p.snapshot.Load()
+
+	assert.Equal(t, first.lastSuccess, second.lastSuccess)
+	assert.True(t, second.collectedAt.After(first.collectedAt) || second.collectedAt.Equal(first.collectedAt))
+
+	families, err := p.Gather()
+	require.NoError(t, err)
+	v, _ := gaugeValue(t, families, "openstack_counting_up")
+	assert.Equal(t, float64(0), v)
+}
+
+func TestRegistry_PollingSkipsServicesWithoutInterval(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	reg := newRegistry(0)
+	c := &countingCollector{}
+	reg.Service("test").MustRegister(c)
+
+	reg.StartPolling(time.Hour, map[string]time.Duration{"test": 0}, logger)
+	defer func() { require.NoError(t, reg.Close()) }()
+
+	for range 2 {
+		_, err := reg.Gather()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), c.collections.Load())
+}
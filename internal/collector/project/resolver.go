@@ -2,101 +2,340 @@ package project
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"maps"
+	"math/rand/v2"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
+	"github.com/vexxhost/openstack_database_exporter/internal/util"
 )
 
 const defaultTTL = 5 * time.Minute
 
-// Info holds resolved project name and domain ID.
+var (
+	projectsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("openstack", "exporter", "project_cache_projects"),
+		"Number of projects in the project name cache.",
+		nil,
+		nil,
+	)
+
+	lastRefreshDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("openstack", "exporter", "project_cache_last_refresh_timestamp_seconds"),
+		"Unix time of the last successful refresh of the project name cache.",
+		nil,
+		nil,
+	)
+
+	usersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("openstack", "exporter", "project_cache_users"),
+		"Number of users in the project name cache.",
+		nil,
+		nil,
+	)
+
+	domainsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("openstack", "exporter", "project_cache_domains"),
+		"Number of domains in the project name cache.",
+		nil,
+		nil,
+	)
+
+	refreshDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("openstack", "exporter", "project_cache_refresh_duration_seconds"),
+		"Duration of the last refresh of the project name cache.",
+		nil,
+		nil,
+	)
+
+	refreshFailuresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("openstack", "exporter", "project_cache_refresh_failures_total"),
+		"Total number of failed refreshes of the project name cache.",
+		nil,
+		nil,
+	)
+)
+
+// ConnectFunc opens the keystone database of a resolver.
+type ConnectFunc func(ctx context.Context) (*keystonedb.Queries, error)
+
+// Info holds the resolved name and domain ID of a project or user.
 type Info struct {
 	Name     string
 	DomainID string
 }
 
-// Resolver resolves project IDs to names and domain IDs via keystone DB.
-// It caches the mapping and refreshes it periodically based on a TTL.
+// Resolver resolves the IDs of keystone projects and domains to their names
+// via keystone DB, and optionally lists the users, named after their local,
+// nonlocal (e.g. LDAP) or federated identity. It caches the mappings and
+// refreshes them in the background about every TTL, so that lookups never
+// wait for keystone. When a refresh fails the last loaded mappings keep being
+// served.
 type Resolver struct {
-	logger     *slog.Logger
+	logger  *slog.Logger
+	connect ConnectFunc
+	ttl     time.Duration
+	// withUsers loads the users on every refresh, which scans every user
+	// of every domain.
+	withUsers bool
+	// keystoneDB is set by the refresh goroutine once connected.
 	keystoneDB *keystonedb.Queries
-	ttl        time.Duration
 
-	mu       sync.RWMutex
-	projects map[string]Info
-	lastLoad time.Time
+	mu          sync.RWMutex
+	projects    map[string]Info
+	users       map[string]Info
+	domains     map[string]string
+	lastRefresh time.Time
+	duration    time.Duration
+	failures    float64
+
+	stop context.CancelFunc
+	done chan struct{}
 }
 
-// NewResolver creates a resolver that fetches projects from keystone and
-// caches them for the given TTL. If keystoneDB is nil, the resolver returns
-// project IDs as-is. A zero TTL uses the default (5 minutes).
+// NewResolver creates a resolver that fetches projects, users and domains
+// from keystone and refreshes them every TTL until it is closed. If
+// keystoneDB is nil, the resolver returns IDs as-is. A zero TTL uses the default (5
+// minutes).
 func NewResolver(logger *slog.Logger, keystoneDB *keystonedb.Queries, ttl time.Duration) *Resolver {
+	var connect ConnectFunc
+	if keystoneDB != nil {
+		connect = func(context.Context) (*keystonedb.Queries, error) { return keystoneDB, nil }
+	}
+	r := newResolver(logger, connect, ttl, true)
+	if connect == nil {
+		return r
+	}
+
+	// The first load is waited for, so that the first scrapes already
+	// carry project names.
+	if r.tryConnect(context.Background()) {
+		r.refresh(context.Background())
+	}
+	r.start(r.interval())
+	return r
+}
+
+// NewConnectingResolver is like NewResolver but opens the keystone database
+// with connect, and loads the users only if withUsers is set. The first load
+// runs in the background, so IDs are returned as-is until it is done. If
+// keystone cannot be reached, connect is retried with the backoff of the
+// service collectors, see util.RegisterWhenConnected. If connect is nil, the
+// resolver returns IDs as-is.
+func NewConnectingResolver(logger *slog.Logger, connect ConnectFunc, ttl time.Duration, withUsers bool) *Resolver {
+	r := newResolver(logger, connect, ttl, withUsers)
+	if connect != nil {
+		r.start(0)
+	}
+	return r
+}
+
+func newResolver(logger *slog.Logger, connect ConnectFunc, ttl time.Duration, withUsers bool) *Resolver {
 	if ttl == 0 {
 		ttl = defaultTTL
 	}
+	if connect == nil {
+		logger.Warn("Keystone database not available, tenant labels will use project IDs")
+	}
 
-	r := &Resolver{
-		logger:     logger,
-		keystoneDB: keystoneDB,
-		ttl:        ttl,
-		projects:   make(map[string]Info),
+	return &Resolver{
+		logger:    logger,
+		connect:   connect,
+		ttl:       ttl,
+		withUsers: withUsers,
+		projects:  make(map[string]Info),
+		users:     make(map[string]Info),
+		domains:   make(map[string]string),
 	}
+}
 
-	if keystoneDB == nil {
-		logger.Warn("Keystone database not available, tenant labels will use project IDs")
-		return r
+// start runs the background refresh, first after delay.
+func (r *Resolver) start(delay time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stop = cancel
+	r.done = make(chan struct{})
+	go r.run(ctx, delay)
+}
+
+// run connects to keystone if needed and refreshes the mapping, first after
+// delay, until ctx is done.
+func (r *Resolver) run(ctx context.Context, delay time.Duration) {
+	defer close(r.done)
+
+	backoff := util.NextBackoff(0)
+	next := func() time.Duration {
+		if r.keystoneDB == nil {
+			return backoff
+		}
+		return r.interval()
 	}
 
-	r.refresh()
-	return r
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if r.keystoneDB == nil && !r.tryConnect(ctx) {
+			backoff = util.NextBackoff(backoff)
+			timer.Reset(next())
+			continue
+		}
+		r.refresh(ctx)
+		timer.Reset(next())
+	}
 }
 
-// refresh reloads the project mapping from keystone.
-func (r *Resolver) refresh() {
-	if r.keystoneDB == nil {
-		return
+// tryConnect opens the keystone database, bounded by util.ConnectTimeout,
+// and reports whether it succeeded. A failed attempt counts as a failed
+// refresh.
+func (r *Resolver) tryConnect(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, util.ConnectTimeout)
+	defer cancel()
+
+	keystoneDB, err := r.connect(ctx)
+	if errors.Is(err, context.Canceled) {
+		// The resolver is being closed.
+		return false
+	}
+	if err != nil {
+		r.mu.Lock()
+		r.failures++
+		r.mu.Unlock()
+
+		r.logger.Warn("Failed to connect to keystone database for project name resolution, will retry", "error", err)
+		return false
 	}
 
-	projects, err := r.keystoneDB.GetProjectMetrics(context.Background())
+	r.keystoneDB = keystoneDB
+	return true
+}
+
+// interval returns the time until the next refresh: the TTL plus up to a
+// tenth of it, so that the resolvers of several exporters or targets do not
+// scan keystone in lockstep.
+func (r *Resolver) interval() time.Duration {
+	return r.ttl + rand.N(r.ttl/10+1)
+}
+
+// refresh reloads the mappings from keystone, bounded by the TTL. The users
+// are loaded apart from the projects and domains, so that failing to load them
+// keeps only the cached users.
+func (r *Resolver) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, r.ttl)
+	defer cancel()
+
+	start := time.Now()
+	projects, domains, err := r.loadProjects(ctx)
+	var users map[string]Info
+	var usersErr error
+	if err == nil && r.withUsers {
+		users, usersErr = r.loadUsers(ctx)
+	}
+	duration := time.Since(start)
+	if errors.Is(err, context.Canceled) || errors.Is(usersErr, context.Canceled) {
+		// The resolver is being closed.
+		return
+	}
 	if err != nil {
-		r.logger.Error("Failed to load projects from keystone", "error", err)
+		r.mu.Lock()
+		r.duration = duration
+		r.failures++
+		r.mu.Unlock()
+
+		r.logger.Error("Failed to load projects from keystone, keeping the cached ones", "error", err)
 		return
 	}
 
-	newMap := make(map[string]Info, len(projects))
-	for _, p := range projects {
-		newMap[p.ID] = Info{
+	r.mu.Lock()
+	r.projects = projects
+	r.domains = domains
+	switch {
+	case usersErr != nil:
+		r.failures++
+	case r.withUsers:
+		r.users = users
+	}
+	r.lastRefresh = time.Now()
+	r.duration = duration
+	users = r.users
+	r.mu.Unlock()
+
+	if usersErr != nil {
+		r.logger.Error("Failed to load users from keystone, keeping the cached ones", "error", usersErr)
+	}
+	r.logger.Info("Loaded project mappings from keystone", "count", len(projects), "users", len(users), "domains", len(domains))
+}
+
+// loadProjects reads the projects and domains from keystone.
+func (r *Resolver) loadProjects(ctx context.Context) (projects map[string]Info, domains map[string]string, err error) {
+	projectRows, err := r.keystoneDB.GetProjectMetrics(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	projects = make(map[string]Info, len(projectRows))
+	for _, p := range projectRows {
+		projects[p.ID] = Info{
 			Name:     p.Name,
 			DomainID: p.DomainID,
 		}
 	}
 
-	r.mu.Lock()
-	r.projects = newMap
-	r.lastLoad = time.Now()
-	r.mu.Unlock()
+	domainRows, err := r.keystoneDB.GetDomainMetrics(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	domains = make(map[string]string, len(domainRows))
+	for _, d := range domainRows {
+		domains[d.ID] = d.Name
+	}
 
-	r.logger.Info("Loaded project mappings from keystone", "count", len(newMap))
+	return projects, domains, nil
 }
 
-// ensureFresh triggers a refresh if the cached data is older than the TTL.
-func (r *Resolver) ensureFresh() {
-	r.mu.RLock()
-	stale := time.Since(r.lastLoad) > r.ttl
-	r.mu.RUnlock()
+// loadUsers reads the users from keystone.
+func (r *Resolver) loadUsers(ctx context.Context) (map[string]Info, error) {
+	userRows, err := r.keystoneDB.GetUserNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make(map[string]Info, len(userRows))
+	for _, u := range userRows {
+		// A user without any identity, which keystone does not create,
+		// keeps its ID.
+		name := u.Name
+		if name == "" {
+			name = u.ID
+		}
+		users[u.ID] = Info{
+			Name:     name,
+			DomainID: u.DomainID,
+		}
+	}
+	return users, nil
+}
 
-	if stale {
-		r.refresh()
+// Close stops the background refresh.
+func (r *Resolver) Close() {
+	if r.stop == nil {
+		return
 	}
+	r.stop()
+	<-r.done
 }
 
 // Resolve returns the project name and domain_id for a given project ID.
 // Falls back to the project ID itself if not found.
 func (r *Resolver) Resolve(projectID string) (name, domainID string) {
-	r.ensureFresh()
-
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -106,17 +345,63 @@ func (r *Resolver) Resolve(projectID string) (name, domainID string) {
 	return projectID, ""
 }
 
+// ResolveDomain returns the name of a given domain ID. Falls back to the
+// domain ID itself if not found.
+func (r *Resolver) ResolveDomain(domainID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name, ok := r.domains[domainID]; ok {
+		return name
+	}
+	return domainID
+}
+
 // AllProjects returns a snapshot of all cached project IDs and their info.
 func (r *Resolver) AllProjects() map[string]Info {
-	r.ensureFresh()
-
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	// Return a copy to avoid data races
-	result := make(map[string]Info, len(r.projects))
-	for k, v := range r.projects {
-		result[k] = v
+	return maps.Clone(r.projects)
+}
+
+// AllUsers returns a snapshot of all cached user IDs and their info, which is
+// empty unless the resolver loads the users.
+func (r *Resolver) AllUsers() map[string]Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return maps.Clone(r.users)
+}
+
+func (r *Resolver) Describe(ch chan<- *prometheus.Desc) {
+	ch <- projectsDesc
+	ch <- usersDesc
+	ch <- domainsDesc
+	ch <- lastRefreshDesc
+	ch <- refreshDurationDesc
+	ch <- refreshFailuresDesc
+}
+
+// Collect exports the state of the cache. The refresh metrics are left out
+// without a keystone database, and the number of users unless they are
+// loaded.
+func (r *Resolver) Collect(ch chan<- prometheus.Metric) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(projectsDesc, prometheus.GaugeValue, float64(len(r.projects)))
+	if r.connect == nil {
+		return
+	}
+	if r.withUsers {
+		ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(len(r.users)))
+	}
+	ch <- prometheus.MustNewConstMetric(domainsDesc, prometheus.GaugeValue, float64(len(r.domains)))
+	if !r.lastRefresh.IsZero() {
+		ch <- prometheus.MustNewConstMetric(lastRefreshDesc, prometheus.GaugeValue, float64(r.lastRefresh.UnixNano())/1e9)
 	}
-	return result
+	ch <- prometheus.MustNewConstMetric(refreshDurationDesc, prometheus.GaugeValue, r.duration.Seconds())
+	ch <- prometheus.MustNewConstMetric(refreshFailuresDesc, prometheus.CounterValue, r.failures)
 }
@@ -0,0 +1,206 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	keystonedb "github.com/vexxhost/openstack_database_exporter/internal/db/keystone"
+)
+
+func projectRows(names ...string) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "enabled", "domain_id", "parent_id", "is_domain", "tags"})
+	for _, name := range names {
+		rows.AddRow(name+"-id", name, "", true, "default", "", false, "")
+	}
+	return rows
+}
+
+// expectRefresh expects the queries of one refresh, loading projects named
+// after names, the default domain and the users of the projects.
+func expectRefresh(mock sqlmock.Sqlmock, names ...string) {
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetProjectMetrics)).WillReturnRows(projectRows(names...))
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetDomainMetrics)).WillReturnRows(domainRows())
+
+	users := sqlmock.NewRows([]string{"id", "domain_id", "name"})
+	for _, name := range names {
+		users.AddRow(name+"-user-id", "default", name+"-user")
+	}
+	users.AddRow("nameless-user-id", "default", "")
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetUserNames)).WillReturnRows(users)
+}
+
+func domainRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "description", "enabled"}).AddRow("default", "Default", "", true)
+}
+
+func newMockResolver(t *testing.T, ttl time.Duration) (*Resolver, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	expectRefresh(mock, "admin", "demo")
+
+	r := NewResolver(slog.New(slog.NewTextHandler(io.Discard, nil)), keystonedb.New(db), ttl)
+	t.Cleanup(r.Close)
+	return r, mock
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	r, _ := newMockResolver(t, time.Hour)
+
+	name, domainID := r.Resolve("demo-id")
+	assert.Equal(t, "demo", name)
+	assert.Equal(t, "default", domainID)
+
+	name, domainID = r.Resolve("unknown-id")
+	assert.Equal(t, "unknown-id", name)
+	assert.Empty(t, domainID)
+
+	projects := r.AllProjects()
+	assert.Len(t, projects, 2)
+	delete(projects, "demo-id")
+	assert.Len(t, r.AllProjects(), 2)
+
+	users := r.AllUsers()
+	assert.Len(t, users, 3)
+	assert.Equal(t, Info{Name: "demo-user", DomainID: "default"}, users["demo-user-id"])
+	assert.Equal(t, Info{Name: "nameless-user-id", DomainID: "default"}, users["nameless-user-id"])
+
+	assert.Equal(t, "Default", r.ResolveDomain("default"))
+	assert.Equal(t, "unknown-domain-id", r.ResolveDomain("unknown-domain-id"))
+}
+
+func TestResolver_KeepsProjectsWhenRefreshFails(t *testing.T) {
+	r, mock := newMockResolver(t, time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetProjectMetrics)).WillReturnError(errors.New("keystone is down"))
+	r.refresh(context.Background())
+
+	name, _ := r.Resolve("demo-id")
+	assert.Equal(t, "demo", name)
+	assert.Equal(t, "demo-user", r.AllUsers()["demo-user-id"].Name)
+
+	err := testutil.CollectAndCompare(r, strings.NewReader(`# HELP openstack_exporter_project_cache_projects Number of projects in the project name cache.
+# TYPE openstack_exporter_project_cache_projects gauge
+openstack_exporter_project_cache_projects 2
+# HELP openstack_exporter_project_cache_refresh_failures_total Total number of failed refreshes of the project name cache.
+# TYPE openstack_exporter_project_cache_refresh_failures_total counter
+openstack_exporter_project_cache_refresh_failures_total 1
+`), "openstack_exporter_project_cache_projects", "openstack_exporter_project_cache_refresh_failures_total")
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolver_KeepsUsersWhenUserRefreshFails(t *testing.T) {
+	r, mock := newMockResolver(t, time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetProjectMetrics)).WillReturnRows(projectRows("admin", "demo", "service"))
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetDomainMetrics)).WillReturnRows(domainRows())
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetUserNames)).WillReturnError(errors.New("keystone is down"))
+	r.refresh(context.Background())
+
+	name, _ := r.Resolve("service-id")
+	assert.Equal(t, "service", name)
+	assert.Equal(t, "demo-user", r.AllUsers()["demo-user-id"].Name)
+
+	err := testutil.CollectAndCompare(r, strings.NewReader(`# HELP openstack_exporter_project_cache_projects Number of projects in the project name cache.
+# TYPE openstack_exporter_project_cache_projects gauge
+openstack_exporter_project_cache_projects 3
+# HELP openstack_exporter_project_cache_refresh_failures_total Total number of failed refreshes of the project name cache.
+# TYPE openstack_exporter_project_cache_refresh_failures_total counter
+openstack_exporter_project_cache_refresh_failures_total 1
+# HELP openstack_exporter_project_cache_users Number of users in the project name cache.
+# TYPE openstack_exporter_project_cache_users gauge
+openstack_exporter_project_cache_users 3
+`), "openstack_exporter_project_cache_projects", "openstack_exporter_project_cache_refresh_failures_total", "openstack_exporter_project_cache_users")
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolver_RefreshesInBackground(t *testing.T) {
+	r, mock := newMockResolver(t, 10*time.Millisecond)
+	expectRefresh(mock, "admin", "demo", "service")
+
+	require.Eventually(t, func() bool {
+		name, _ := r.Resolve("service-id")
+		return name == "service"
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestResolver_WithoutKeystone(t *testing.T) {
+	r := NewResolver(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, 0)
+	r.Close()
+
+	name, domainID := r.Resolve("demo-id")
+	assert.Equal(t, "demo-id", name)
+	assert.Empty(t, domainID)
+
+	assert.Empty(t, r.AllUsers())
+	assert.Equal(t, "default", r.ResolveDomain("default"))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(r))
+}
+
+func TestResolver_RetriesConnect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	expectRefresh(mock, "admin", "demo")
+
+	var attempts atomic.Int32
+	connect := func(context.Context) (*keystonedb.Queries, error) {
+		if attempts.Add(1) == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return keystonedb.New(db), nil
+	}
+
+	r := NewConnectingResolver(slog.New(slog.NewTextHandler(io.Discard, nil)), connect, time.Hour, true)
+	t.Cleanup(r.Close)
+
+	name, _ := r.Resolve("demo-id")
+	assert.Equal(t, "demo-id", name)
+
+	require.Eventually(t, func() bool {
+		name, _ := r.Resolve("demo-id")
+		return name == "demo"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, 2, attempts.Load())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolver_WithoutUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetProjectMetrics)).WillReturnRows(projectRows("admin", "demo"))
+	mock.ExpectQuery(regexp.QuoteMeta(keystonedb.GetDomainMetrics)).WillReturnRows(domainRows())
+
+	connect := func(context.Context) (*keystonedb.Queries, error) { return keystonedb.New(db), nil }
+	r := NewConnectingResolver(slog.New(slog.NewTextHandler(io.Discard, nil)), connect, time.Hour, false)
+	t.Cleanup(r.Close)
+
+	// The first load runs in the background.
+	require.Eventually(t, func() bool {
+		name, _ := r.Resolve("demo-id")
+		return name == "demo"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, r.AllUsers())
+	assert.Zero(t, testutil.CollectAndCount(r, "openstack_exporter_project_cache_users"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
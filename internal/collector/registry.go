@@ -0,0 +1,598 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/collector/project"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
+	"github.com/vexxhost/openstack_database_exporter/internal/redact"
+	"github.com/vexxhost/openstack_database_exporter/internal/relabel"
+	"github.com/vexxhost/openstack_database_exporter/internal/util"
+)
+
+const (
+	exporterSubsystem = "exporter"
+
+	// scrapeTimeoutHeader is set by Prometheus on every scrape request.
+	scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+	// collectParam lists the services to collect in a scrape request.
+	collectParam = "collect[]"
+
+	// regionLabel tells apart the metrics of the targets of one exporter.
+	regionLabel = "region"
+)
+
+type serviceCollector struct {
+	service   string
+	collector prometheus.Collector
+}
+
+// Registry holds the collectors of every configured service and gathers them
+// under a per-scrape context, so that scrape deadlines reach every database
+// query instead of letting a slow table hang the scrape.
+type Registry struct {
+	mu         sync.RWMutex
+	validator  *prometheus.Registry
+	collectors []serviceCollector
+	scheduler  *util.Scheduler
+
+	self     *prometheus.Registry
+	timeouts *prometheus.CounterVec
+	metrics  *collectorMetrics
+	queries  *db.Metrics
+
+	pollers     []*poller
+	stopPolling context.CancelFunc
+	polling     sync.WaitGroup
+
+	// inFlight holds the shared collection running for each service.
+	inFlightMu  sync.Mutex
+	inFlight    map[string]*sharedCollection
+	collections *prometheus.CounterVec
+
+	pools    *db.Pools
+	resolver *project.Resolver
+	logger   *slog.Logger
+	status   collectionStatus
+
+	// relabel, if set, rewrites the gathered series before the region
+	// label is added.
+	relabel *relabel.Relabeler
+	// region, if set, is added as the region label of every metric.
+	region string
+	// snapshot runs the queries of each collection of a service in one
+	// read-only transaction per database.
+	snapshot bool
+}
+
+// newRegistry creates an empty registry that runs at most parallelism
+// collectors at once (unlimited if zero).
+func newRegistry(parallelism int) *Registry {
+	r := &Registry{
+		validator: prometheus.NewRegistry(),
+		scheduler: util.NewScheduler(parallelism),
+		pools:     db.NewPools(Namespace),
+		self:      prometheus.NewRegistry(),
+		inFlight:  make(map[string]*sharedCollection),
+		timeouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: exporterSubsystem,
+				Name:      "collector_timeouts_total",
+				Help:      "Total number of collections cut short by the scrape deadline.",
+			},
+			[]string{"service"},
+		),
+		collections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: exporterSubsystem,
+				Name:      "collections_total",
+				Help:      "Total number of collections of a service by scrapes, either fresh or shared with a concurrent scrape.",
+			},
+			[]string{"service", "source"},
+		),
+		metrics: newCollectorMetrics(),
+		queries: db.NewMetrics(Namespace),
+		logger:  slog.New(slog.DiscardHandler),
+	}
+	r.self.MustRegister(r.timeouts, r.collections, r.metrics.duration, r.metrics.success, r.queries, r.pools, &pollerMetrics{registry: r})
+
+	return r
+}
+
+// Register registers a collector that does not belong to any service.
+func (r *Registry) Register(c prometheus.Collector) error {
+	return r.register("", c)
+}
+
+// MustRegister works like Register but panics on error.
+func (r *Registry) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister removes a previously registered collector.
+func (r *Registry) Unregister(c prometheus.Collector) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The validator cannot unregister unchecked collectors, so the list of
+	// collectors is authoritative.
+	r.validator.Unregister(c)
+	for i, sc := range r.collectors {
+		if sc.collector == c {
+			r.collectors = append(r.collectors[:i], r.collectors[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Service returns a Registerer that attributes collectors to the named
+// service, which is used to label exporter metrics such as timeouts.
+func (r *Registry) Service(name string) prometheus.Registerer {
+	return &serviceRegisterer{registry: r, service: name}
+}
+
+func (r *Registry) register(service string, c prometheus.Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.validator.Register(c); err != nil {
+		return err
+	}
+	r.collectors = append(r.collectors, serviceCollector{service: service, collector: c})
+	return nil
+}
+
+// Gather implements prometheus.Gatherer without a deadline.
+func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
+	return r.GatherContext(context.Background())
+}
+
+// GatherContext collects all registered collectors, passing ctx to those that
+// implement util.ContextCollector. Services that are polled in the background are
+// served from their latest snapshot instead.
+func (r *Registry) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	return r.GatherServices(ctx, nil)
+}
+
+// GatherServices is like GatherContext but only collects the named services,
+// or every service if services is empty. Collectors that do not belong to any
+// service and the exporter metrics are always gathered.
+func (r *Registry) GatherServices(ctx context.Context, services []string) ([]*dto.MetricFamily, error) {
+	selected := func(service string) bool {
+		return len(services) == 0 || service == "" || slices.Contains(services, service)
+	}
+
+	r.mu.RLock()
+	polled := make(map[string]bool, len(r.pollers))
+	gatherers := make(prometheus.Gatherers, 0, len(r.pollers)+2)
+	for _, p := range r.pollers {
+		polled[p.service] = true
+		if selected(p.service) {
+			gatherers = append(gatherers, p)
+		}
+	}
+	var live []string
+	for _, sc := range r.collectors {
+		if sc.service != "" && !polled[sc.service] && selected(sc.service) && !slices.Contains(live, sc.service) {
+			live = append(live, sc.service)
+		}
+	}
+	r.mu.RUnlock()
+
+	// Collectors of no service are cheap and collected by every scrape;
+	// those of each service are shared between concurrent scrapes.
+	global, err := r.gather(ctx, func(service string) bool { return service == "" })
+	if err != nil {
+		return nil, err
+	}
+	shared := make(prometheus.Gatherers, len(live))
+	var wg sync.WaitGroup
+	for i, service := range live {
+		wg.Go(func() {
+			families, err := r.gatherShared(ctx, service)
+			shared[i] = prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+				return families, err
+			})
+		})
+	}
+	wg.Wait()
+
+	gatherers = append(append(prometheus.Gatherers{global}, shared...), gatherers...)
+	families, err := gatherers.Gather()
+	if r.relabel != nil {
+		families = r.relabel.Apply(families)
+	}
+
+	// Exporter metrics are gathered last, once the relabel rules ran, so
+	// that timeouts counted and series dropped during this scrape are
+	// already reflected. They are not relabeled.
+	relabeled := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return families, err
+	})
+	families, err = prometheus.Gatherers{relabeled, r.self}.Gather()
+	if r.region != "" {
+		families = withLabel(families, regionLabel, r.region)
+	}
+	return families, err
+}
+
+// withLabel returns families with a constant label added to every metric.
+// The metrics of families are left untouched, as they may belong to a
+// snapshot served again by the next scrape.
+func withLabel(families []*dto.MetricFamily, name, value string) []*dto.MetricFamily {
+	label := &dto.LabelPair{Name: &name, Value: &value}
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		metrics := make([]*dto.Metric, 0, len(mf.Metric))
+		for _, m := range mf.Metric {
+			labels := make([]*dto.LabelPair, 0, len(m.Label)+1)
+			labels = append(labels, m.Label...)
+			i, _ := slices.BinarySearchFunc(labels, name, func(l *dto.LabelPair, name string) int {
+				return strings.Compare(l.GetName(), name)
+			})
+			labels = slices.Insert(labels, i, label)
+
+			metrics = append(metrics, &dto.Metric{
+				Label:       labels,
+				Gauge:       m.Gauge,
+				Counter:     m.Counter,
+				Summary:     m.Summary,
+				Untyped:     m.Untyped,
+				Histogram:   m.Histogram,
+				TimestampMs: m.TimestampMs,
+			})
+		}
+		result = append(result, &dto.MetricFamily{
+			Name:   mf.Name,
+			Help:   mf.Help,
+			Type:   mf.Type,
+			Unit:   mf.Unit,
+			Metric: metrics,
+		})
+	}
+	return result
+}
+
+// gatherShared collects a single service like gatherService, unless a
+// collection of the service is already in flight, whose result is then shared
+// instead of running every query again. A shared collection runs until every
+// scrape waiting for it is done, see sharedContext; a scrape that is done
+// before then returns its context's error.
+func (r *Registry) gatherShared(ctx context.Context, service string) ([]*dto.MetricFamily, error) {
+	r.inFlightMu.Lock()
+	c, ok := r.inFlight[service]
+	fresh := !ok || !c.ctx.join(ctx)
+	if fresh {
+		c = &sharedCollection{ctx: newSharedContext(ctx), done: make(chan struct{})}
+		r.inFlight[service] = c
+		go r.runShared(c, service)
+	}
+	r.inFlightMu.Unlock()
+
+	source := "shared"
+	if fresh {
+		source = "fresh"
+	}
+	r.collections.WithLabelValues(service, source).Inc()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		if !c.ctx.leave(ctx.Err()) {
+			return nil, ctx.Err()
+		}
+		// The collection is cut short by the end of its last scrape,
+		// whose partial result is still served.
+		<-c.done
+	}
+	return copyFamilies(c.families), c.err
+}
+
+// runShared runs the shared collection c of service.
+func (r *Registry) runShared(c *sharedCollection, service string) {
+	c.families, c.err = r.gatherService(c.ctx, service)
+
+	r.inFlightMu.Lock()
+	if r.inFlight[service] == c {
+		delete(r.inFlight, service)
+	}
+	r.inFlightMu.Unlock()
+
+	c.ctx.cancel()
+	close(c.done)
+}
+
+// gatherService collects the collectors of a single service. With snapshots
+// enabled they read a consistent snapshot of the service's databases, one
+// collector at a time.
+func (r *Registry) gatherService(ctx context.Context, service string) ([]*dto.MetricFamily, error) {
+	if r.snapshot {
+		snapshot, err := r.pools.Snapshot(ctx, service)
+		if err != nil {
+			r.logger.Warn("Failed to begin snapshot, collecting without it", "service", service, "error", err)
+		} else {
+			defer func() { _ = snapshot.Close() }()
+			ctx = util.Serial(db.WithSnapshot(ctx, snapshot))
+		}
+	}
+
+	scrape, err := r.gather(ctx, func(s string) bool { return s == service })
+	if err != nil {
+		return nil, err
+	}
+	return scrape.Gather()
+}
+
+// gather returns a registry holding the collectors of the services matched
+// by include, each bound to ctx.
+func (r *Registry) gather(ctx context.Context, include func(service string) bool) (*prometheus.Registry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scrape := prometheus.NewRegistry()
+	for _, sc := range r.collectors {
+		if !include(sc.service) {
+			continue
+		}
+		if err := scrape.Register(&boundCollector{ctx: ctx, serviceCollector: sc, scheduler: r.scheduler, timeouts: r.timeouts, metrics: r.metrics, status: &r.status, logger: r.logger}); err != nil {
+			return nil, err
+		}
+	}
+	return scrape, nil
+}
+
+// StartPolling refreshes every service in the background at interval, or at
+// the service's entry in intervals if present, and serves scrapes from the
+// resulting snapshots. Services with a zero interval keep being collected
+// on every scrape.
+func (r *Registry) StartPolling(interval time.Duration, intervals map[string]time.Duration, logger *slog.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopPolling != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stopPolling = cancel
+
+	seen := make(map[string]bool)
+	for _, sc := range r.collectors {
+		if sc.service == "" || seen[sc.service] {
+			continue
+		}
+		seen[sc.service] = true
+
+		d := interval
+		if v, ok := intervals[sc.service]; ok {
+			d = v
+		}
+		if d <= 0 {
+			continue
+		}
+
+		p := &poller{service: sc.service, interval: d, logger: logger, registry: r}
+		r.pollers = append(r.pollers, p)
+
+		r.polling.Add(1)
+		go p.run(ctx, &r.polling)
+
+		logger.Info("Polling service in the background", "service", sc.service, "interval", d)
+	}
+}
+
+// Close stops background polling and the refresh of the project resolver,
+// waits for running refreshes to end and closes the connection pools opened
+// for the registered services.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	stop := r.stopPolling
+	r.mu.Unlock()
+
+	if stop != nil {
+		stop()
+		r.polling.Wait()
+	}
+	if r.resolver != nil {
+		r.resolver.Close()
+	}
+	return r.pools.Close()
+}
+
+// Handler returns an HTTP handler that serves the registry. Each scrape runs
+// under the request context, limited by the Prometheus scrape timeout header
+// minus offset, or by timeout when that is shorter. A zero timeout leaves the
+// header as the only limit.
+//
+// Like in postgres_exporter, a scrape can be limited to some services by
+// listing them in collect[] query parameters, e.g. ?collect[]=nova.
+func (r *Registry) Handler(timeout, offset time.Duration, opts promhttp.HandlerOpts) http.Handler {
+	return scrapeHandler(timeout, offset, opts, r.GatherServices)
+}
+
+// scrapeHandler returns an HTTP handler that serves the metrics returned by
+// gather under the deadline of the scrape, see Registry.Handler.
+func scrapeHandler(timeout, offset time.Duration, opts promhttp.HandlerOpts, gather func(context.Context, []string) ([]*dto.MetricFamily, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		services := req.URL.Query()[collectParam]
+		for _, service := range services {
+			if _, ok := Collectors[service]; !ok {
+				http.Error(w, fmt.Sprintf("unknown service %q in %s", service, collectParam), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx := req.Context()
+		if d := scrapeTimeout(req, timeout, offset); d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+
+		gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return gather(ctx, services)
+		})
+		promhttp.HandlerFor(gatherer, opts).ServeHTTP(w, req)
+	})
+}
+
+// scrapeTimeout returns the effective deadline for a scrape request, or zero
+// if the scrape is unbounded.
+func scrapeTimeout(req *http.Request, timeout, offset time.Duration) time.Duration {
+	if v := req.Header.Get(scrapeTimeoutHeader); v != "" {
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err == nil && seconds > 0 {
+			d := time.Duration(seconds*float64(time.Second)) - offset
+			if d <= 0 {
+				d = time.Duration(seconds * float64(time.Second))
+			}
+			if timeout <= 0 || d < timeout {
+				return d
+			}
+		}
+	}
+	return timeout
+}
+
+type serviceRegisterer struct {
+	registry *Registry
+	service  string
+}
+
+func (s *serviceRegisterer) Register(c prometheus.Collector) error {
+	return s.registry.register(s.service, c)
+}
+
+func (s *serviceRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := s.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (s *serviceRegisterer) Unregister(c prometheus.Collector) bool {
+	return s.registry.Unregister(c)
+}
+
+// collectorMetrics describe the last collection of every named collector.
+type collectorMetrics struct {
+	duration *prometheus.GaugeVec
+	success  *prometheus.GaugeVec
+}
+
+func newCollectorMetrics() *collectorMetrics {
+	return &collectorMetrics{
+		duration: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: exporterSubsystem,
+				Name:      "collector_duration_seconds",
+				Help:      "Duration of the last collection of a collector.",
+			},
+			[]string{"service", "collector"},
+		),
+		success: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: exporterSubsystem,
+				Name:      "collector_success",
+				Help:      "Whether all queries of the last collection of a collector succeeded within the scrape deadline.",
+			},
+			[]string{"service", "collector"},
+		),
+	}
+}
+
+// boundCollector binds a registered collector to the context of one scrape.
+type boundCollector struct {
+	serviceCollector
+	ctx       context.Context
+	scheduler *util.Scheduler
+	timeouts  *prometheus.CounterVec
+	metrics   *collectorMetrics
+	status    *collectionStatus
+	logger    *slog.Logger
+}
+
+func (b *boundCollector) Describe(ch chan<- *prometheus.Desc) {
+	b.collector.Describe(ch)
+}
+
+func (b *boundCollector) Collect(ch chan<- prometheus.Metric) {
+	b.scheduler.Run(b.ctx, b.service, func(ctx context.Context) {
+		named, ok := b.collector.(*util.NamedCollector)
+		if !ok || b.service == "" {
+			b.collect(ctx, b.collector, ch)
+			b.countTimeout(ctx.Err())
+			return
+		}
+
+		ctx, errs := db.WithQueryErrors(ctx)
+		start := time.Now()
+		ok = b.collect(ctx, named, ch)
+		// The deadline is checked once, so that a collector finishing just
+		// before it is neither counted as timed out nor as failed.
+		ctxErr := ctx.Err()
+
+		success := float64(0)
+		switch {
+		case !ok:
+			b.status.failure(b.service, named.Name, errors.New("collector panicked"))
+		case errs.Count() > 0:
+			b.status.failure(b.service, named.Name, errs.Last())
+		case ctxErr != nil:
+			b.status.failure(b.service, named.Name, ctxErr)
+		default:
+			b.status.success(b.service)
+			success = 1
+		}
+		b.metrics.duration.WithLabelValues(b.service, named.Name).Set(time.Since(start).Seconds())
+		b.metrics.success.WithLabelValues(b.service, named.Name).Set(success)
+		b.countTimeout(ctxErr)
+	})
+}
+
+// countTimeout counts a collection whose context ended with err as timed out
+// if its deadline passed, including when it could not even start.
+func (b *boundCollector) countTimeout(err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		b.timeouts.WithLabelValues(b.service).Inc()
+	}
+}
+
+// collect collects c and reports whether it returned without panicking. A
+// panic is logged with its value redacted instead of crashing the exporter.
+func (b *boundCollector) collect(ctx context.Context, c prometheus.Collector, ch chan<- prometheus.Metric) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("Collector panicked", "service", b.service, "error", redact.Panic(r), "stack", string(debug.Stack()))
+			ok = false
+		}
+	}()
+
+	util.CollectContext(ctx, c, ch)
+	return true
+}
@@ -0,0 +1,420 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/relabel"
+	"github.com/vexxhost/openstack_database_exporter/internal/util"
+)
+
+var testDesc = prometheus.NewDesc("openstack_test_up", "up", nil, nil)
+
+// deadlineCollector reports up=0 once its context is done, like a collector
+// whose query was cancelled.
+type deadlineCollector struct {
+	deadline time.Time
+	wait     bool
+}
+
+func (c *deadlineCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- testDesc
+}
+
+func (c *deadlineCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *deadlineCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.deadline, _ = ctx.Deadline()
+	if c.wait {
+		<-ctx.Done()
+	}
+
+	up := float64(1)
+	if ctx.Err() != nil {
+		up = 0
+	}
+	ch <- prometheus.MustNewConstMetric(testDesc, prometheus.GaugeValue, up)
+}
+
+func TestRegistry_GatherContextPassesDeadline(t *testing.T) {
+	reg := newRegistry(0)
+	c := &deadlineCollector{}
+	reg.Service("test").MustRegister(c)
+
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	_, err := reg.GatherContext(ctx)
+	require.NoError(t, err)
+	assert.True(t, c.deadline.Equal(deadline))
+}
+
+func TestRegistry_TimeoutReportsDown(t *testing.T) {
+	reg := newRegistry(0)
+	reg.Service("test").MustRegister(&deadlineCollector{wait: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return reg.GatherContext(ctx)
+	})
+	err := testutil.GatherAndCompare(gatherer, strings.NewReader(`# HELP openstack_exporter_collections_total Total number of collections of a service by scrapes, either fresh or shared with a concurrent scrape.
+# TYPE openstack_exporter_collections_total counter
+openstack_exporter_collections_total{service="test",source="fresh"} 1
+# HELP openstack_exporter_collector_timeouts_total Total number of collections cut short by the scrape deadline.
+# TYPE openstack_exporter_collector_timeouts_total counter
+openstack_exporter_collector_timeouts_total{service="test"} 1
+# HELP openstack_test_up up
+# TYPE openstack_test_up gauge
+openstack_test_up 0
+`))
+	require.NoError(t, err)
+}
+
+func TestRegistry_DuplicateRegistration(t *testing.T) {
+	reg := newRegistry(0)
+	c := &deadlineCollector{}
+	require.NoError(t, reg.Service("test").Register(c))
+	require.Error(t, reg.Service("other").Register(c))
+
+	assert.True(t, reg.Unregister(c))
+	require.NoError(t, reg.Register(c))
+}
+
+func TestRegistry_HandlerAppliesScrapeTimeout(t *testing.T) {
+	reg := newRegistry(0)
+	c := &deadlineCollector{}
+	reg.MustRegister(c)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set(scrapeTimeoutHeader, "10")
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	reg.Handler(0, time.Second, promhttp.HandlerOpts{}).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "openstack_test_up 1")
+	assert.WithinDuration(t, start.Add(9*time.Second), c.deadline, time.Second)
+}
+
+func TestScrapeTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		timeout  time.Duration
+		offset   time.Duration
+		expected time.Duration
+	}{
+		{name: "no header and no flag", expected: 0},
+		{name: "flag only", timeout: 5 * time.Second, expected: 5 * time.Second},
+		{name: "header minus offset", header: "10", offset: 500 * time.Millisecond, expected: 9500 * time.Millisecond},
+		{name: "fractional header", header: "1.5", expected: 1500 * time.Millisecond},
+		{name: "flag shorter than header", header: "10", timeout: 3 * time.Second, expected: 3 * time.Second},
+		{name: "header shorter than flag", header: "2", timeout: 30 * time.Second, expected: 2 * time.Second},
+		{name: "offset larger than header", header: "0.1", offset: time.Second, expected: 100 * time.Millisecond},
+		{name: "invalid header falls back to flag", header: "soon", timeout: time.Second, expected: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set(scrapeTimeoutHeader, tt.header)
+			}
+			assert.Equal(t, tt.expected, scrapeTimeout(req, tt.timeout, tt.offset))
+		})
+	}
+}
+
+func TestRegistry_ReconnectSwapsCollectors(t *testing.T) {
+	reg := newRegistry(0)
+
+	var ready atomic.Bool
+	connect := func(context.Context) ([]prometheus.Collector, error) {
+		if !ready.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return []prometheus.Collector{&deadlineCollector{}}, nil
+	}
+	util.RegisterWhenConnected(reg.Service("test"), "openstack", "test", "test", connect, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Len(t, reg.collectors, 2)
+
+	ready.Store(true)
+	_, err := reg.Gather()
+	require.NoError(t, err)
+
+	// The reconnect collector was replaced by the real one.
+	require.Len(t, reg.collectors, 2)
+	_, ok := reg.collectors[1].collector.(*deadlineCollector)
+	assert.True(t, ok)
+	assert.Equal(t, "test", reg.collectors[1].service)
+}
+
+func TestRegistry_ReconnectReportsFailure(t *testing.T) {
+	reg := newRegistry(0)
+
+	connect := func(context.Context) ([]prometheus.Collector, error) {
+		return nil, errors.New("connection refused")
+	}
+	util.RegisterWhenConnected(reg.Service("test"), "openstack", "test", "test", connect, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`# HELP openstack_exporter_collector_success Whether all queries of the last collection of a collector succeeded within the scrape deadline.
+# TYPE openstack_exporter_collector_success gauge
+openstack_exporter_collector_success{collector="connect",service="test"} 0
+`), "openstack_exporter_collector_success")
+	require.NoError(t, err)
+}
+
+// panicCollector panics with a message holding a database URL.
+type panicCollector struct{}
+
+func (panicCollector) Describe(ch chan<- *prometheus.Desc) { ch <- testDesc }
+func (panicCollector) Collect(chan<- prometheus.Metric) {
+	panic("cannot open mysql://nova:s3cret@db/nova")
+}
+
+func TestRegistry_RecoversPanics(t *testing.T) {
+	var logs strings.Builder
+	reg := newRegistry(0)
+	reg.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	reg.Service("test").MustRegister(util.Named("volumes", panicCollector{}))
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`# HELP openstack_exporter_collector_success Whether all queries of the last collection of a collector succeeded within the scrape deadline.
+# TYPE openstack_exporter_collector_success gauge
+openstack_exporter_collector_success{collector="volumes",service="test"} 0
+`), "openstack_exporter_collector_success")
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "mysql://nova:xxxxx@db/nova")
+	assert.NotContains(t, logs.String(), "s3cret")
+}
+
+func TestRegistry_CollectorMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		wait     bool
+		expected string
+	}{
+		{name: "success", expected: "1"},
+		{name: "deadline exceeded", wait: true, expected: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := newRegistry(0)
+			reg.Service("test").MustRegister(util.Named("volumes", &deadlineCollector{wait: tt.wait}))
+			reg.Service("test").MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "openstack_test_unnamed", Help: "unnamed"}))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+				return reg.GatherContext(ctx)
+			})
+			err := testutil.GatherAndCompare(gatherer, strings.NewReader(`# HELP openstack_exporter_collector_success Whether all queries of the last collection of a collector succeeded within the scrape deadline.
+# TYPE openstack_exporter_collector_success gauge
+openstack_exporter_collector_success{collector="volumes",service="test"} `+tt.expected+`
+`), "openstack_exporter_collector_success")
+			require.NoError(t, err)
+			assert.Equal(t, 1, testutil.CollectAndCount(reg.metrics.duration))
+		})
+	}
+}
+
+func TestRegistry_RegionLabel(t *testing.T) {
+	reg := newRegistry(0)
+	reg.region = "RegionOne"
+	reg.Service("test").MustRegister(util.Named("volumes", &deadlineCollector{}))
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`# HELP openstack_exporter_collector_success Whether all queries of the last collection of a collector succeeded within the scrape deadline.
+# TYPE openstack_exporter_collector_success gauge
+openstack_exporter_collector_success{collector="volumes",region="RegionOne",service="test"} 1
+# HELP openstack_test_up up
+# TYPE openstack_test_up gauge
+openstack_test_up{region="RegionOne"} 1
+`), "openstack_exporter_collector_success", "openstack_test_up")
+	require.NoError(t, err)
+}
+
+func TestRegistry_Relabel(t *testing.T) {
+	metric, err := relabel.Compile("openstack_test_servers")
+	require.NoError(t, err)
+
+	servers := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "openstack_test_servers", Help: "servers"}, []string{"id", "project_id"})
+	servers.WithLabelValues("a", "admin").Set(1)
+	servers.WithLabelValues("b", "admin").Set(1)
+	servers.WithLabelValues("c", "demo").Set(1)
+
+	reg := newRegistry(0)
+	reg.region = "RegionOne"
+	reg.relabel = relabel.New(Namespace, []relabel.Rule{{Metric: metric, DropLabels: []string{"id"}}})
+	reg.self.MustRegister(reg.relabel)
+	reg.Service("test").MustRegister(servers)
+
+	// The region label is added after the rules ran, and the series they
+	// dropped are counted by the same scrape.
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`# HELP openstack_exporter_relabel_dropped_series_total Total number of series dropped by the relabel rules, because they were filtered out, merged after dropping labels or summed by a series cap.
+# TYPE openstack_exporter_relabel_dropped_series_total counter
+openstack_exporter_relabel_dropped_series_total{metric="openstack_test_servers",reason="labels",region="RegionOne"} 1
+# HELP openstack_test_servers servers
+# TYPE openstack_test_servers gauge
+openstack_test_servers{project_id="admin",region="RegionOne"} 2
+openstack_test_servers{project_id="demo",region="RegionOne"} 1
+`), "openstack_exporter_relabel_dropped_series_total", "openstack_test_servers")
+	require.NoError(t, err)
+}
+
+func TestRegistry_HandlerFiltersServices(t *testing.T) {
+	reg := newRegistry(0)
+	reg.Service("nova").MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "openstack_nova_test", Help: "nova"}))
+	reg.Service("keystone").MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "openstack_identity_test", Help: "keystone"}))
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "openstack_test_global", Help: "global"}))
+
+	tests := []struct {
+		name       string
+		url        string
+		code       int
+		present    []string
+		notPresent []string
+	}{
+		{
+			name:    "all services",
+			url:     "/metrics",
+			code:    http.StatusOK,
+			present: []string{"openstack_nova_test", "openstack_identity_test", "openstack_test_global"},
+		},
+		{
+			name:       "one service",
+			url:        "/metrics?collect[]=nova",
+			code:       http.StatusOK,
+			present:    []string{"openstack_nova_test", "openstack_test_global"},
+			notPresent: []string{"openstack_identity_test"},
+		},
+		{
+			name:    "several services",
+			url:     "/metrics?collect[]=nova&collect[]=keystone",
+			code:    http.StatusOK,
+			present: []string{"openstack_nova_test", "openstack_identity_test"},
+		},
+		{
+			name: "unknown service",
+			url:  "/metrics?collect[]=swift",
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			reg.Handler(0, 0, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			require.Equal(t, tt.code, rec.Code)
+			for _, name := range tt.present {
+				assert.Contains(t, rec.Body.String(), name)
+			}
+			for _, name := range tt.notPresent {
+				assert.NotContains(t, rec.Body.String(), name)
+			}
+		})
+	}
+}
+
+// gatedCollector counts its collections, each of which lasts until release
+// is closed.
+type gatedCollector struct {
+	started chan struct{}
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (c *gatedCollector) Describe(ch chan<- *prometheus.Desc) { ch <- testDesc }
+func (c *gatedCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.calls.Add(1) == 1 {
+		close(c.started)
+	}
+	<-c.release
+	ch <- prometheus.MustNewConstMetric(testDesc, prometheus.GaugeValue, 1)
+}
+
+func TestRegistry_SharesConcurrentCollections(t *testing.T) {
+	reg := newRegistry(0)
+	c := &gatedCollector{started: make(chan struct{}), release: make(chan struct{})}
+	reg.Service("test").MustRegister(c)
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			families, err := reg.Gather()
+			assert.NoError(t, err)
+			assert.Contains(t, familyNames(families), "openstack_test_up")
+		})
+		<-c.started
+	}
+	// Give the second scrape time to join the collection in flight.
+	time.Sleep(50 * time.Millisecond)
+	close(c.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), c.calls.Load())
+	err := testutil.CollectAndCompare(reg.collections, strings.NewReader(`# HELP openstack_exporter_collections_total Total number of collections of a service by scrapes, either fresh or shared with a concurrent scrape.
+# TYPE openstack_exporter_collections_total counter
+openstack_exporter_collections_total{service="test",source="fresh"} 1
+openstack_exporter_collections_total{service="test",source="shared"} 1
+`))
+	require.NoError(t, err)
+}
+
+func TestRegistry_SharedCollectionOutlivesFirstScrape(t *testing.T) {
+	reg := newRegistry(0)
+	c := &gatedCollector{started: make(chan struct{}), release: make(chan struct{})}
+	reg.Service("test").MustRegister(c)
+
+	// The first scrape gives up before the collection ends.
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	firstErr := make(chan error)
+	go func() {
+		_, err := reg.GatherContext(short)
+		firstErr <- err
+	}()
+	<-c.started
+
+	second := make(chan []*dto.MetricFamily)
+	go func() {
+		families, err := reg.GatherContext(context.Background())
+		assert.NoError(t, err)
+		second <- families
+	}()
+
+	require.ErrorIs(t, <-firstErr, context.DeadlineExceeded)
+	close(c.release)
+	assert.Contains(t, familyNames(<-second), "openstack_test_up")
+	assert.Equal(t, int32(1), c.calls.Load())
+}
+
+func familyNames(families []*dto.MetricFamily) []string {
+	names := make([]string, 0, len(families))
+	for _, mf := range families {
+		names = append(names, mf.GetName())
+	}
+	return names
+}
@@ -0,0 +1,373 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Reloader serves the metrics of a Registry that is rebuilt from a freshly
+// loaded Config on every Reload, so that the configuration can change without
+// restarting the HTTP server.
+type Reloader struct {
+	load   func() (Config, error)
+	opts   promhttp.HandlerOpts
+	logger *slog.Logger
+
+	lastReloadSuccessful prometheus.Gauge
+	lastReloadSuccess    prometheus.Gauge
+
+	// reloadMu serializes reloads, so that the last configuration loaded
+	// wins. mu serializes swapping and closing the current registries,
+	// which are built before taking it.
+	reloadMu sync.Mutex
+	mu       sync.Mutex
+	current  atomic.Pointer[loadedRegistry]
+
+	// stopMu guards stopping against requests starting while Shutdown
+	// waits for those in flight.
+	stopMu   sync.RWMutex
+	stopping bool
+	requests sync.WaitGroup
+	// aborted is cancelled when Shutdown gives up waiting for requests.
+	aborted       context.Context
+	abortRequests context.CancelFunc
+}
+
+type loadedRegistry struct {
+	registry *Registry
+	targets  map[string]*Registry
+	handler  http.Handler
+	probes   map[string]http.Handler
+	logger   *slog.Logger
+
+	// mu guards refs and retired.
+	mu      sync.Mutex
+	refs    int
+	retired bool
+}
+
+// acquire takes a reference on l for a request, unless l has been retired.
+func (l *loadedRegistry) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.retired {
+		return false
+	}
+	l.refs++
+	return true
+}
+
+// release drops a reference taken by acquire, closing l if it has been
+// retired and this was the last request using it.
+func (l *loadedRegistry) release() {
+	l.mu.Lock()
+	l.refs--
+	last := l.retired && l.refs == 0
+	l.mu.Unlock()
+
+	if last {
+		l.closeRetired()
+	}
+}
+
+// retire marks l as replaced and closes it once the requests using it ended,
+// so that their collections are not cut short by closed connection pools.
+func (l *loadedRegistry) retire() {
+	l.mu.Lock()
+	l.retired = true
+	unused := l.refs == 0
+	l.mu.Unlock()
+
+	if unused {
+		l.closeRetired()
+	}
+}
+
+func (l *loadedRegistry) closeRetired() {
+	if err := l.close(); err != nil {
+		l.logger.Warn("Failed to close previous registry", "error", err)
+	}
+}
+
+// close closes the registries of every target.
+func (l *loadedRegistry) close() error {
+	errs := []error{l.registry.Close()}
+	for _, reg := range l.targets {
+		errs = append(errs, reg.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// NewReloader loads the initial configuration and builds its registry.
+func NewReloader(load func() (Config, error), opts promhttp.HandlerOpts, logger *slog.Logger) (*Reloader, error) {
+	r := &Reloader{
+		load:   load,
+		opts:   opts,
+		logger: logger,
+		lastReloadSuccessful: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: exporterSubsystem,
+			Name:      "config_last_reload_successful",
+			Help:      "Whether the last configuration reload attempt was successful.",
+		}),
+		lastReloadSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: exporterSubsystem,
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "Timestamp of the last successful configuration reload.",
+		}),
+	}
+	r.aborted, r.abortRequests = context.WithCancel(context.Background())
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the configuration and swaps in a registry built from it. The
+// previous registry is closed once the new one serves scrapes and the
+// requests still using it ended. If the configuration cannot be loaded, the
+// previous registry is kept.
+func (r *Reloader) Reload() error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	if r.isStopping() {
+		return errShuttingDown
+	}
+
+	cfg, err := r.load()
+	if err != nil {
+		r.lastReloadSuccessful.Set(0)
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// The registries connect to their databases while being built, which
+	// must not hold back scrapes or a shutdown.
+	loaded := r.build(cfg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isStopping() {
+		if err := loaded.close(); err != nil {
+			r.logger.Warn("Failed to close registry", "error", err)
+		}
+		return errShuttingDown
+	}
+
+	prev := r.current.Swap(loaded)
+	r.lastReloadSuccessful.Set(1)
+	r.lastReloadSuccess.SetToCurrentTime()
+
+	if prev != nil {
+		prev.retire()
+		r.logger.Info("Reloaded configuration")
+	}
+	return nil
+}
+
+// errShuttingDown is returned by reloads once Shutdown has been called.
+var errShuttingDown = errors.New("the exporter is shutting down")
+
+func (r *Reloader) isStopping() bool {
+	r.stopMu.RLock()
+	defer r.stopMu.RUnlock()
+
+	return r.stopping
+}
+
+// build builds the registries of cfg.
+func (r *Reloader) build(cfg Config) *loadedRegistry {
+	reg := NewRegistry(cfg, r.logger)
+	reg.MustRegister(r.lastReloadSuccessful, r.lastReloadSuccess)
+
+	loaded := &loadedRegistry{
+		registry: reg,
+		targets:  make(map[string]*Registry, len(cfg.Targets)),
+		probes:   make(map[string]http.Handler, len(cfg.Targets)),
+		logger:   r.logger,
+	}
+	registries := []*Registry{reg}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Targets)) {
+		target := cfg.Targets[name]
+		targetReg := NewRegistry(target, r.logger.With("target", name))
+		loaded.targets[name] = targetReg
+		loaded.probes[name] = targetReg.Handler(target.ScrapeTimeout, target.ScrapeTimeoutOffset, r.opts)
+		registries = append(registries, targetReg)
+	}
+	loaded.handler = scrapeHandler(cfg.ScrapeTimeout, cfg.ScrapeTimeoutOffset, r.opts, gatherAll(registries))
+	return loaded
+}
+
+// gatherAll returns a function gathering the services of registries
+// concurrently, so that a slow target does not hold back the others.
+func gatherAll(registries []*Registry) func(context.Context, []string) ([]*dto.MetricFamily, error) {
+	if len(registries) == 1 {
+		return registries[0].GatherServices
+	}
+	return func(ctx context.Context, services []string) ([]*dto.MetricFamily, error) {
+		gatherers := make(prometheus.Gatherers, len(registries))
+		var wg sync.WaitGroup
+		for i, reg := range registries {
+			wg.Go(func() {
+				families, err := reg.GatherServices(ctx, services)
+				gatherers[i] = prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+					return families, err
+				})
+			})
+		}
+		wg.Wait()
+		return gatherers.Gather()
+	}
+}
+
+// ServeHTTP serves the metrics of the current registry and of every target.
+func (r *Reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.serve(w, req, func(w http.ResponseWriter, req *http.Request, loaded *loadedRegistry) {
+		loaded.handler.ServeHTTP(w, req)
+	})
+}
+
+// serve runs handle with the current registries unless the exporter is
+// shutting down, keeping track of the request so that Shutdown waits for it
+// and a reload does not close the registries under it. The request context is
+// cancelled if Shutdown gives up waiting.
+func (r *Reloader) serve(w http.ResponseWriter, req *http.Request, handle func(http.ResponseWriter, *http.Request, *loadedRegistry)) {
+	r.stopMu.RLock()
+	if r.stopping {
+		r.stopMu.RUnlock()
+		http.Error(w, "The exporter is shutting down.", http.StatusServiceUnavailable)
+		return
+	}
+	r.requests.Add(1)
+	r.stopMu.RUnlock()
+	defer r.requests.Done()
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	stop := context.AfterFunc(r.aborted, cancel)
+	defer stop()
+
+	loaded := r.acquire()
+	defer loaded.release()
+
+	handle(w, req.WithContext(ctx), loaded)
+}
+
+// acquire returns the current registries with a reference taken on them.
+func (r *Reloader) acquire() *loadedRegistry {
+	for {
+		// A registry retired between loading and acquiring it is
+		// replaced by then.
+		if loaded := r.current.Load(); loaded.acquire() {
+			return loaded
+		}
+	}
+}
+
+// ReloadHandler returns an HTTP handler that reloads the configuration on
+// POST and PUT requests.
+func (r *Reloader) ReloadHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost && req.Method != http.MethodPut {
+			w.Header().Set("Allow", "POST, PUT")
+			http.Error(w, "This endpoint requires a POST or PUT request.", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.Reload(); err != nil {
+			r.logger.Error("Failed to reload configuration", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
+
+// ServiceHandler returns an HTTP handler that serves the metrics of the
+// service named by the request path below prefix, e.g. nova for
+// <prefix>/nova, so that every service can be scraped at its own interval.
+func (r *Reloader) ServiceHandler(prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		service := strings.TrimPrefix(req.URL.Path, prefix)
+		if _, ok := Collectors[service]; !ok {
+			http.NotFound(w, req)
+			return
+		}
+
+		req = req.Clone(req.Context())
+		query := req.URL.Query()
+		query[collectParam] = []string{service}
+		req.URL.RawQuery = query.Encode()
+		r.ServeHTTP(w, req)
+	})
+}
+
+// ProbeHandler returns an HTTP handler that serves the metrics of the target
+// named by the target query parameter only, like the probes of the blackbox
+// exporter.
+func (r *Reloader) ProbeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.serve(w, req, func(w http.ResponseWriter, req *http.Request, loaded *loadedRegistry) {
+			name := req.URL.Query().Get("target")
+			if name == "" {
+				http.Error(w, "target parameter is missing", http.StatusBadRequest)
+				return
+			}
+
+			probe, ok := loaded.probes[name]
+			if !ok {
+				http.Error(w, fmt.Sprintf("unknown target %q", name), http.StatusNotFound)
+				return
+			}
+			probe.ServeHTTP(w, req)
+		})
+	})
+}
+
+// Shutdown stops serving requests and waits for those in flight, then closes
+// the registries and with them every connection pool. Requests still running
+// once ctx is done are cancelled, cutting their collections short, and ctx's
+// error is returned after they ended.
+func (r *Reloader) Shutdown(ctx context.Context) error {
+	r.stopMu.Lock()
+	r.stopping = true
+	r.stopMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		r.requests.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+		r.abortRequests()
+		<-drained
+	}
+	return errors.Join(err, r.Close())
+}
+
+// Close closes the current registries.
+func (r *Reloader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current.Load().close()
+}
@@ -0,0 +1,229 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/util"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	var loadErr error
+	loads := 0
+	load := func() (Config, error) {
+		loads++
+		return Config{}, loadErr
+	}
+
+	r, err := NewReloader(load, promhttp.HandlerOpts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+
+	first := r.current.Load()
+	require.NoError(t, r.Reload())
+	assert.NotSame(t, first, r.current.Load())
+	assert.Equal(t, 2, loads)
+
+	// A broken configuration keeps the previous registry.
+	loadErr = errors.New("bad config")
+	current := r.current.Load()
+	require.Error(t, r.Reload())
+	assert.Same(t, current, r.current.Load())
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "openstack_exporter_config_last_reload_successful 0")
+}
+
+func TestReloader_ReloadHandler(t *testing.T) {
+	load := func() (Config, error) { return Config{}, nil }
+	r, err := NewReloader(load, promhttp.HandlerOpts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+
+	tests := []struct {
+		method   string
+		expected int
+	}{
+		{method: http.MethodGet, expected: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, expected: http.StatusOK},
+		{method: http.MethodPut, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ReloadHandler().ServeHTTP(rec, httptest.NewRequest(tt.method, "/-/reload", nil))
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
+
+func TestReloader_Targets(t *testing.T) {
+	load := func() (Config, error) {
+		return Config{Targets: map[string]Config{
+			"RegionOne": {Region: "RegionOne"},
+			"RegionTwo": {Region: "RegionTwo"},
+		}}, nil
+	}
+	r, err := NewReloader(load, promhttp.HandlerOpts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+
+	for name, reg := range r.current.Load().targets {
+		reg.Service("test").MustRegister(util.Named("volumes", &deadlineCollector{}))
+		assert.Equal(t, name, reg.region)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `openstack_test_up{region="RegionOne"} 1`)
+	assert.Contains(t, rec.Body.String(), `openstack_test_up{region="RegionTwo"} 1`)
+	assert.Contains(t, rec.Body.String(), "openstack_exporter_config_last_reload_successful 1")
+
+	tests := []struct {
+		name     string
+		url      string
+		expected int
+	}{
+		{name: "target", url: "/probe?target=RegionTwo", expected: http.StatusOK},
+		{name: "missing target", url: "/probe", expected: http.StatusBadRequest},
+		{name: "unknown target", url: "/probe?target=RegionThree", expected: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ProbeHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			assert.Equal(t, tt.expected, rec.Code)
+			if tt.expected == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `openstack_test_up{region="RegionTwo"} 1`)
+				assert.NotContains(t, rec.Body.String(), "RegionOne")
+			}
+		})
+	}
+}
+
+func TestReloader_ServiceHandler(t *testing.T) {
+	load := func() (Config, error) { return Config{}, nil }
+	r, err := NewReloader(load, promhttp.HandlerOpts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+
+	reg := r.current.Load().registry
+	reg.Service("nova").MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "openstack_nova_test", Help: "nova"}))
+	reg.Service("keystone").MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "openstack_identity_test", Help: "keystone"}))
+
+	rec := httptest.NewRecorder()
+	r.ServiceHandler("/metrics/").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/keystone", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "openstack_identity_test")
+	assert.NotContains(t, rec.Body.String(), "openstack_nova_test")
+
+	rec = httptest.NewRecorder()
+	r.ServiceHandler("/metrics/").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/swift", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// blockingCollector tells when it is collected and reports up=0 once its
+// context is done.
+type blockingCollector struct {
+	started chan struct{}
+}
+
+func (c *blockingCollector) Describe(ch chan<- *prometheus.Desc) { ch <- testDesc }
+func (c *blockingCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *blockingCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	close(c.started)
+	<-ctx.Done()
+	ch <- prometheus.MustNewConstMetric(testDesc, prometheus.GaugeValue, 0)
+}
+
+func TestReloader_Shutdown(t *testing.T) {
+	load := func() (Config, error) { return Config{}, nil }
+	r, err := NewReloader(load, promhttp.HandlerOpts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	c := &blockingCollector{started: make(chan struct{})}
+	r.current.Load().registry.Service("test").MustRegister(c)
+
+	scraped := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		scraped <- rec
+	}()
+	<-c.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, r.Shutdown(ctx), context.DeadlineExceeded)
+
+	// The scrape was cut short rather than left hanging.
+	rec := <-scraped
+	assert.Contains(t, rec.Body.String(), "openstack_test_up 0")
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Error(t, r.Reload())
+}
+
+func TestReloader_ReloadWaitsForScrapes(t *testing.T) {
+	load := func() (Config, error) { return Config{}, nil }
+	r, err := NewReloader(load, promhttp.HandlerOpts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+
+	prev := r.current.Load()
+	c := &gatedCollector{started: make(chan struct{}), release: make(chan struct{})}
+	prev.registry.Service("test").MustRegister(c)
+
+	scraped := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		scraped <- rec
+	}()
+	<-c.started
+
+	// The previous registry stays open until the scrape using it ended.
+	require.NoError(t, r.Reload())
+	assert.NotSame(t, prev, r.current.Load())
+	prev.mu.Lock()
+	assert.True(t, prev.retired)
+	assert.Equal(t, 1, prev.refs)
+	prev.mu.Unlock()
+
+	close(c.release)
+	rec := <-scraped
+	assert.Contains(t, rec.Body.String(), "openstack_test_up 1")
+	prev.mu.Lock()
+	assert.Zero(t, prev.refs)
+	prev.mu.Unlock()
+	assert.False(t, prev.acquire())
+}
+
+func TestReloader_ShutdownWithoutScrapes(t *testing.T) {
+	load := func() (Config, error) { return Config{}, nil }
+	r, err := NewReloader(load, promhttp.HandlerOpts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Shutdown(context.Background()))
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// sharedCollection is a collection of a service shared by the scrapes that
+// ask for the service while it runs.
+type sharedCollection struct {
+	ctx  *sharedContext
+	done chan struct{}
+
+	families []*dto.MetricFamily
+	err      error
+}
+
+// sharedContext is the context of a shared collection. It carries the values
+// of the scrape that started the collection but is done only once every scrape
+// waiting for it is done, so that the collection is bounded by the longest of
+// their timeouts and is not cut short when a single scrape leaves. It is
+// cancelled once the collection ended.
+type sharedContext struct {
+	context.Context
+	done chan struct{}
+
+	mu        sync.Mutex
+	waiters   int
+	deadline  time.Time
+	unbounded bool
+	err       error
+}
+
+func newSharedContext(ctx context.Context) *sharedContext {
+	s := &sharedContext{Context: context.WithoutCancel(ctx), done: make(chan struct{})}
+	s.join(ctx)
+	return s
+}
+
+// join adds a scrape running under ctx to the waiters, unless s is done
+// already.
+func (s *sharedContext) join(ctx context.Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return false
+	}
+	s.waiters++
+	if deadline, ok := ctx.Deadline(); !ok {
+		s.unbounded = true
+	} else if deadline.After(s.deadline) {
+		s.deadline = deadline
+	}
+	return true
+}
+
+// leave removes a scrape that gave up with err from the waiters, and reports
+// whether it was the last one, which ends s with err.
+func (s *sharedContext) leave(err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.waiters--
+	if s.waiters > 0 {
+		return false
+	}
+	s.end(err)
+	return true
+}
+
+// cancel ends s once the collection ended.
+func (s *sharedContext) cancel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.end(context.Canceled)
+}
+
+func (s *sharedContext) end(err error) {
+	if s.err == nil {
+		s.err = err
+		close(s.done)
+	}
+}
+
+func (s *sharedContext) Deadline() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.unbounded {
+		return time.Time{}, false
+	}
+	return s.deadline, true
+}
+
+func (s *sharedContext) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *sharedContext) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedContext(t *testing.T) {
+	short, cancelShort := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelShort()
+	long, cancelLong := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelLong()
+
+	s := newSharedContext(short)
+	require.True(t, s.join(long))
+	deadline, ok := s.Deadline()
+	require.True(t, ok)
+	longDeadline, _ := long.Deadline()
+	assert.Equal(t, longDeadline, deadline)
+
+	assert.False(t, s.leave(context.Canceled))
+	require.NoError(t, s.Err())
+	assert.True(t, s.leave(context.DeadlineExceeded))
+	<-s.Done()
+	assert.ErrorIs(t, s.Err(), context.DeadlineExceeded)
+	assert.False(t, s.join(long))
+
+	unbounded := newSharedContext(short)
+	require.True(t, unbounded.join(context.Background()))
+	_, ok = unbounded.Deadline()
+	assert.False(t, ok)
+	unbounded.cancel()
+	assert.ErrorIs(t, unbounded.Err(), context.Canceled)
+}
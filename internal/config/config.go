@@ -0,0 +1,433 @@
+// Package config loads the exporter settings from a YAML file.
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"go.yaml.in/yaml/v2"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/collector"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
+	"github.com/vexxhost/openstack_database_exporter/internal/relabel"
+)
+
+// File is the layout of the configuration file. Every setting is optional
+// and falls back to the command-line flags.
+type File struct {
+	ProjectCacheTTL          time.Duration `yaml:"project_cache_ttl"`
+	Scrape                   Scrape        `yaml:"scrape"`
+	Poll                     Poll          `yaml:"poll"`
+	Pool                     Pool          `yaml:"pool"`
+	Replication              Replication   `yaml:"replication"`
+	DisableDefaultCollectors bool          `yaml:"disable_default_collectors"`
+	// Aggregate replaces per-object metrics with per-project aggregates,
+	// see collector.AggregateServices.
+	Aggregate bool `yaml:"aggregate"`
+	// Relabel rewrites the series of the metrics of every target, in
+	// order.
+	Relabel []Relabel `yaml:"relabel"`
+	// Region is the region label of the metrics of Services.
+	Region   string             `yaml:"region"`
+	Services map[string]Service `yaml:"services"`
+	// Targets are further sets of databases, e.g. one per region, keyed by
+	// the region label of their metrics.
+	Targets map[string]Target `yaml:"targets"`
+}
+
+// Target holds the services of one target. Their settings default to the
+// top-level ones, but no database URL is inherited.
+type Target struct {
+	Services map[string]Service `yaml:"services"`
+}
+
+// Scrape bounds every scrape.
+type Scrape struct {
+	Timeout       time.Duration `yaml:"timeout"`
+	TimeoutOffset time.Duration `yaml:"timeout_offset"`
+	Parallelism   int           `yaml:"parallelism"`
+	// Snapshot reads each service from a consistent snapshot.
+	Snapshot bool `yaml:"snapshot"`
+}
+
+// Poll enables background polling of every service.
+type Poll struct {
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Replication guards reading from replicas listed before the primary in a
+// database URL.
+type Replication struct {
+	MaxLag            time.Duration `yaml:"max_lag"`
+	MaxWsrepRecvQueue int           `yaml:"max_wsrep_recv_queue"`
+}
+
+// Pool sizes a connection pool. Zero values are inherited.
+type Pool struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// apply returns base with the values set in p.
+func (p Pool) apply(base db.PoolConfig) db.PoolConfig {
+	if p.MaxOpenConns > 0 {
+		base.MaxOpenConns = p.MaxOpenConns
+	}
+	if p.MaxIdleConns > 0 {
+		base.MaxIdleConns = p.MaxIdleConns
+	}
+	if p.ConnMaxLifetime > 0 {
+		base.ConnMaxLifetime = p.ConnMaxLifetime
+	}
+	if p.ConnMaxIdleTime > 0 {
+		base.ConnMaxIdleTime = p.ConnMaxIdleTime
+	}
+	return base
+}
+
+func (p Pool) validate() error {
+	if p.MaxOpenConns < 0 || p.MaxIdleConns < 0 {
+		return fmt.Errorf("pool sizes must not be negative")
+	}
+	if p.ConnMaxLifetime < 0 || p.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("pool durations must not be negative")
+	}
+	return nil
+}
+
+// Relabel bounds the series of the metrics whose name matches the regular
+// expression Metric. Regular expressions match whole names and values. Merged
+// series are summed, so drop_labels, keep_labels and max_series only suit
+// count-like metrics, not state gauges such as nova_server_status.
+type Relabel struct {
+	Metric string `yaml:"metric"`
+	// KeepSeries keeps only the series whose labels all match, and
+	// DropSeries drops the series of which any label matches.
+	KeepSeries map[string]string `yaml:"keep_series"`
+	DropSeries map[string]string `yaml:"drop_series"`
+	// DropLabels removes labels and KeepLabels all others. Series left
+	// with the same labels are summed.
+	DropLabels []string `yaml:"drop_labels"`
+	KeepLabels []string `yaml:"keep_labels"`
+	// MaxSeries caps the number of series, summing the overflow into a
+	// series whose labels are all __other__.
+	MaxSeries int `yaml:"max_series"`
+}
+
+// rule compiles the regular expressions of r.
+func (r Relabel) rule() (relabel.Rule, error) {
+	if r.Metric == "" {
+		return relabel.Rule{}, fmt.Errorf("metric must be set")
+	}
+	if len(r.DropLabels) > 0 && len(r.KeepLabels) > 0 {
+		return relabel.Rule{}, fmt.Errorf("drop_labels and keep_labels are mutually exclusive")
+	}
+	if r.MaxSeries < 0 {
+		return relabel.Rule{}, fmt.Errorf("max_series must not be negative")
+	}
+
+	metric, err := relabel.Compile(r.Metric)
+	if err != nil {
+		return relabel.Rule{}, fmt.Errorf("metric: %w", err)
+	}
+	keep, err := compileLabels(r.KeepSeries)
+	if err != nil {
+		return relabel.Rule{}, fmt.Errorf("keep_series: %w", err)
+	}
+	drop, err := compileLabels(r.DropSeries)
+	if err != nil {
+		return relabel.Rule{}, fmt.Errorf("drop_series: %w", err)
+	}
+	return relabel.Rule{
+		Metric:     metric,
+		KeepSeries: keep,
+		DropSeries: drop,
+		DropLabels: r.DropLabels,
+		KeepLabels: r.KeepLabels,
+		MaxSeries:  r.MaxSeries,
+	}, nil
+}
+
+func compileLabels(exprs map[string]string) (map[string]*regexp.Regexp, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]*regexp.Regexp, len(exprs))
+	for _, name := range slices.Sorted(maps.Keys(exprs)) {
+		re, err := relabel.Compile(exprs[name])
+		if err != nil {
+			return nil, fmt.Errorf("label %q: %w", name, err)
+		}
+		result[name] = re
+	}
+	return result, nil
+}
+
+// Service holds the settings of one service.
+type Service struct {
+	DatabaseURL string `yaml:"database_url"`
+	// APIDatabaseURL is only used by nova.
+	APIDatabaseURL string         `yaml:"api_database_url"`
+	PollInterval   *time.Duration `yaml:"poll_interval"`
+	Pool           Pool           `yaml:"pool"`
+	// Collectors enables or disables the service's collectors by name.
+	Collectors map[string]bool `yaml:"collectors"`
+	// Aggregate overrides the top-level aggregate setting.
+	Aggregate *bool `yaml:"aggregate"`
+}
+
+// Load reads the configuration file at filename and applies it on top of
+// base, which usually holds the values of the command-line flags.
+func Load(filename string, base collector.Config) (collector.Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return collector.Config{}, err
+	}
+
+	cfg, err := Parse(data, base)
+	if err != nil {
+		return collector.Config{}, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// LoadWithDatabaseSources is like Load but resolves the database URLs from
+// sources afterwards, see ApplyDatabaseSources, so that a password file also
+// replaces the password of a database_url set in the file. An empty filename
+// only applies sources to base. The sources only apply to the top-level
+// services, not to those of the targets.
+func LoadWithDatabaseSources(filename string, base collector.Config, sources map[string]DatabaseSource) (collector.Config, error) {
+	cfg := base
+	if filename != "" {
+		var err error
+		if cfg, err = Load(filename, base); err != nil {
+			return collector.Config{}, err
+		}
+	}
+	if err := ApplyDatabaseSources(&cfg, sources); err != nil {
+		return collector.Config{}, err
+	}
+	return cfg, nil
+}
+
+// Parse is like Load but reads the configuration from data.
+func Parse(data []byte, base collector.Config) (collector.Config, error) {
+	f := File{
+		ProjectCacheTTL: base.ProjectCacheTTL,
+		Scrape: Scrape{
+			Timeout:       base.ScrapeTimeout,
+			TimeoutOffset: base.ScrapeTimeoutOffset,
+			Parallelism:   base.Parallelism,
+			Snapshot:      base.Snapshot,
+		},
+		Poll: Poll{Interval: base.PollInterval},
+		Pool: Pool{
+			MaxOpenConns:    base.Pool.MaxOpenConns,
+			MaxIdleConns:    base.Pool.MaxIdleConns,
+			ConnMaxLifetime: base.Pool.ConnMaxLifetime,
+			ConnMaxIdleTime: base.Pool.ConnMaxIdleTime,
+		},
+		Replication: Replication{
+			MaxLag:            base.Replication.MaxLag,
+			MaxWsrepRecvQueue: base.Replication.MaxRecvQueue,
+		},
+		DisableDefaultCollectors: base.DisableDefaultCollectors,
+		Aggregate:                base.Aggregate,
+	}
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return collector.Config{}, err
+	}
+	if err := f.validate(); err != nil {
+		return collector.Config{}, err
+	}
+
+	cfg := base
+	cfg.ProjectCacheTTL = f.ProjectCacheTTL
+	cfg.ScrapeTimeout = f.Scrape.Timeout
+	cfg.ScrapeTimeoutOffset = f.Scrape.TimeoutOffset
+	cfg.Parallelism = f.Scrape.Parallelism
+	cfg.Snapshot = f.Scrape.Snapshot
+	cfg.PollInterval = f.Poll.Interval
+	cfg.Pool = f.Pool.apply(db.PoolConfig{})
+	cfg.Replication = db.ReplicationConfig{MaxLag: f.Replication.MaxLag, MaxRecvQueue: f.Replication.MaxWsrepRecvQueue}
+	cfg.ServicePollIntervals = maps.Clone(base.ServicePollIntervals)
+	cfg.ServicePools = maps.Clone(base.ServicePools)
+	cfg.DisableDefaultCollectors = f.DisableDefaultCollectors
+	cfg.Collectors = maps.Clone(base.Collectors)
+	cfg.Aggregate = f.Aggregate
+	cfg.ServiceAggregate = maps.Clone(base.ServiceAggregate)
+	cfg.Region = f.Region
+	cfg.Relabel = nil
+	for _, r := range f.Relabel {
+		// The rules were checked by validate.
+		rule, _ := r.rule()
+		cfg.Relabel = append(cfg.Relabel, rule)
+	}
+
+	// Targets start from the top-level settings, before the services
+	// override them.
+	if len(f.Targets) > 0 {
+		cfg.Targets = make(map[string]collector.Config, len(f.Targets))
+	}
+	for name, t := range f.Targets {
+		target := cfg
+		clearDatabaseURLs(&target)
+		target.ServicePollIntervals = maps.Clone(cfg.ServicePollIntervals)
+		target.ServicePools = maps.Clone(cfg.ServicePools)
+		target.Collectors = maps.Clone(cfg.Collectors)
+		target.ServiceAggregate = maps.Clone(cfg.ServiceAggregate)
+		target.Region = name
+		target.Targets = nil
+		applyServices(&target, t.Services)
+		cfg.Targets[name] = target
+	}
+
+	applyServices(&cfg, f.Services)
+
+	return cfg, nil
+}
+
+// applyServices applies the settings of services to cfg.
+func applyServices(cfg *collector.Config, services map[string]Service) {
+	for name, s := range services {
+		if s.DatabaseURL != "" {
+			*databaseURL(cfg, name) = s.DatabaseURL
+		}
+		if s.APIDatabaseURL != "" {
+			cfg.NovaAPIDatabaseURL = s.APIDatabaseURL
+		}
+		if s.PollInterval != nil {
+			if cfg.ServicePollIntervals == nil {
+				cfg.ServicePollIntervals = make(map[string]time.Duration)
+			}
+			cfg.ServicePollIntervals[name] = *s.PollInterval
+		}
+		if s.Pool != (Pool{}) {
+			if cfg.ServicePools == nil {
+				cfg.ServicePools = make(map[string]db.PoolConfig)
+			}
+			pool, ok := cfg.ServicePools[name]
+			if !ok {
+				pool = cfg.Pool
+			}
+			cfg.ServicePools[name] = s.Pool.apply(pool)
+		}
+		for collectorName, enabled := range s.Collectors {
+			if cfg.Collectors == nil {
+				cfg.Collectors = make(map[string]bool)
+			}
+			cfg.Collectors[name+"."+collectorName] = enabled
+		}
+		if s.Aggregate != nil {
+			if cfg.ServiceAggregate == nil {
+				cfg.ServiceAggregate = make(map[string]bool)
+			}
+			cfg.ServiceAggregate[name] = *s.Aggregate
+		}
+	}
+}
+
+func (f *File) validate() error {
+	if f.ProjectCacheTTL < 0 || f.Scrape.Timeout < 0 || f.Scrape.TimeoutOffset < 0 || f.Poll.Interval < 0 {
+		return fmt.Errorf("durations must not be negative")
+	}
+	if err := f.Pool.validate(); err != nil {
+		return err
+	}
+	if f.Replication.MaxLag < 0 || f.Replication.MaxWsrepRecvQueue < 0 {
+		return fmt.Errorf("replication limits must not be negative")
+	}
+
+	for i, r := range f.Relabel {
+		if _, err := r.rule(); err != nil {
+			return fmt.Errorf("relabel rule %d: %w", i+1, err)
+		}
+	}
+
+	if err := validateServices(f.Services); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(f.Targets)) {
+		if name == "" {
+			return fmt.Errorf("target names must not be empty")
+		}
+		if name == f.Region {
+			return fmt.Errorf("target %q: the name is the region of the top-level services", name)
+		}
+		if err := validateServices(f.Targets[name].Services); err != nil {
+			return fmt.Errorf("target %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func validateServices(services map[string]Service) error {
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		s := services[name]
+		if databaseURL(&collector.Config{}, name) == nil {
+			return fmt.Errorf("unknown service %q", name)
+		}
+		if s.APIDatabaseURL != "" && name != "nova" {
+			return fmt.Errorf("service %q: api_database_url is only supported for nova", name)
+		}
+		if s.Aggregate != nil && !slices.Contains(collector.AggregateServices, name) {
+			return fmt.Errorf("service %q: aggregate is not supported", name)
+		}
+		if s.PollInterval != nil && *s.PollInterval < 0 {
+			return fmt.Errorf("service %q: poll_interval must not be negative", name)
+		}
+		if err := s.Pool.validate(); err != nil {
+			return fmt.Errorf("service %q: %w", name, err)
+		}
+		for collectorName := range s.Collectors {
+			if !slices.Contains(collector.Collectors[name], collectorName) {
+				return fmt.Errorf("service %q: unknown collector %q", name, collectorName)
+			}
+		}
+	}
+	return nil
+}
+
+// clearDatabaseURLs unsets the database URL of every service in cfg.
+func clearDatabaseURLs(cfg *collector.Config) {
+	for service := range collector.Collectors {
+		*databaseURL(cfg, service) = ""
+	}
+	cfg.NovaAPIDatabaseURL = ""
+}
+
+// databaseURL returns the field of cfg holding the database URL of the named
+// service, or nil if there is no such service.
+func databaseURL(cfg *collector.Config, service string) *string {
+	switch service {
+	case "cinder":
+		return &cfg.CinderDatabaseURL
+	case "glance":
+		return &cfg.GlanceDatabaseURL
+	case "heat":
+		return &cfg.HeatDatabaseURL
+	case "ironic":
+		return &cfg.IronicDatabaseURL
+	case "keystone":
+		return &cfg.KeystoneDatabaseURL
+	case "magnum":
+		return &cfg.MagnumDatabaseURL
+	case "manila":
+		return &cfg.ManilaDatabaseURL
+	case "neutron":
+		return &cfg.NeutronDatabaseURL
+	case "nova":
+		return &cfg.NovaDatabaseURL
+	case "octavia":
+		return &cfg.OctaviaDatabaseURL
+	case "placement":
+		return &cfg.PlacementDatabaseURL
+	}
+	return nil
+}
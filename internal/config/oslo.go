@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/collector"
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
+)
+
+// DatabaseSource reads the database URL of a service from the files mounted
+// for the service itself.
+type DatabaseSource struct {
+	// ConfigFile is an oslo.config file holding the URL as the connection
+	// option of Section. It is only read if no URL is configured otherwise.
+	ConfigFile string
+	Section    string
+	// PasswordFile holds the password replacing the one of the URL.
+	PasswordFile string
+}
+
+// DatabaseSection returns the oslo.config section holding the database
+// connection of service, where "nova_api" names the nova API database.
+func DatabaseSection(service string) string {
+	switch service {
+	case "nova_api":
+		return "api_database"
+	case "placement":
+		return "placement_database"
+	}
+	return "database"
+}
+
+// Files returns the files read by s.
+func (s DatabaseSource) Files() []string {
+	var files []string
+	for _, file := range []string{s.ConfigFile, s.PasswordFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Resolve returns the database URL built from s, starting from the
+// configured databaseURL.
+func (s DatabaseSource) Resolve(databaseURL string) (string, error) {
+	if databaseURL == "" && s.ConfigFile != "" {
+		connection, err := readOsloOption(s.ConfigFile, s.Section, "connection")
+		if err != nil {
+			return "", err
+		}
+		databaseURL = connection
+	}
+	if databaseURL == "" || s.PasswordFile == "" {
+		return databaseURL, nil
+	}
+
+	password, err := os.ReadFile(s.PasswordFile)
+	if err != nil {
+		return "", err
+	}
+
+	// The password applies to every database of a failover list.
+	urls := db.SplitURLs(databaseURL)
+	for i, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			// The error would include the URL and thus the old password.
+			return "", fmt.Errorf("invalid database URL for password file %s", s.PasswordFile)
+		}
+		u.User = url.UserPassword(u.User.Username(), strings.TrimRight(string(password), "\r\n"))
+		urls[i] = u.String()
+	}
+	return strings.Join(urls, ","), nil
+}
+
+// ApplyDatabaseSources resolves the database URLs of cfg from sources, keyed
+// by service name with "nova_api" for the nova API database.
+func ApplyDatabaseSources(cfg *collector.Config, sources map[string]DatabaseSource) error {
+	for service, source := range sources {
+		field := databaseURL(cfg, service)
+		if service == "nova_api" {
+			field = &cfg.NovaAPIDatabaseURL
+		}
+		if field == nil {
+			return fmt.Errorf("unknown service %q", service)
+		}
+
+		resolved, err := source.Resolve(*field)
+		if err != nil {
+			return fmt.Errorf("%s database: %w", service, err)
+		}
+		*field = resolved
+	}
+	return nil
+}
+
+// readOsloOption returns the value of option in section of an oslo.config
+// INI file. As with oslo.config, later values override earlier ones,
+// indented lines continue the previous value and values may be quoted.
+func readOsloOption(filename, section, option string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		current string
+		key     string
+		value   string
+		found   bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';':
+			continue
+		case line[0] == ' ' || line[0] == '\t':
+			if key == "" {
+				return "", fmt.Errorf("%s:%d: unexpected continuation line", filename, lineno)
+			}
+			if current == section && key == option {
+				value += "\n" + trimmed
+			}
+			continue
+		case trimmed[0] == '[':
+			if !strings.HasSuffix(trimmed, "]") {
+				return "", fmt.Errorf("%s:%d: invalid section header", filename, lineno)
+			}
+			current = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			key = ""
+			continue
+		}
+
+		i := strings.IndexAny(trimmed, "=:")
+		if i < 0 {
+			return "", fmt.Errorf("%s:%d: expected key = value", filename, lineno)
+		}
+		key = strings.TrimSpace(trimmed[:i])
+		if current == section && key == option {
+			value = strings.TrimSpace(trimmed[i+1:])
+			found = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if !found {
+		return "", fmt.Errorf("%s: no %s option in section [%s]", filename, option, section)
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return value, nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Watch checks the files at every interval and calls changed once any of
+// them was modified, created or removed, until ctx is done.
+func Watch(ctx context.Context, files []string, interval time.Duration, changed func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := stat(files)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := stat(files)
+		if !equalStats(current, last) {
+			last = current
+			changed()
+		}
+	}
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func stat(files []string) []fileStat {
+	stats := make([]fileStat, len(files))
+	for i, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			stats[i] = fileStat{modTime: info.ModTime(), size: info.Size(), exists: true}
+		}
+	}
+	return stats
+}
+
+func equalStats(a, b []fileStat) bool {
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size || a[i].exists != b[i].exists {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	filename := writeFile(t, "password", "old")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan struct{}, 1)
+	go Watch(ctx, []string{filename}, 10*time.Millisecond, func() { changed <- struct{}{} })
+
+	select {
+	case <-changed:
+		t.Fatal("unchanged file reported as changed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(filename, []byte("rotated"), 0o600))
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not detected")
+	}
+}
@@ -257,6 +257,65 @@ func (q *Queries) GetSnapshotCount(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const GetVolumeAggregates = `-- name: GetVolumeAggregates :many
+SELECT
+    v.project_id,
+    v.status,
+    vt.name as volume_type,
+    v.availability_zone,
+    COUNT(*) as count,
+    CAST(COALESCE(SUM(v.size), 0) AS SIGNED) as size
+FROM
+    volumes v
+    LEFT JOIN volume_types vt ON v.volume_type_id = vt.id
+WHERE
+    v.deleted = 0
+GROUP BY
+    v.project_id,
+    v.status,
+    vt.name,
+    v.availability_zone
+`
+
+type GetVolumeAggregatesRow struct {
+	ProjectID        sql.NullString
+	Status           sql.NullString
+	VolumeType       sql.NullString
+	AvailabilityZone sql.NullString
+	Count            int64
+	Size             int64
+}
+
+func (q *Queries) GetVolumeAggregates(ctx context.Context) ([]GetVolumeAggregatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, GetVolumeAggregates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVolumeAggregatesRow
+	for rows.Next() {
+		var i GetVolumeAggregatesRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.Status,
+			&i.VolumeType,
+			&i.AvailabilityZone,
+			&i.Count,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const GetVolumeTypes = `-- name: GetVolumeTypes :many
 SELECT
     id,
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	queries "github.com/vexxhost/openstack_database_exporter/sql"
+)
+
+// Dialect is the SQL dialect of a database engine.
+type Dialect string
+
+const (
+	// MySQL is the dialect of MySQL and MariaDB, which the sqlc packages
+	// are generated for.
+	MySQL Dialect = "mysql"
+	// PostgreSQL is the dialect of PostgreSQL. Queries run on PostgreSQL
+	// are replaced by their version in sql/<schema>/postgresql.
+	PostgreSQL Dialect = "postgresql"
+)
+
+// schemes maps the oslo.db URL schemes, without the Python driver suffix, to
+// their dialect.
+var schemes = map[string]Dialect{
+	"mysql":      MySQL,
+	"postgresql": PostgreSQL,
+	"postgres":   PostgreSQL,
+}
+
+// URLDialect returns the dialect of an oslo.db connection string, e.g.
+// PostgreSQL for postgresql+psycopg2://nova:pw@db/nova.
+func URLDialect(connectionString string) (Dialect, error) {
+	if connectionString == "" {
+		return "", errors.New("connection string is empty")
+	}
+	scheme, _, ok := strings.Cut(connectionString, "://")
+	if !ok {
+		return "", errors.New("connection string has no scheme")
+	}
+	scheme, _, _ = strings.Cut(scheme, "+")
+	dialect, ok := schemes[scheme]
+	if !ok {
+		return "", fmt.Errorf("unsupported database scheme: %s (only mysql and postgresql are supported)", scheme)
+	}
+	return dialect, nil
+}
+
+// postgresQueries returns the PostgreSQL queries of every schema, keyed by
+// schema and sqlc query name.
+var postgresQueries = sync.OnceValues(func() (map[string]map[string]string, error) {
+	return loadQueries(queries.PostgreSQL, "postgresql")
+})
+
+// loadQueries reads <schema>/<dir>/queries.sql of every schema in fsys.
+func loadQueries(fsys fs.FS, dir string) (map[string]map[string]string, error) {
+	files, err := fs.Glob(fsys, path.Join("*", dir, "queries.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parseQueries(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		result[strings.SplitN(file, "/", 2)[0]] = parsed
+	}
+	return result, nil
+}
+
+// parseQueries splits an sqlc queries file into its named queries. As in the
+// code generated by sqlc, each query starts with its "-- name:" comment and
+// has no trailing semicolon. Anything before the first query is ignored.
+func parseQueries(data string) (map[string]string, error) {
+	blocks := strings.Split("\n"+data, "\n-- name: ")
+	result := make(map[string]string, len(blocks)-1)
+	for _, block := range blocks[1:] {
+		query := "-- name: " + strings.TrimSuffix(strings.TrimSpace(block), ";")
+		name := queryName(query)
+		if _, ok := result[name]; ok {
+			return nil, fmt.Errorf("duplicate query %s", name)
+		}
+		result[name] = query + "\n"
+	}
+	return result, nil
+}
+
+// translate wraps connector so that the sqlc queries of schema, which are
+// written for MySQL, run in their PostgreSQL version instead.
+func translate(connector driver.Connector, schema string) (driver.Connector, error) {
+	if schema == "" {
+		return nil, errors.New("the schema of a PostgreSQL database must be set")
+	}
+	all, err := postgresQueries()
+	if err != nil {
+		return nil, err
+	}
+	translations, ok := all[schema]
+	if !ok {
+		return nil, fmt.Errorf("no PostgreSQL queries for the %s schema", schema)
+	}
+	return &dialectConnector{Connector: connector, schema: schema, queries: translations}, nil
+}
+
+type dialectConnector struct {
+	driver.Connector
+	schema  string
+	queries map[string]string
+}
+
+func (c *dialectConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &dialectConn{Conn: conn, connector: c}, nil
+}
+
+// query returns the PostgreSQL version of query. Queries not generated by
+// sqlc, such as the ones checking replication, are already written for the
+// engine and returned unchanged.
+func (c *dialectConnector) query(query string) (string, error) {
+	name := queryName(query)
+	if name == unknownQuery {
+		return query, nil
+	}
+	translated, ok := c.queries[name]
+	if !ok {
+		return "", fmt.Errorf("query %s of the %s schema has no PostgreSQL version", name, c.schema)
+	}
+	return translated, nil
+}
+
+// dialectConn wraps a driver connection, forwarding the optional driver
+// interfaces that database/sql relies on.
+type dialectConn struct {
+	driver.Conn
+	connector *dialectConnector
+}
+
+func (c *dialectConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	query, err := c.connector.query(query)
+	if err != nil {
+		return nil, err
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *dialectConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	query, err := c.connector.query(query)
+	if err != nil {
+		return nil, err
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *dialectConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	query, err := c.connector.query(query)
+	if err != nil {
+		return nil, err
+	}
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *dialectConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return nil, errors.New("driver connection does not support BeginTx")
+}
+
+func (c *dialectConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *dialectConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *dialectConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *dialectConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
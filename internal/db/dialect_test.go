@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"maps"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLDialect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Dialect
+		wantErr  bool
+	}{
+		{input: "mysql://nova:pw@db/nova", expected: MySQL},
+		{input: "mysql+pymysql://nova:pw@db/nova", expected: MySQL},
+		{input: "postgresql://nova:pw@db/nova", expected: PostgreSQL},
+		{input: "postgresql+psycopg2://nova:pw@db/nova", expected: PostgreSQL},
+		{input: "postgres://nova:pw@db/nova", expected: PostgreSQL},
+		{input: "sqlite:///nova.db", wantErr: true},
+		{input: "db/nova", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			dialect, err := URLDialect(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, dialect)
+		})
+	}
+}
+
+func TestParseQueries(t *testing.T) {
+	queries, err := parseQueries("-- name: GetA :many\n-- A comment\nSELECT a FROM t;\n\n-- name: GetB :one\nSELECT COUNT(*) FROM t;\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"GetA": "-- name: GetA :many\n-- A comment\nSELECT a FROM t\n",
+		"GetB": "-- name: GetB :one\nSELECT COUNT(*) FROM t\n",
+	}, queries)
+
+	_, err = parseQueries("-- name: GetA :many\nSELECT 1;\n-- name: GetA :many\nSELECT 2;\n")
+	require.Error(t, err)
+}
+
+// TestPostgreSQLQueries ensures that every query generated by sqlc has a
+// PostgreSQL version.
+func TestPostgreSQLQueries(t *testing.T) {
+	mysql, err := loadQueries(os.DirFS("../../sql"), "")
+	require.NoError(t, err)
+	postgres, err := postgresQueries()
+	require.NoError(t, err)
+
+	require.NotEmpty(t, mysql)
+	assert.ElementsMatch(t, slices.Collect(maps.Keys(mysql)), slices.Collect(maps.Keys(postgres)))
+	for schema, queries := range mysql {
+		assert.ElementsMatch(t, slices.Collect(maps.Keys(queries)), slices.Collect(maps.Keys(postgres[schema])), schema)
+	}
+}
+
+func TestDialectConnector(t *testing.T) {
+	conn := &fakeConn{results: map[string][]map[string]driver.Value{
+		"-- name: GetA :many\nSELECT a FROM t\n": {{"a": int64(1)}},
+		"SELECT 1":                               {{"?column?": int64(1)}},
+	}}
+	c := &dialectConnector{
+		Connector: &fakeConnector{conn: conn},
+		schema:    "test",
+		queries:   map[string]string{"GetA": "-- name: GetA :many\nSELECT a FROM t\n"},
+	}
+
+	driverConn, err := c.Connect(context.Background())
+	require.NoError(t, err)
+	queryer := driverConn.(driver.QueryerContext)
+
+	// The MySQL query is replaced by the PostgreSQL one of the same name.
+	_, err = queryer.QueryContext(context.Background(), "-- name: GetA :many\nSELECT `a` FROM t\n", nil)
+	require.NoError(t, err)
+
+	// Queries not generated by sqlc are run as they are.
+	_, err = queryer.QueryContext(context.Background(), "SELECT 1", nil)
+	require.NoError(t, err)
+
+	_, err = queryer.QueryContext(context.Background(), "-- name: GetB :many\nSELECT b FROM t\n", nil)
+	require.ErrorContains(t, err, "GetB")
+}
+
+func TestTranslate(t *testing.T) {
+	for _, schema := range []string{"nova", "nova_api", "placement"} {
+		_, err := translate(&fakeConnector{}, schema)
+		require.NoError(t, err, schema)
+	}
+
+	_, err := translate(&fakeConnector{}, "swift")
+	require.Error(t, err)
+	_, err = translate(&fakeConnector{}, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReplicationConfig guards against reading from replicas that fall behind.
+type ReplicationConfig struct {
+	// MaxLag is the maximum replication delay of a replica. Zero disables
+	// the replication checks.
+	MaxLag time.Duration
+	// MaxRecvQueue is the maximum length of the receive queue of a Galera
+	// node.
+	MaxRecvQueue int
+}
+
+var schemePrefix = regexp.MustCompile(`^\s*[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// SplitURLs splits a comma-separated list of connection strings. A comma
+// that is not followed by a URL scheme is part of the preceding connection
+// string, e.g. of its password.
+func SplitURLs(s string) []string {
+	var urls []string
+	for _, part := range strings.Split(s, ",") {
+		if len(urls) > 0 && !schemePrefix.MatchString(part) {
+			urls[len(urls)-1] += "," + part
+			continue
+		}
+		urls = append(urls, part)
+	}
+
+	for i := range urls {
+		urls[i] = strings.TrimSpace(urls[i])
+	}
+	return urls
+}
+
+// failoverConnector opens connections to the first usable backend, so that
+// a service can be read from a replica while it is healthy and from the
+// primary otherwise. Since every new connection starts over with the first
+// backend, the pool moves back to the replica as its connections are
+// recycled, which they are at least every FailoverConnMaxLifetime by
+// default.
+type failoverConnector struct {
+	backends    []driver.Connector
+	dialect     Dialect
+	replication ReplicationConfig
+}
+
+func (c *failoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	errs := make([]error, 0, len(c.backends))
+	for i, backend := range c.backends {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		conn, err := backend.Connect(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("backend %d: %w", i+1, err))
+			continue
+		}
+
+		// The last backend is used regardless of its replication state,
+		// as there is nothing left to fall back to.
+		if i < len(c.backends)-1 && c.replication.MaxLag > 0 {
+			if err := checkReplication(ctx, conn, c.dialect, c.replication); err != nil {
+				_ = conn.Close()
+				errs = append(errs, fmt.Errorf("backend %d: %w", i+1, err))
+				continue
+			}
+		}
+		return conn, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (c *failoverConnector) Driver() driver.Driver {
+	return c.backends[0].Driver()
+}
+
+// checkReplication fails if conn is a replica lagging more than allowed.
+func checkReplication(ctx context.Context, conn driver.Conn, dialect Dialect, cfg ReplicationConfig) error {
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return nil
+	}
+	if dialect == PostgreSQL {
+		return checkPostgresReplication(ctx, queryer, cfg)
+	}
+	return checkMySQLReplication(ctx, queryer, cfg)
+}
+
+// checkMySQLReplication fails if conn is a replica lagging more than allowed,
+// or a Galera node that is not synced or has too long a receive queue.
+// Servers that are neither are fine.
+func checkMySQLReplication(ctx context.Context, queryer driver.QueryerContext, cfg ReplicationConfig) error {
+	// SHOW SLAVE STATUS was removed in MySQL 8.4, SHOW REPLICA STATUS is
+	// not known to MariaDB before 10.5.
+	status, err := queryRows(ctx, queryer, "SHOW REPLICA STATUS")
+	if err != nil {
+		status, err = queryRows(ctx, queryer, "SHOW SLAVE STATUS")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check replication status: %w", err)
+	}
+	if len(status) > 0 {
+		lag, ok := status[0]["Seconds_Behind_Source"]
+		if !ok {
+			lag = status[0]["Seconds_Behind_Master"]
+		}
+		if lag == nil {
+			return errors.New("replication is not running")
+		}
+		seconds, err := strconv.ParseInt(valueString(lag), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid replication lag %q", valueString(lag))
+		}
+		if time.Duration(seconds)*time.Second > cfg.MaxLag {
+			return fmt.Errorf("replication lag of %ds exceeds %s", seconds, cfg.MaxLag)
+		}
+		return nil
+	}
+
+	variables, err := queryRows(ctx, queryer, "SHOW GLOBAL STATUS WHERE Variable_name IN ('wsrep_local_state_comment', 'wsrep_local_recv_queue')")
+	if err != nil {
+		return fmt.Errorf("failed to check galera status: %w", err)
+	}
+	wsrep := make(map[string]string, len(variables))
+	for _, row := range variables {
+		wsrep[valueString(row["Variable_name"])] = valueString(row["Value"])
+	}
+	if state, ok := wsrep["wsrep_local_state_comment"]; ok && state != "Synced" {
+		return fmt.Errorf("galera node is %s, not synced", state)
+	}
+	if queue, ok := wsrep["wsrep_local_recv_queue"]; ok {
+		n, err := strconv.Atoi(queue)
+		if err != nil {
+			return fmt.Errorf("invalid galera receive queue %q", queue)
+		}
+		if n > cfg.MaxRecvQueue {
+			return fmt.Errorf("galera receive queue of %d exceeds %d", n, cfg.MaxRecvQueue)
+		}
+	}
+	return nil
+}
+
+// queryRows runs query on a driver connection and returns its rows keyed by
+// column name.
+func queryRows(ctx context.Context, queryer driver.QueryerContext, query string) ([]map[string]driver.Value, error) {
+	rows, err := queryer.QueryContext(ctx, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns := rows.Columns()
+	var result []map[string]driver.Value
+	for {
+		values := make([]driver.Value, len(columns))
+		if err := rows.Next(values); err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			return nil, err
+		}
+
+		row := make(map[string]driver.Value, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+}
+
+func valueString(v driver.Value) string {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
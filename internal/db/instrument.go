@@ -0,0 +1,336 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unknownQuery labels queries that were not generated by sqlc.
+const unknownQuery = "unknown"
+
+// Metrics instruments every query run on the connection pools opened by
+// ConnectContext, labelled by service and sqlc query name. Because it wraps
+// the driver, all generated Queries types are instrumented without changes.
+type Metrics struct {
+	duration *prometheus.HistogramVec
+	rows     *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+}
+
+// NewMetrics creates the query metrics under the exporter subsystem of
+// namespace.
+func NewMetrics(namespace string) *Metrics {
+	labels := []string{"service", "query"}
+	return &Metrics{
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "exporter",
+				Name:      "query_duration_seconds",
+				Help:      "Duration of database queries, from sending the query until its rows are closed.",
+				Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+			},
+			labels,
+		),
+		rows: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "exporter",
+				Name:      "query_rows_total",
+				Help:      "Total number of rows returned by database queries.",
+			},
+			labels,
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "exporter",
+				Name:      "query_errors_total",
+				Help:      "Total number of database queries that failed.",
+			},
+			labels,
+		),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.rows.Describe(ch)
+	m.errors.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.rows.Collect(ch)
+	m.errors.Collect(ch)
+}
+
+// instrument wraps connector so that its connections report to m.
+func (m *Metrics) instrument(connector driver.Connector, service string) driver.Connector {
+	return &instrumentedConnector{Connector: connector, metrics: m, service: service}
+}
+
+// QueryErrors counts the failed queries run under a context returned by
+// WithQueryErrors.
+type QueryErrors struct {
+	n    atomic.Int64
+	last atomic.Pointer[error]
+}
+
+// Count returns the number of failed queries.
+func (e *QueryErrors) Count() int64 {
+	return e.n.Load()
+}
+
+// Last returns the error of the last failed query, or nil if none failed.
+func (e *QueryErrors) Last() error {
+	if err := e.last.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+type queryErrorsKey struct{}
+
+// WithQueryErrors returns a context that counts the queries failing under
+// it on instrumented connection pools.
+func WithQueryErrors(ctx context.Context) (context.Context, *QueryErrors) {
+	errs := &QueryErrors{}
+	return context.WithValue(ctx, queryErrorsKey{}, errs), errs
+}
+
+// RecordError counts err as a failed query under ctx, if ctx was returned by
+// WithQueryErrors. It reports failures outside of the queries of instrumented
+// pools, such as failing to connect.
+func RecordError(ctx context.Context, err error) {
+	if errs, ok := ctx.Value(queryErrorsKey{}).(*QueryErrors); ok {
+		errs.n.Add(1)
+		errs.last.Store(&err)
+	}
+}
+
+// queryName returns the sqlc name of query, which sqlc puts in a leading
+// "-- name: <Name> :<command>" comment.
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return unknownQuery
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+// observation tracks one query until its rows are closed.
+type observation struct {
+	ctx     context.Context
+	metrics *Metrics
+	service string
+	query   string
+	start   time.Time
+}
+
+func (m *Metrics) observe(ctx context.Context, service, query string) *observation {
+	return &observation{ctx: ctx, metrics: m, service: service, query: queryName(query), start: time.Now()}
+}
+
+func (o *observation) done(rows int, err error) {
+	o.metrics.duration.WithLabelValues(o.service, o.query).Observe(time.Since(o.start).Seconds())
+	if rows > 0 {
+		o.metrics.rows.WithLabelValues(o.service, o.query).Add(float64(rows))
+	}
+	if err != nil {
+		o.metrics.errors.WithLabelValues(o.service, o.query).Inc()
+		RecordError(o.ctx, err)
+	}
+}
+
+type instrumentedConnector struct {
+	driver.Connector
+	metrics *Metrics
+	service string
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn, metrics: c.metrics, service: c.service}, nil
+}
+
+// instrumentedConn wraps a driver connection, forwarding the optional driver
+// interfaces that database/sql relies on.
+type instrumentedConn struct {
+	driver.Conn
+	metrics *Metrics
+	service string
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	o := c.metrics.observe(ctx, c.service, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		// database/sql retries with a prepared statement, which is
+		// instrumented instead.
+		return nil, err
+	}
+	if err != nil {
+		o.done(0, err)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, observation: o}, nil
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	o := c.metrics.observe(ctx, c.service, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err
+	}
+	o.done(0, err)
+	return result, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return nil, errors.New("driver connection does not support BeginTx")
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type instrumentedStmt struct {
+	driver.Stmt
+	conn  *instrumentedConn
+	query string
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, errors.New("driver statement does not support QueryContext")
+	}
+
+	o := s.conn.metrics.observe(ctx, s.conn.service, s.query)
+	rows, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		o.done(0, err)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, observation: o}, nil
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, errors.New("driver statement does not support ExecContext")
+	}
+
+	o := s.conn.metrics.observe(ctx, s.conn.service, s.query)
+	result, err := execer.ExecContext(ctx, args)
+	o.done(0, err)
+	return result, err
+}
+
+func (s *instrumentedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedRows completes the observation of a query once its rows are
+// closed.
+type instrumentedRows struct {
+	driver.Rows
+	observation *observation
+	n           int
+	eof         bool
+	err         error
+}
+
+func (r *instrumentedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.n++
+	case err == io.EOF:
+		r.eof = true
+	default:
+		r.err = err
+	}
+	return err
+}
+
+func (r *instrumentedRows) Close() error {
+	err := r.Rows.Close()
+	if r.observation != nil {
+		failed := errors.Join(r.err, err)
+		if failed == nil && !r.eof {
+			// database/sql closes the rows early when the context is
+			// done.
+			failed = r.observation.ctx.Err()
+		}
+		r.observation.done(r.n, failed)
+		r.observation = nil
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dsnConnector opens connections of a registered driver.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+func newInstrumentedMock(t *testing.T, metrics *Metrics) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	dsn := "instrument_" + t.Name()
+	mockDB, mock, err := sqlmock.NewWithDSN(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = mockDB.Close() })
+
+	conn := sql.OpenDB(metrics.instrument(dsnConnector{dsn: dsn, driver: mockDB.Driver()}, "cinder"))
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, mock
+}
+
+func TestMetrics_Query(t *testing.T) {
+	metrics := NewMetrics("openstack")
+	conn, mock := newInstrumentedMock(t, metrics)
+
+	const getVolumes = "-- name: GetVolumes :many\nSELECT id FROM volumes"
+	mock.ExpectQuery("SELECT id FROM volumes").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("a").AddRow("b"))
+	mock.ExpectQuery("SELECT id FROM snapshots").WillReturnError(errors.New("table missing"))
+
+	rows, err := conn.QueryContext(context.Background(), getVolumes)
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Close())
+
+	ctx, errs := WithQueryErrors(context.Background())
+	_, err = conn.QueryContext(ctx, "-- name: GetSnapshots :many\nSELECT id FROM snapshots")
+	require.Error(t, err)
+	assert.Equal(t, int64(1), errs.Count())
+	assert.ErrorContains(t, errs.Last(), "table missing")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	err = testutil.CollectAndCompare(metrics, strings.NewReader(`# HELP openstack_exporter_query_errors_total Total number of database queries that failed.
+# TYPE openstack_exporter_query_errors_total counter
+openstack_exporter_query_errors_total{query="GetSnapshots",service="cinder"} 1
+# HELP openstack_exporter_query_rows_total Total number of rows returned by database queries.
+# TYPE openstack_exporter_query_rows_total counter
+openstack_exporter_query_rows_total{query="GetVolumes",service="cinder"} 2
+`), "openstack_exporter_query_errors_total", "openstack_exporter_query_rows_total")
+	require.NoError(t, err)
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics, "openstack_exporter_query_duration_seconds"))
+}
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "-- name: GetInstances :many\nSELECT 1", expected: "GetInstances"},
+		{query: "-- name: GetProjectMetrics :one\nSELECT 1", expected: "GetProjectMetrics"},
+		{query: "SELECT 1", expected: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, queryName(tt.query))
+		})
+	}
+}
@@ -8,6 +8,15 @@ import (
 	"database/sql"
 )
 
+type FederatedUser struct {
+	ID          int32
+	UserID      string
+	IdpID       string
+	ProtocolID  string
+	UniqueID    string
+	DisplayName sql.NullString
+}
+
 type Group struct {
 	ID          string
 	DomainID    string
@@ -16,6 +25,21 @@ type Group struct {
 	Extra       sql.NullString
 }
 
+type LocalUser struct {
+	ID              int32
+	UserID          string
+	DomainID        string
+	Name            string
+	FailedAuthCount sql.NullInt32
+	FailedAuthAt    sql.NullTime
+}
+
+type NonlocalUser struct {
+	DomainID string
+	Name     string
+	UserID   string
+}
+
 type Project struct {
 	ID          string
 	Name        string
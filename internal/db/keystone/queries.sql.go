@@ -244,3 +244,44 @@ func (q *Queries) GetUserMetrics(ctx context.Context) ([]GetUserMetricsRow, erro
 	}
 	return items, nil
 }
+
+const GetUserNames = `-- name: GetUserNames :many
+SELECT
+    u.id,
+    u.domain_id,
+    COALESCE(MIN(lu.name), MIN(nlu.name), MIN(fu.display_name), MIN(fu.unique_id), '') as name
+FROM user u
+LEFT JOIN local_user lu ON u.id = lu.user_id
+LEFT JOIN nonlocal_user nlu ON u.id = nlu.user_id
+LEFT JOIN federated_user fu ON u.id = fu.user_id
+GROUP BY u.id, u.domain_id
+`
+
+type GetUserNamesRow struct {
+	ID       string
+	DomainID string
+	Name     string
+}
+
+func (q *Queries) GetUserNames(ctx context.Context) ([]GetUserNamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, GetUserNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserNamesRow
+	for rows.Next() {
+		var i GetUserNamesRow
+		if err := rows.Scan(&i.ID, &i.DomainID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -70,6 +70,59 @@ func (q *Queries) GetAgents(ctx context.Context) ([]GetAgentsRow, error) {
 	return items, nil
 }
 
+const GetFloatingIPAggregates = `-- name: GetFloatingIPAggregates :many
+SELECT
+    fip.project_id,
+    fip.floating_network_id,
+    fip.status,
+    (fip.router_id IS NOT NULL AND fip.router_id <> '') as associated,
+    CAST(COUNT(*) AS SIGNED) as cnt
+FROM
+    floatingips fip
+GROUP BY
+    fip.project_id,
+    fip.floating_network_id,
+    fip.status,
+    associated
+`
+
+type GetFloatingIPAggregatesRow struct {
+	ProjectID         sql.NullString
+	FloatingNetworkID string
+	Status            sql.NullString
+	Associated        bool
+	Cnt               int64
+}
+
+func (q *Queries) GetFloatingIPAggregates(ctx context.Context) ([]GetFloatingIPAggregatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, GetFloatingIPAggregates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFloatingIPAggregatesRow
+	for rows.Next() {
+		var i GetFloatingIPAggregatesRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.FloatingNetworkID,
+			&i.Status,
+			&i.Associated,
+			&i.Cnt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const GetFloatingIPs = `-- name: GetFloatingIPs :many
 SELECT
     fip.id,
@@ -399,6 +452,7 @@ func (q *Queries) GetNetworks(ctx context.Context) ([]GetNetworksRow, error) {
 const GetPorts = `-- name: GetPorts :many
 SELECT
     p.id,
+    p.project_id,
     p.mac_address,
     p.device_owner,
     p.status,
@@ -413,6 +467,7 @@ FROM
     LEFT JOIN ipallocations ia ON p.id = ia.port_id
 GROUP BY
     p.id,
+    p.project_id,
     p.mac_address,
     p.device_owner,
     p.status,
@@ -424,6 +479,7 @@ GROUP BY
 
 type GetPortsRow struct {
 	ID             string
+	ProjectID      sql.NullString
 	MacAddress     string
 	DeviceOwner    string
 	Status         string
@@ -445,6 +501,7 @@ func (q *Queries) GetPorts(ctx context.Context) ([]GetPortsRow, error) {
 		var i GetPortsRow
 		if err := rows.Scan(
 			&i.ID,
+			&i.ProjectID,
 			&i.MacAddress,
 			&i.DeviceOwner,
 			&i.Status,
@@ -106,6 +106,61 @@ func (q *Queries) GetComputeNodes(ctx context.Context) ([]GetComputeNodesRow, er
 	return items, nil
 }
 
+const GetInstanceAggregates = `-- name: GetInstanceAggregates :many
+SELECT
+    project_id,
+    availability_zone,
+    instance_type_id,
+    vm_state,
+    task_state,
+    COUNT(*) as count,
+    CAST(COALESCE(SUM(root_gb), 0) AS SIGNED) as root_gb
+FROM instances
+WHERE deleted = 0
+GROUP BY project_id, availability_zone, instance_type_id, vm_state, task_state
+`
+
+type GetInstanceAggregatesRow struct {
+	ProjectID        sql.NullString
+	AvailabilityZone sql.NullString
+	InstanceTypeID   sql.NullInt32
+	VmState          sql.NullString
+	TaskState        sql.NullString
+	Count            int64
+	RootGb           int64
+}
+
+func (q *Queries) GetInstanceAggregates(ctx context.Context) ([]GetInstanceAggregatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, GetInstanceAggregates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInstanceAggregatesRow
+	for rows.Next() {
+		var i GetInstanceAggregatesRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.AvailabilityZone,
+			&i.InstanceTypeID,
+			&i.VmState,
+			&i.TaskState,
+			&i.Count,
+			&i.RootGb,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const GetInstances = `-- name: GetInstances :many
 SELECT 
     id,
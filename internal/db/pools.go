@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Pools tracks the connection pools opened through ConnectContext and exports
+// their statistics, labelled by service and database name. Pools sharing both
+// labels are summed up.
+type Pools struct {
+	maxOpenDesc           *prometheus.Desc
+	openDesc              *prometheus.Desc
+	inUseDesc             *prometheus.Desc
+	idleDesc              *prometheus.Desc
+	waitCountDesc         *prometheus.Desc
+	waitDurationDesc      *prometheus.Desc
+	maxIdleClosedDesc     *prometheus.Desc
+	maxIdleTimeClosedDesc *prometheus.Desc
+	maxLifetimeClosedDesc *prometheus.Desc
+
+	mu     sync.Mutex
+	pools  []trackedPool
+	closed bool
+}
+
+type trackedPool struct {
+	db       *sql.DB
+	service  string
+	schema   string
+	database string
+}
+
+// NewPools creates an empty set of connection pools whose statistics are
+// exported under the exporter subsystem of namespace.
+func NewPools(namespace string) *Pools {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exporter", name),
+			help,
+			[]string{"service", "database"},
+			nil,
+		)
+	}
+
+	return &Pools{
+		maxOpenDesc:           desc("db_max_open_connections", "Maximum number of open connections to the database."),
+		openDesc:              desc("db_open_connections", "The number of established connections both in use and idle."),
+		inUseDesc:             desc("db_in_use_connections", "The number of connections currently in use."),
+		idleDesc:              desc("db_idle_connections", "The number of idle connections."),
+		waitCountDesc:         desc("db_wait_count_total", "The total number of connections waited for."),
+		waitDurationDesc:      desc("db_wait_duration_seconds_total", "The total time blocked waiting for a new connection."),
+		maxIdleClosedDesc:     desc("db_max_idle_closed_total", "The total number of connections closed due to the maximum number of idle connections."),
+		maxIdleTimeClosedDesc: desc("db_max_idle_time_closed_total", "The total number of connections closed due to the maximum idle time."),
+		maxLifetimeClosedDesc: desc("db_max_lifetime_closed_total", "The total number of connections closed due to the maximum connection lifetime."),
+	}
+}
+
+func (p *Pools) add(db *sql.DB, service, schema, database string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return errors.New("connection pools already closed")
+	}
+	p.pools = append(p.pools, trackedPool{db: db, service: service, schema: schema, database: database})
+	return nil
+}
+
+// remove stops tracking db.
+func (p *Pools) remove(db *sql.DB) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pools = slices.DeleteFunc(p.pools, func(pool trackedPool) bool { return pool.db == db })
+}
+
+// Close closes every tracked pool. Pools opened afterwards are closed right
+// away.
+func (p *Pools) Close() error {
+	p.mu.Lock()
+	pools := p.pools
+	p.pools = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	errs := make([]error, 0, len(pools))
+	for _, pool := range pools {
+		errs = append(errs, pool.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Ping pings every tracked pool concurrently and returns the outcome per
+// service, joining the errors of services with several databases.
+func (p *Pools) Ping(ctx context.Context) map[string]error {
+	p.mu.Lock()
+	pools := slices.Clone(p.pools)
+	p.mu.Unlock()
+
+	errs := make([]error, len(pools))
+	var wg sync.WaitGroup
+	for i, pool := range pools {
+		wg.Go(func() {
+			if err := pool.db.PingContext(ctx); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", pool.database, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	result := make(map[string]error)
+	for i, pool := range pools {
+		result[pool.service] = errors.Join(result[pool.service], errs[i])
+	}
+	return result
+}
+
+func (p *Pools) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.maxOpenDesc
+	ch <- p.openDesc
+	ch <- p.inUseDesc
+	ch <- p.idleDesc
+	ch <- p.waitCountDesc
+	ch <- p.waitDurationDesc
+	ch <- p.maxIdleClosedDesc
+	ch <- p.maxIdleTimeClosedDesc
+	ch <- p.maxLifetimeClosedDesc
+}
+
+func (p *Pools) Collect(ch chan<- prometheus.Metric) {
+	type key struct{ service, database string }
+
+	p.mu.Lock()
+	var keys []key
+	stats := make(map[key]sql.DBStats)
+	for _, pool := range p.pools {
+		k := key{pool.service, pool.database}
+		s, ok := stats[k]
+		if !ok {
+			keys = append(keys, k)
+		}
+		stats[k] = addStats(s, pool.db.Stats())
+	}
+	p.mu.Unlock()
+
+	for _, k := range keys {
+		s := stats[k]
+		ch <- prometheus.MustNewConstMetric(p.maxOpenDesc, prometheus.GaugeValue, float64(s.MaxOpenConnections), k.service, k.database)
+		ch <- prometheus.MustNewConstMetric(p.openDesc, prometheus.GaugeValue, float64(s.OpenConnections), k.service, k.database)
+		ch <- prometheus.MustNewConstMetric(p.inUseDesc, prometheus.GaugeValue, float64(s.InUse), k.service, k.database)
+		ch <- prometheus.MustNewConstMetric(p.idleDesc, prometheus.GaugeValue, float64(s.Idle), k.service, k.database)
+		ch <- prometheus.MustNewConstMetric(p.waitCountDesc, prometheus.CounterValue, float64(s.WaitCount), k.service, k.database)
+		ch <- prometheus.MustNewConstMetric(p.waitDurationDesc, prometheus.CounterValue, s.WaitDuration.Seconds(), k.service, k.database)
+		ch <- prometheus.MustNewConstMetric(p.maxIdleClosedDesc, prometheus.CounterValue, float64(s.MaxIdleClosed), k.service, k.database)
+		ch <- prometheus.MustNewConstMetric(p.maxIdleTimeClosedDesc, prometheus.CounterValue, float64(s.MaxIdleTimeClosed), k.service, k.database)
+		ch <- prometheus.MustNewConstMetric(p.maxLifetimeClosedDesc, prometheus.CounterValue, float64(s.MaxLifetimeClosed), k.service, k.database)
+	}
+}
+
+func addStats(a, b sql.DBStats) sql.DBStats {
+	return sql.DBStats{
+		MaxOpenConnections: a.MaxOpenConnections + b.MaxOpenConnections,
+		OpenConnections:    a.OpenConnections + b.OpenConnections,
+		InUse:              a.InUse + b.InUse,
+		Idle:               a.Idle + b.Idle,
+		WaitCount:          a.WaitCount + b.WaitCount,
+		WaitDuration:       a.WaitDuration + b.WaitDuration,
+		MaxIdleClosed:      a.MaxIdleClosed + b.MaxIdleClosed,
+		MaxIdleTimeClosed:  a.MaxIdleTimeClosed + b.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  a.MaxLifetimeClosed + b.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// snapshotTxOptions begin the transactions of a Snapshot. PostgreSQL runs
+// them as REPEATABLE READ, whose snapshot is taken by the first query; on
+// MySQL snapshotConnector starts them WITH CONSISTENT SNAPSHOT.
+var snapshotTxOptions = &sql.TxOptions{Isolation: sql.LevelSnapshot, ReadOnly: true}
+
+// Snapshot gives the queries of one collection of a service a consistent
+// view of its databases, by running them in one read-only transaction per
+// database. Rows changing while the collection runs are seen as they were
+// when it started, so that e.g. the ports of a network never outnumber its
+// addresses.
+type Snapshot struct {
+	// txs holds the transactions by the schema of their database.
+	txs map[string]*sql.Tx
+}
+
+// Snapshot begins a snapshot of the databases of service. The transactions
+// last until s is closed or ctx is done. A database that was connected to
+// more than once, e.g. after a failed connection attempt, is read through
+// the pool opened last.
+func (p *Pools) Snapshot(ctx context.Context, service string) (*Snapshot, error) {
+	p.mu.Lock()
+	pools := make(map[string]*sql.DB)
+	for _, pool := range p.pools {
+		if pool.service == service {
+			pools[pool.schema] = pool.db
+		}
+	}
+	p.mu.Unlock()
+
+	s := &Snapshot{txs: make(map[string]*sql.Tx, len(pools))}
+	for schema, db := range pools {
+		tx, err := db.BeginTx(ctx, snapshotTxOptions)
+		if err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("%s: %w", schema, err)
+		}
+		s.txs[schema] = tx
+	}
+	return s, nil
+}
+
+// Close ends the transactions of the snapshot. As they are read-only, they
+// are rolled back.
+func (s *Snapshot) Close() error {
+	errs := make([]error, 0, len(s.txs))
+	for _, schema := range slices.Sorted(maps.Keys(s.txs)) {
+		if err := s.txs[schema].Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			errs = append(errs, fmt.Errorf("%s: %w", schema, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type snapshotKey struct{}
+
+// WithSnapshot returns a context under which InSnapshot binds queries to the
+// transactions of s.
+func WithSnapshot(ctx context.Context, s *Snapshot) context.Context {
+	return context.WithValue(ctx, snapshotKey{}, s)
+}
+
+// InSnapshot returns the sqlc queries q of schema bound to the transaction
+// of the snapshot carried by ctx, or q itself outside of a snapshot. A
+// transaction runs one query at a time, so the collectors sharing a snapshot
+// must not query concurrently.
+func InSnapshot[Q interface{ WithTx(*sql.Tx) Q }](ctx context.Context, schema string, q Q) Q {
+	s, ok := ctx.Value(snapshotKey{}).(*Snapshot)
+	if !ok {
+		return q
+	}
+	tx, ok := s.txs[schema]
+	if !ok {
+		return q
+	}
+	return q.WithTx(tx)
+}
+
+// snapshotConnector begins the transactions of a Snapshot on MySQL, whose
+// driver does not support sql.LevelSnapshot, with START TRANSACTION WITH
+// CONSISTENT SNAPSHOT. Unlike a plain REPEATABLE READ transaction, whose
+// snapshot is only taken by its first query, every table is then read as it
+// was when the transaction began.
+type snapshotConnector struct {
+	driver.Connector
+}
+
+func (c *snapshotConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotConn{Conn: conn}, nil
+}
+
+// snapshotConn wraps a driver connection, forwarding the optional driver
+// interfaces that database/sql relies on.
+type snapshotConn struct {
+	driver.Conn
+}
+
+func (c *snapshotConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if sql.IsolationLevel(opts.Isolation) != sql.LevelSnapshot {
+		if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+			return beginner.BeginTx(ctx, opts)
+		}
+		return nil, errors.New("driver connection does not support BeginTx")
+	}
+
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, errors.New("driver connection does not support ExecContext")
+	}
+	start := "START TRANSACTION WITH CONSISTENT SNAPSHOT"
+	if opts.ReadOnly {
+		start += ", READ ONLY"
+	}
+	// A consistent snapshot is only taken at the REPEATABLE READ level,
+	// which the server may not default to.
+	for _, query := range []string{"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ", start} {
+		if _, err := execer.ExecContext(ctx, query, nil); err != nil {
+			return nil, err
+		}
+	}
+	return &snapshotTx{conn: execer}, nil
+}
+
+func (c *snapshotConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *snapshotConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *snapshotConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *snapshotConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *snapshotConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *snapshotConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *snapshotConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// snapshotTx ends a transaction begun by snapshotConn.
+type snapshotTx struct {
+	conn driver.ExecerContext
+}
+
+func (tx *snapshotTx) Commit() error {
+	_, err := tx.conn.ExecContext(context.Background(), "COMMIT", nil)
+	return err
+}
+
+func (tx *snapshotTx) Rollback() error {
+	_, err := tx.conn.ExecContext(context.Background(), "ROLLBACK", nil)
+	return err
+}
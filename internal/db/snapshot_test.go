@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txQueries stands in for the Queries type generated by sqlc.
+type txQueries struct {
+	tx *sql.Tx
+}
+
+func (q *txQueries) WithTx(tx *sql.Tx) *txQueries {
+	return &txQueries{tx: tx}
+}
+
+func TestPools_Snapshot(t *testing.T) {
+	pools := NewPools("openstack")
+	defer func() { _ = pools.Close() }()
+
+	mocks := make(map[string]sqlmock.Sqlmock)
+	for _, schema := range []string{"nova", "nova_api"} {
+		conn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		require.NoError(t, pools.add(conn, "nova", schema, schema))
+		mocks[schema] = mock
+	}
+	other, _, err := sqlmock.New()
+	require.NoError(t, err)
+	require.NoError(t, pools.add(other, "cinder", "cinder", "cinder"))
+
+	snapshot, err := pools.Snapshot(context.Background(), "nova")
+	require.NoError(t, err)
+	require.Len(t, snapshot.txs, 2)
+
+	q := &txQueries{}
+	assert.Same(t, q, InSnapshot(context.Background(), "nova", q))
+
+	ctx := WithSnapshot(context.Background(), snapshot)
+	assert.Same(t, snapshot.txs["nova"], InSnapshot(ctx, "nova", q).tx)
+	assert.Same(t, snapshot.txs["nova_api"], InSnapshot(ctx, "nova_api", q).tx)
+	assert.Same(t, q, InSnapshot(ctx, "cinder", q))
+
+	require.NoError(t, snapshot.Close())
+	for schema, mock := range mocks {
+		assert.NoError(t, mock.ExpectationsWereMet(), schema)
+	}
+}
+
+func TestPools_SnapshotBeginFails(t *testing.T) {
+	pools := NewPools("openstack")
+	defer func() { _ = pools.Close() }()
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+	require.NoError(t, pools.add(conn, "keystone", "keystone", "keystone"))
+
+	_, err = pools.Snapshot(context.Background(), "keystone")
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.ErrorContains(t, err, "keystone: ")
+}
+
+func TestSnapshotConn_BeginTx(t *testing.T) {
+	dsn := "snapshot_" + t.Name()
+	mockDB, mock, err := sqlmock.NewWithDSN(dsn)
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	conn := sql.OpenDB(&snapshotConnector{Connector: dsnConnector{dsn: dsn, driver: mockDB.Driver()}})
+	defer func() { _ = conn.Close() }()
+
+	mock.ExpectExec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id FROM instances").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("a"))
+	mock.ExpectExec("ROLLBACK").WillReturnResult(sqlmock.NewResult(0, 0))
+	// Other transactions are begun by the driver.
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	tx, err := conn.BeginTx(context.Background(), snapshotTxOptions)
+	require.NoError(t, err)
+	var id string
+	require.NoError(t, tx.QueryRowContext(context.Background(), "SELECT id FROM instances").Scan(&id))
+	assert.Equal(t, "a", id)
+	require.NoError(t, tx.Rollback())
+
+	tx, err = conn.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
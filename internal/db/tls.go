@@ -0,0 +1,200 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tlsFiles holds the oslo.db/PyMySQL SSL parameters of a connection string.
+type tlsFiles struct {
+	ca         string
+	cert       string
+	key        string
+	verify     bool
+	serverName string
+}
+
+// parseTLSParams removes the ssl_* parameters from query. It returns nil if
+// none of ssl_ca, ssl_cert, ssl_key and ssl_verify_cert is set.
+//
+// As with PyMySQL, the server certificate is verified when ssl_ca is set
+// unless ssl_verify_cert is false. Verification also checks that the
+// certificate is valid for host unless ssl_check_hostname is false.
+func parseTLSParams(query url.Values, host string) (*tlsFiles, error) {
+	checkHostname := true
+	if v := query.Get("ssl_check_hostname"); v != "" {
+		check, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssl_check_hostname value %q", v)
+		}
+		checkHostname = check
+	}
+	query.Del("ssl_check_hostname")
+
+	if !query.Has("ssl_ca") && !query.Has("ssl_cert") && !query.Has("ssl_key") && !query.Has("ssl_verify_cert") {
+		return nil, nil
+	}
+
+	files := &tlsFiles{
+		ca:   query.Get("ssl_ca"),
+		cert: query.Get("ssl_cert"),
+		key:  query.Get("ssl_key"),
+	}
+	if checkHostname {
+		files.serverName = host
+	}
+	files.verify = files.ca != ""
+	if v := query.Get("ssl_verify_cert"); v != "" {
+		verify, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssl_verify_cert value %q", v)
+		}
+		files.verify = verify
+	}
+	for _, param := range []string{"ssl_ca", "ssl_cert", "ssl_key", "ssl_verify_cert"} {
+		query.Del(param)
+	}
+
+	if (files.cert == "") != (files.key == "") {
+		return nil, errors.New("ssl_cert and ssl_key must be set together")
+	}
+	return files, nil
+}
+
+// config builds a TLS configuration that re-reads the certificate files
+// whenever they change on disk, so that rotated certificates are picked up by
+// new connections without restarting the exporter.
+func (f *tlsFiles) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The server certificate is verified in VerifyConnection against
+		// the current CA file instead.
+		InsecureSkipVerify: true,
+	}
+
+	if f.cert != "" {
+		keyPair := newFileCache(func() (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(f.cert, f.key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			return &cert, nil
+		}, f.cert, f.key)
+		if _, err := keyPair.load(); err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return keyPair.load()
+		}
+	}
+
+	if f.verify {
+		roots := newFileCache(func() (*x509.CertPool, error) { return nil, nil })
+		if f.ca != "" {
+			roots = newFileCache(func() (*x509.CertPool, error) {
+				pem, err := os.ReadFile(f.ca)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+				}
+				pool := x509.NewCertPool()
+				if !pool.AppendCertsFromPEM(pem) {
+					return nil, fmt.Errorf("no certificates found in %s", f.ca)
+				}
+				return pool, nil
+			}, f.ca)
+		}
+		if _, err := roots.load(); err != nil {
+			return nil, err
+		}
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			pool, err := roots.load()
+			if err != nil {
+				return err
+			}
+			return verifyPeer(cs, pool, f.serverName)
+		}
+	}
+
+	return cfg, nil
+}
+
+// verifyPeer verifies the server certificate chain of cs against roots, or
+// the system roots if nil.
+func verifyPeer(cs tls.ConnectionState, roots *x509.CertPool, serverName string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server presented no certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       serverName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// fileCache holds a value parsed from files and parses them again once any
+// of them is modified. If parsing fails, the last good value is kept.
+type fileCache[T any] struct {
+	parse func() (T, error)
+	paths []string
+
+	mu     sync.Mutex
+	stamps []time.Time
+	value  T
+	loaded bool
+}
+
+func newFileCache[T any](parse func() (T, error), paths ...string) *fileCache[T] {
+	return &fileCache[T]{parse: parse, paths: paths}
+}
+
+func (c *fileCache[T]) load() (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stamps := make([]time.Time, len(c.paths))
+	for i, path := range c.paths {
+		if info, err := os.Stat(path); err == nil {
+			stamps[i] = info.ModTime()
+		}
+	}
+	if c.loaded && equalStamps(stamps, c.stamps) {
+		return c.value, nil
+	}
+
+	value, err := c.parse()
+	if err != nil {
+		if c.loaded {
+			// A rotation may be in progress, e.g. the key was replaced but
+			// the certificate not yet.
+			return c.value, nil
+		}
+		return value, err
+	}
+	c.value, c.stamps, c.loaded = value, stamps, true
+	return value, nil
+}
+
+func equalStamps(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "mysql URL",
+			input:    "mysql://nova:s3cret@db:3306/nova",
+			expected: "mysql://nova:xxxxx@db:3306/nova",
+		},
+		{
+			name:     "mysql+pymysql URL",
+			input:    "mysql+pymysql://nova:s3cret@db/nova?charset=utf8",
+			expected: "mysql+pymysql://nova:xxxxx@db/nova?charset=utf8",
+		},
+		{
+			name:     "mysql+mysqldb URL",
+			input:    "mysql+mysqldb://nova:s3cret@db/nova",
+			expected: "mysql+mysqldb://nova:xxxxx@db/nova",
+		},
+		{
+			name:     "mysql+mysqlconnector URL",
+			input:    "mysql+mysqlconnector://nova:s3cret@db/nova",
+			expected: "mysql+mysqlconnector://nova:xxxxx@db/nova",
+		},
+		{
+			name:     "postgresql URL",
+			input:    "postgresql+psycopg2://nova:s3cret@db:5432/nova",
+			expected: "postgresql+psycopg2://nova:xxxxx@db:5432/nova",
+		},
+		{
+			name:     "escaped password",
+			input:    "mysql://nova:p%40ss%2Fw0rd@db/nova",
+			expected: "mysql://nova:xxxxx@db/nova",
+		},
+		{
+			name:     "unescaped @ in password",
+			input:    "mysql://nova:p@ss@db/nova",
+			expected: "mysql://nova:xxxxx@db/nova",
+		},
+		{
+			name:     "unix socket URL",
+			input:    "mysql+pymysql://nova:s3cret@/nova?unix_socket=/var/run/mysqld/mysqld.sock",
+			expected: "mysql+pymysql://nova:xxxxx@/nova?unix_socket=/var/run/mysqld/mysqld.sock",
+		},
+		{
+			name:     "empty password",
+			input:    "mysql://nova:@db/nova",
+			expected: "mysql://nova:xxxxx@db/nova",
+		},
+		{
+			name:     "URL without password",
+			input:    "mysql://nova@db/nova",
+			expected: "mysql://nova@db/nova",
+		},
+		{
+			name:     "URL without credentials",
+			input:    "mysql://db:3306/nova",
+			expected: "mysql://db:3306/nova",
+		},
+		{
+			name:     "URL inside an error",
+			input:    `parse "mysql+pymysql://nova:s3cret@db/nova": invalid port`,
+			expected: `parse "mysql+pymysql://nova:xxxxx@db/nova": invalid port`,
+		},
+		{
+			name:     "Go DSN over TCP",
+			input:    "nova:s3cret@tcp(db:3306)/nova?parseTime=true",
+			expected: "nova:xxxxx@tcp(db:3306)/nova?parseTime=true",
+		},
+		{
+			name:     "Go DSN over a unix socket",
+			input:    "dsn nova:s3cret@unix(/var/run/mysqld/mysqld.sock)/nova",
+			expected: "dsn nova:xxxxx@unix(/var/run/mysqld/mysqld.sock)/nova",
+		},
+		{
+			name:     "Go DSN without address",
+			input:    "nova:s3cret@/nova",
+			expected: "nova:xxxxx@/nova",
+		},
+		{
+			name:     "password parameter",
+			input:    "host=db user=nova password=s3cret dbname=nova",
+			expected: "host=db user=nova password=xxxxx dbname=nova",
+		},
+		{
+			name:     "password query parameter",
+			input:    "postgresql://db/nova?user=nova&password=s3cret&sslmode=disable",
+			expected: "postgresql://db/nova?user=nova&password=xxxxx&sslmode=disable",
+		},
+		{
+			name:     "unrelated text",
+			input:    "Error 1045 (28000): Access denied for user 'nova'@'10.0.0.1' (using password: YES)",
+			expected: "Error 1045 (28000): Access denied for user 'nova'@'10.0.0.1' (using password: YES)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, String(tt.input))
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	require.NoError(t, Error(nil))
+
+	base := errors.New("connection refused")
+	err := Error(fmt.Errorf("connecting to mysql://nova:s3cret@db/nova: %w", base))
+	assert.Equal(t, "connecting to mysql://nova:xxxxx@db/nova: connection refused", err.Error())
+	assert.ErrorIs(t, err, base)
+	assert.Same(t, err, Error(err))
+}
+
+func TestPanic(t *testing.T) {
+	err := func() (err error) {
+		defer func() { err = Panic(recover()) }()
+		panic("cannot open mysql://nova:s3cret@db/nova")
+	}()
+	assert.Equal(t, "panic: cannot open mysql://nova:xxxxx@db/nova", err.Error())
+}
+
+type dsn string
+
+func (d dsn) String() string { return string(d) }
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, nil)))
+
+	logger.With("url", "mysql://nova:with@db/nova").WithGroup("db").Error(
+		"failed to open mysql://nova:message@db/nova",
+		"error", errors.New("dial nova:error@tcp(db:3306)/nova"),
+		"stringer", dsn("mysql://nova:stringer@db/nova"),
+		slog.Group("config", "url", "mysql://nova:group@db/nova"),
+		"any", struct{ URL string }{"mysql://nova:any@db/nova"},
+		"count", 3,
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"with", "message", "error", "stringer", "group", "any"} {
+		assert.NotContains(t, out, "nova:"+secret+"@", secret)
+	}
+	assert.Equal(t, 6, strings.Count(out, Placeholder), out)
+	assert.Contains(t, out, "db.count=3")
+}
@@ -0,0 +1,272 @@
+// Package relabel limits the series the exporter serves: it filters series
+// by label value, drops labels and caps the number of series of a metric, so
+// that per-object metrics such as nova_server_status do not overwhelm
+// Prometheus.
+package relabel
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Other is the value of every label of the series aggregating the overflow
+// of a series cap.
+const Other = "__other__"
+
+// Reasons for dropping series, the reason label of the dropped series
+// counter.
+const (
+	reasonFilter = "filter"
+	reasonLabels = "labels"
+	reasonCap    = "cap"
+)
+
+// Rule rewrites the series of the metrics whose name matches Metric. Only
+// counters, gauges and untyped metrics are rewritten, as the series of
+// histograms and summaries cannot be summed.
+//
+// DropLabels, KeepLabels and MaxSeries sum the series they merge, which is
+// only meaningful for metrics counting or sizing something, e.g.
+// nova_server_local_gb or cinder_volume_count. The sum of enum or state
+// gauges such as nova_server_status, whose value encodes a status, means
+// nothing; filter their series with KeepSeries and DropSeries instead.
+type Rule struct {
+	Metric *regexp.Regexp
+	// KeepSeries keeps only the series whose labels all match their
+	// regular expression, and DropSeries drops the series of which any
+	// label matches. A missing label has an empty value.
+	KeepSeries map[string]*regexp.Regexp
+	DropSeries map[string]*regexp.Regexp
+	// DropLabels removes the listed labels and KeepLabels all others.
+	// Series that are left with the same labels are summed.
+	DropLabels []string
+	KeepLabels []string
+	// MaxSeries, if set, caps the number of series of the metric. The
+	// series after the first MaxSeries-1 in label order are summed into a
+	// single series whose labels are all set to Other.
+	MaxSeries int
+}
+
+// Compile compiles a regular expression matching whole label values and
+// metric names, like the ones of Prometheus relabel configs.
+func Compile(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// Relabeler applies rules to gathered metric families and counts the series
+// they drop.
+type Relabeler struct {
+	rules   []Rule
+	dropped *prometheus.CounterVec
+}
+
+// New creates a relabeler applying rules in order, with its metrics under
+// the exporter subsystem of namespace.
+func New(namespace string, rules []Rule) *Relabeler {
+	return &Relabeler{
+		rules: rules,
+		dropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "exporter",
+				Name:      "relabel_dropped_series_total",
+				Help:      "Total number of series dropped by the relabel rules, because they were filtered out, merged after dropping labels or summed by a series cap.",
+			},
+			[]string{"metric", "reason"},
+		),
+	}
+}
+
+func (r *Relabeler) Describe(ch chan<- *prometheus.Desc) {
+	r.dropped.Describe(ch)
+}
+
+func (r *Relabeler) Collect(ch chan<- prometheus.Metric) {
+	r.dropped.Collect(ch)
+}
+
+// Apply returns families rewritten by the rules. The metrics of families are
+// left untouched, as they may belong to a snapshot served again by the next
+// scrape.
+func (r *Relabeler) Apply(families []*dto.MetricFamily) []*dto.MetricFamily {
+	if len(r.rules) == 0 {
+		return families
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		metrics := mf.Metric
+		for _, rule := range r.rules {
+			if !rule.Metric.MatchString(mf.GetName()) || !summable(mf.GetType()) {
+				continue
+			}
+			metrics = r.apply(rule, mf.GetName(), mf.GetType(), metrics)
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		result = append(result, &dto.MetricFamily{
+			Name:   mf.Name,
+			Help:   mf.Help,
+			Type:   mf.Type,
+			Unit:   mf.Unit,
+			Metric: metrics,
+		})
+	}
+	return result
+}
+
+// apply rewrites the series of the metric name with one rule.
+func (r *Relabeler) apply(rule Rule, name string, typ dto.MetricType, metrics []*dto.Metric) []*dto.Metric {
+	if len(rule.KeepSeries) > 0 || len(rule.DropSeries) > 0 {
+		kept := make([]*dto.Metric, 0, len(metrics))
+		for _, m := range metrics {
+			if rule.keep(m) {
+				kept = append(kept, m)
+			}
+		}
+		r.drop(name, reasonFilter, len(metrics)-len(kept))
+		metrics = kept
+	}
+
+	if len(rule.DropLabels) > 0 || len(rule.KeepLabels) > 0 {
+		merged := make([]*dto.Metric, 0, len(metrics))
+		index := make(map[string]int, len(metrics))
+		for _, m := range metrics {
+			labels := rule.labels(m.Label)
+			key := signature(labels)
+			if i, ok := index[key]; ok {
+				merged[i] = withValue(typ, merged[i].Label, value(typ, merged[i])+value(typ, m))
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, withValue(typ, labels, value(typ, m)))
+		}
+		slices.SortFunc(merged, compareLabels)
+		r.drop(name, reasonLabels, len(metrics)-len(merged))
+		metrics = merged
+	}
+
+	if rule.MaxSeries > 0 && len(metrics) > rule.MaxSeries {
+		// The series are in label order, so the same ones are kept by
+		// every scrape while the objects do not change.
+		kept := slices.Clone(metrics[:rule.MaxSeries-1])
+		var sum float64
+		for _, m := range metrics[rule.MaxSeries-1:] {
+			sum += value(typ, m)
+		}
+		kept = append(kept, withValue(typ, other(metrics[0].Label), sum))
+		slices.SortFunc(kept, compareLabels)
+		r.drop(name, reasonCap, len(metrics)-len(kept))
+		metrics = kept
+	}
+
+	return metrics
+}
+
+func (r *Relabeler) drop(name, reason string, n int) {
+	if n > 0 {
+		r.dropped.WithLabelValues(name, reason).Add(float64(n))
+	}
+}
+
+// keep tells whether the series m passes the label filters of the rule.
+func (rule Rule) keep(m *dto.Metric) bool {
+	for name, re := range rule.KeepSeries {
+		if !re.MatchString(labelValue(m.Label, name)) {
+			return false
+		}
+	}
+	for name, re := range rule.DropSeries {
+		if re.MatchString(labelValue(m.Label, name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// labels returns the labels the rule leaves of labels.
+func (rule Rule) labels(labels []*dto.LabelPair) []*dto.LabelPair {
+	return slices.DeleteFunc(slices.Clone(labels), func(l *dto.LabelPair) bool {
+		if slices.Contains(rule.DropLabels, l.GetName()) {
+			return true
+		}
+		return len(rule.KeepLabels) > 0 && !slices.Contains(rule.KeepLabels, l.GetName())
+	})
+}
+
+// other returns labels with every value set to Other.
+func other(labels []*dto.LabelPair) []*dto.LabelPair {
+	value := Other
+	result := make([]*dto.LabelPair, 0, len(labels))
+	for _, l := range labels {
+		result = append(result, &dto.LabelPair{Name: l.Name, Value: &value})
+	}
+	return result
+}
+
+func labelValue(labels []*dto.LabelPair, name string) string {
+	for _, l := range labels {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+// signature identifies a set of labels, which are sorted by name. The
+// separator never occurs in UTF-8 text.
+func signature(labels []*dto.LabelPair) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(l.GetName())
+		b.WriteByte(0xff)
+		b.WriteString(l.GetValue())
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+// compareLabels orders series by their label values like the gathered
+// series of a metric, whose labels all have the same names.
+func compareLabels(a, b *dto.Metric) int {
+	for i := range min(len(a.Label), len(b.Label)) {
+		if c := strings.Compare(a.Label[i].GetValue(), b.Label[i].GetValue()); c != 0 {
+			return c
+		}
+	}
+	return len(a.Label) - len(b.Label)
+}
+
+func summable(typ dto.MetricType) bool {
+	return typ == dto.MetricType_COUNTER || typ == dto.MetricType_GAUGE || typ == dto.MetricType_UNTYPED
+}
+
+func value(typ dto.MetricType, m *dto.Metric) float64 {
+	switch typ {
+	case dto.MetricType_COUNTER:
+		return m.GetCounter().GetValue()
+	case dto.MetricType_GAUGE:
+		return m.GetGauge().GetValue()
+	default:
+		return m.GetUntyped().GetValue()
+	}
+}
+
+// withValue returns a new series of type typ.
+func withValue(typ dto.MetricType, labels []*dto.LabelPair, v float64) *dto.Metric {
+	m := &dto.Metric{Label: labels}
+	switch typ {
+	case dto.MetricType_COUNTER:
+		m.Counter = &dto.Counter{Value: &v}
+	case dto.MetricType_GAUGE:
+		m.Gauge = &dto.Gauge{Value: &v}
+	default:
+		m.Untyped = &dto.Untyped{Value: &v}
+	}
+	return m
+}
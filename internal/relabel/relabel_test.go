@@ -0,0 +1,195 @@
+package relabel
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// servers gathers a server status gauge and a histogram of five servers.
+func servers(t *testing.T) []*dto.MetricFamily {
+	t.Helper()
+
+	status := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "server_status", Help: "server_status"}, []string{"id", "domain_id", "project_id"})
+	status.WithLabelValues("a", "default", "admin").Set(1)
+	status.WithLabelValues("b", "default", "admin").Set(2)
+	status.WithLabelValues("c", "default", "demo").Set(3)
+	status.WithLabelValues("d", "ldap", "demo").Set(4)
+	status.WithLabelValues("e", "default", "test").Set(5)
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "server_boot_seconds", Help: "server_boot_seconds", Buckets: []float64{1}}, []string{"id"})
+	duration.WithLabelValues("a").Observe(0.5)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(status, duration)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	return families
+}
+
+func mustCompile(t *testing.T, expr string) *regexp.Regexp {
+	t.Helper()
+
+	re, err := Compile(expr)
+	require.NoError(t, err)
+	return re
+}
+
+func TestRelabeler_Apply(t *testing.T) {
+	const histogram = `# HELP server_boot_seconds server_boot_seconds
+# TYPE server_boot_seconds histogram
+server_boot_seconds_bucket{id="a",le="1"} 1
+server_boot_seconds_bucket{id="a",le="+Inf"} 1
+server_boot_seconds_sum{id="a"} 0.5
+server_boot_seconds_count{id="a"} 1
+`
+
+	tests := []struct {
+		name     string
+		rules    func(t *testing.T) []Rule
+		expected string
+		dropped  string
+	}{
+		{
+			name: "series filters",
+			rules: func(t *testing.T) []Rule {
+				return []Rule{{
+					Metric:     mustCompile(t, "server_.*"),
+					KeepSeries: map[string]*regexp.Regexp{"domain_id": mustCompile(t, "default")},
+					DropSeries: map[string]*regexp.Regexp{"project_id": mustCompile(t, "test|staging")},
+				}}
+			},
+			expected: histogram + `# HELP server_status server_status
+# TYPE server_status gauge
+server_status{domain_id="default",id="a",project_id="admin"} 1
+server_status{domain_id="default",id="b",project_id="admin"} 2
+server_status{domain_id="default",id="c",project_id="demo"} 3
+`,
+			dropped: `openstack_exporter_relabel_dropped_series_total{metric="server_status",reason="filter"} 2
+`,
+		},
+		{
+			name: "dropped labels are summed",
+			rules: func(t *testing.T) []Rule {
+				return []Rule{{
+					Metric:     mustCompile(t, "server_status"),
+					DropLabels: []string{"id"},
+				}}
+			},
+			expected: histogram + `# HELP server_status server_status
+# TYPE server_status gauge
+server_status{domain_id="default",project_id="admin"} 3
+server_status{domain_id="default",project_id="demo"} 3
+server_status{domain_id="default",project_id="test"} 5
+server_status{domain_id="ldap",project_id="demo"} 4
+`,
+			dropped: `openstack_exporter_relabel_dropped_series_total{metric="server_status",reason="labels"} 1
+`,
+		},
+		{
+			name: "kept labels",
+			rules: func(t *testing.T) []Rule {
+				return []Rule{{
+					Metric:     mustCompile(t, "server_status"),
+					KeepLabels: []string{"domain_id"},
+				}}
+			},
+			expected: histogram + `# HELP server_status server_status
+# TYPE server_status gauge
+server_status{domain_id="default"} 11
+server_status{domain_id="ldap"} 4
+`,
+			dropped: `openstack_exporter_relabel_dropped_series_total{metric="server_status",reason="labels"} 3
+`,
+		},
+		{
+			name: "series cap",
+			rules: func(t *testing.T) []Rule {
+				return []Rule{{
+					Metric:    mustCompile(t, "server_status"),
+					MaxSeries: 3,
+				}}
+			},
+			expected: histogram + `# HELP server_status server_status
+# TYPE server_status gauge
+server_status{domain_id="__other__",id="__other__",project_id="__other__"} 12
+server_status{domain_id="default",id="a",project_id="admin"} 1
+server_status{domain_id="default",id="b",project_id="admin"} 2
+`,
+			dropped: `openstack_exporter_relabel_dropped_series_total{metric="server_status",reason="cap"} 2
+`,
+		},
+		{
+			name: "series cap after filtering everything",
+			rules: func(t *testing.T) []Rule {
+				return []Rule{{
+					Metric:     mustCompile(t, "server_status"),
+					KeepSeries: map[string]*regexp.Regexp{"project_id": mustCompile(t, "none")},
+					MaxSeries:  1,
+				}}
+			},
+			expected: histogram,
+			dropped: `openstack_exporter_relabel_dropped_series_total{metric="server_status",reason="filter"} 5
+`,
+		},
+		{
+			name: "histograms are left untouched",
+			rules: func(t *testing.T) []Rule {
+				return []Rule{{
+					Metric:     mustCompile(t, "server_boot_seconds"),
+					DropLabels: []string{"id"},
+					MaxSeries:  1,
+				}}
+			},
+			expected: histogram + `# HELP server_status server_status
+# TYPE server_status gauge
+server_status{domain_id="default",id="a",project_id="admin"} 1
+server_status{domain_id="default",id="b",project_id="admin"} 2
+server_status{domain_id="default",id="c",project_id="demo"} 3
+server_status{domain_id="default",id="e",project_id="test"} 5
+server_status{domain_id="ldap",id="d",project_id="demo"} 4
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			families := servers(t)
+			before := make([]*dto.MetricFamily, 0, len(families))
+			for _, mf := range families {
+				before = append(before, proto.Clone(mf).(*dto.MetricFamily))
+			}
+			relabeler := New("openstack", tt.rules(t))
+
+			gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+				return relabeler.Apply(families), nil
+			})
+			require.NoError(t, testutil.GatherAndCompare(gatherer, strings.NewReader(tt.expected)))
+
+			// The gathered families may be served again, e.g. from a
+			// poller snapshot.
+			for i, mf := range families {
+				require.True(t, proto.Equal(before[i], mf), mf.GetName())
+			}
+
+			expected := ""
+			if tt.dropped != "" {
+				expected = `# HELP openstack_exporter_relabel_dropped_series_total Total number of series dropped by the relabel rules, because they were filtered out, merged after dropping labels or summed by a series cap.
+# TYPE openstack_exporter_relabel_dropped_series_total counter
+` + tt.dropped
+			}
+			require.NoError(t, testutil.CollectAndCompare(relabeler, strings.NewReader(expected)))
+		})
+	}
+}
+
+func TestRelabeler_ApplyWithoutRules(t *testing.T) {
+	families := servers(t)
+	require.Equal(t, families, New("openstack", nil).Apply(families))
+}
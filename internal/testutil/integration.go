@@ -13,6 +13,9 @@ import (
 
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/mariadb"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
 )
 
 // containerName returns a unique name for a test container, keeping the service
@@ -197,6 +200,60 @@ func NewMySQLContainerWithURL(t *testing.T, serviceName string, schemaFiles ...s
 	return MySQLContainerResult{DB: db, URL: osloURL, container: container}
 }
 
+// NewPostgreSQLContainer starts a named PostgreSQL container, applies the given
+// schema files in order, and returns a *sql.DB connection opened like the
+// exporter does, so that the sqlc queries of schema run in their PostgreSQL
+// version. The container is cleaned up automatically when the test ends.
+func NewPostgreSQLContainer(t *testing.T, schema string, schemaFiles ...string) *sql.DB {
+	t.Helper()
+
+	ctx := context.Background()
+	name := containerName(schema + "-pg")
+
+	container, err := postgres.Run(ctx,
+		"postgres:17",
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		postgres.WithOrderedInitScripts(schemaFiles...),
+		postgres.BasicWaitStrategies(),
+		testcontainers.WithName(name),
+	)
+	if err != nil {
+		t.Fatalf("failed to start PostgreSQL container %s: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			t.Logf("failed to terminate PostgreSQL container %s: %v", name, err)
+		}
+	})
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("failed to get container host: %v", err)
+	}
+	mappedPort, err := container.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		t.Fatalf("failed to get mapped port: %v", err)
+	}
+	osloURL := fmt.Sprintf("postgresql+psycopg2://test:test@%s:%s/testdb?sslmode=disable", host, mappedPort.Port())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	conn, err := db.ConnectContext(ctx, db.Config{URL: osloURL, Schema: schema})
+	if err != nil {
+		t.Fatalf("failed to connect to PostgreSQL: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
+
 // SeedSQL executes raw SQL statements against the database.
 // Multiple statements can be separated by semicolons.
 func SeedSQL(t *testing.T, db *sql.DB, statements ...string) {
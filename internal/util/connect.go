@@ -0,0 +1,238 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/db"
+	"github.com/vexxhost/openstack_database_exporter/internal/redact"
+)
+
+const (
+	// ConnectTimeout bounds the connection attempts made outside of
+	// scrapes, e.g. at startup.
+	ConnectTimeout = 30 * time.Second
+
+	minConnectBackoff = time.Second
+	maxConnectBackoff = 5 * time.Minute
+)
+
+// errNotConnected fails the scrapes of a service whose database has not been
+// reached yet and that do not attempt to connect.
+var errNotConnected = errors.New("database not connected yet")
+
+// NextBackoff returns the delay before retrying a connection whose last
+// attempt was delayed by d, doubling it up to five minutes. A zero d returns
+// the initial delay of one second.
+func NextBackoff(d time.Duration) time.Duration {
+	if d <= 0 {
+		return minConnectBackoff
+	}
+	return min(d*2, maxConnectBackoff)
+}
+
+// ConnectFunc connects to the databases of a service and builds its
+// collectors.
+type ConnectFunc func(ctx context.Context) ([]prometheus.Collector, error)
+
+// RegisterWhenConnected registers the collectors built by connect. If the
+// service's database cannot be reached, the service reports _up=0 and connect
+// is retried with exponential backoff on later scrapes; once it succeeds the
+// real collectors take over. Connection failures are exported per service,
+// and the retries are collected as the service's "connect" collector. The
+// first attempt runs in the background, bounded by ConnectTimeout, so that
+// unreachable services do not hold back the others; scrapes wait for it
+// within their deadline.
+func RegisterWhenConnected(registry prometheus.Registerer, namespace, subsystem, service string, connect ConnectFunc, logger *slog.Logger) {
+	status := newConnectStatus(namespace, service)
+	registry.MustRegister(status)
+
+	c := &reconnectCollector{
+		registry:  registry,
+		service:   service,
+		connect:   connect,
+		status:    status,
+		down:      NewDownCollector(namespace, subsystem),
+		logger:    logger,
+		backoff:   minConnectBackoff,
+		connected: make(chan struct{}),
+	}
+	c.named = Named("connect", c)
+	registry.MustRegister(c.named)
+
+	go func() {
+		defer close(c.connected)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), ConnectTimeout)
+		defer cancel()
+		if _, err := c.attempt(ctx); err != nil {
+			// The next scrape retries right away.
+			logger.Error("Failed to connect to database, will retry", "service", service, "error", err)
+		}
+	}()
+}
+
+// reconnectCollector stands in for a service until its database is reached.
+type reconnectCollector struct {
+	registry prometheus.Registerer
+	// named is the collector registered in place of the service's.
+	named   *NamedCollector
+	service string
+	connect ConnectFunc
+	status  *connectStatus
+	down    prometheus.Collector
+	logger  *slog.Logger
+	// connected is closed once the first attempt is done.
+	connected chan struct{}
+
+	mu          sync.Mutex
+	backoff     time.Duration
+	nextAttempt time.Time
+	collectors  atomic.Pointer[[]prometheus.Collector]
+}
+
+// Describe sends nothing: the collector is unchecked because the metrics it
+// emits change once the database becomes reachable.
+func (c *reconnectCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *reconnectCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *reconnectCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	collectors, err := c.tryConnect(ctx)
+	if err != nil {
+		db.RecordError(ctx, err)
+		c.down.Collect(ch)
+		return
+	}
+
+	fns := make([]func(context.Context) error, 0, len(collectors))
+	for _, collector := range collectors {
+		fns = append(fns, func(ctx context.Context) error {
+			CollectContext(ctx, collector, ch)
+			return nil
+		})
+	}
+	if err := Parallel(ctx, fns...); err != nil {
+		c.logger.Error("Collector failed", "service", c.service, "error", err)
+	}
+}
+
+// tryConnect returns the service's collectors once connected, attempting a
+// connection if the backoff has elapsed. Scrapes wait for the first attempt,
+// but concurrent scrapes do not wait for a later attempt in progress.
+func (c *reconnectCollector) tryConnect(ctx context.Context) ([]prometheus.Collector, error) {
+	if c.connected != nil {
+		select {
+		case <-c.connected:
+		case <-ctx.Done():
+			return nil, errNotConnected
+		}
+	}
+	if collectors := c.collectors.Load(); collectors != nil {
+		return *collectors, nil
+	}
+
+	if !c.mu.TryLock() {
+		return nil, errNotConnected
+	}
+	defer c.mu.Unlock()
+
+	if time.Now().Before(c.nextAttempt) {
+		return nil, errNotConnected
+	}
+
+	collectors, err := c.attempt(ctx)
+	if err != nil {
+		c.nextAttempt = time.Now().Add(c.backoff)
+		c.logger.Warn("Database still unreachable", "service", c.service, "error", err, "retry_in", c.backoff)
+		c.backoff = NextBackoff(c.backoff)
+		return nil, err
+	}
+	return collectors, nil
+}
+
+// attempt connects to the database and swaps in the service's collectors.
+// c.mu must be held.
+func (c *reconnectCollector) attempt(ctx context.Context) ([]prometheus.Collector, error) {
+	collectors, err := c.connect(ctx)
+	if err != nil {
+		c.status.fail(err)
+		return nil, err
+	}
+
+	// Later scrapes use the real collectors directly. Registries that
+	// cannot unregister unchecked collectors keep collecting through c.
+	if c.registry.Unregister(c.named) {
+		for _, collector := range collectors {
+			if err := c.registry.Register(collector); err != nil {
+				c.logger.Error("Failed to register collector after connecting", "service", c.service, "error", err)
+			}
+		}
+	}
+	c.collectors.Store(&collectors)
+
+	c.logger.Info("Connected to database, registered collectors", "service", c.service)
+	return collectors, nil
+}
+
+// connectStatus exports the connection failures of a service.
+type connectStatus struct {
+	failuresDesc  *prometheus.Desc
+	lastErrorDesc *prometheus.Desc
+
+	mu        sync.Mutex
+	failures  float64
+	lastError string
+}
+
+func newConnectStatus(namespace, service string) *connectStatus {
+	labels := prometheus.Labels{"service": service}
+	return &connectStatus{
+		failuresDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exporter", "connect_failures_total"),
+			"Total number of failed attempts to connect to the service's database.",
+			nil,
+			labels,
+		),
+		lastErrorDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exporter", "last_connect_error_info"),
+			"The last error returned while connecting to the service's database.",
+			[]string{"error"},
+			labels,
+		),
+	}
+}
+
+func (s *connectStatus) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures++
+	s.lastError = redact.String(err.Error())
+}
+
+func (s *connectStatus) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.failuresDesc
+	ch <- s.lastErrorDesc
+}
+
+func (s *connectStatus) Collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(s.failuresDesc, prometheus.CounterValue, s.failures)
+	if s.lastError != "" {
+		ch <- prometheus.MustNewConstMetric(s.lastErrorDesc, prometheus.GaugeValue, 1, s.lastError)
+	}
+}
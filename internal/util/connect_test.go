@@ -0,0 +1,161 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyConnect fails until ready is set and counts its attempts.
+type flakyConnect struct {
+	ready    bool
+	attempts int
+}
+
+func (f *flakyConnect) connect(context.Context) ([]prometheus.Collector, error) {
+	f.attempts++
+	if !f.ready {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "openstack", Subsystem: "test", Name: "up", Help: "up"})
+	up.Set(1)
+	return []prometheus.Collector{up}, nil
+}
+
+func TestRegisterWhenConnected_Connected(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	f := &flakyConnect{ready: true}
+	RegisterWhenConnected(reg, "openstack", "test", "test", f.connect, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`# HELP openstack_exporter_connect_failures_total Total number of failed attempts to connect to the service's database.
+# TYPE openstack_exporter_connect_failures_total counter
+openstack_exporter_connect_failures_total{service="test"} 0
+# HELP openstack_test_up up
+# TYPE openstack_test_up gauge
+openstack_test_up 1
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.attempts)
+}
+
+func TestRegisterWhenConnected_Reconnects(t *testing.T) {
+	reg := &recordingRegistry{Registry: prometheus.NewRegistry()}
+	f := &flakyConnect{}
+	RegisterWhenConnected(reg, "openstack", "test", "test", f.connect, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	down := `# HELP openstack_exporter_connect_failures_total Total number of failed attempts to connect to the service's database.
+# TYPE openstack_exporter_connect_failures_total counter
+openstack_exporter_connect_failures_total{service="test"} 2
+# HELP openstack_exporter_last_connect_error_info The last error returned while connecting to the service's database.
+# TYPE openstack_exporter_last_connect_error_info gauge
+openstack_exporter_last_connect_error_info{error="dial tcp: connection refused",service="test"} 1
+# HELP openstack_test_up up
+# TYPE openstack_test_up gauge
+openstack_test_up 0
+`
+	// The first scrape retries immediately, the second is within the backoff.
+	// The status is collected concurrently with the retry, so it is only
+	// checked on the second scrape.
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(down), "openstack_test_up"))
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(down)))
+	assert.Equal(t, 2, f.attempts)
+
+	f.ready = true
+	rc := reg.reconnectCollector(t)
+	rc.mu.Lock()
+	rc.nextAttempt = time.Time{}
+	rc.mu.Unlock()
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`# HELP openstack_test_up up
+# TYPE openstack_test_up gauge
+openstack_test_up 1
+`), "openstack_test_up")
+	require.NoError(t, err)
+	assert.Equal(t, 3, f.attempts)
+
+	// Once connected, the collectors are reused without reconnecting.
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`# HELP openstack_test_up up
+# TYPE openstack_test_up gauge
+openstack_test_up 1
+`), "openstack_test_up"))
+	assert.Equal(t, 3, f.attempts)
+}
+
+func TestReconnectCollector_Backoff(t *testing.T) {
+	f := &flakyConnect{}
+	c := &reconnectCollector{
+		registry: prometheus.NewRegistry(),
+		service:  "test",
+		connect:  f.connect,
+		status:   newConnectStatus("openstack", "test"),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		backoff:  minConnectBackoff,
+	}
+
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second} {
+		c.nextAttempt = time.Time{}
+		collectors, err := c.tryConnect(context.Background())
+		assert.Nil(t, collectors)
+		assert.Error(t, err)
+		assert.Equal(t, want, c.backoff)
+	}
+
+	c.backoff = maxConnectBackoff
+	c.nextAttempt = time.Time{}
+	_, _ = c.tryConnect(context.Background())
+	assert.Equal(t, maxConnectBackoff, c.backoff)
+}
+
+// recordingRegistry remembers the collectors registered with it.
+type recordingRegistry struct {
+	*prometheus.Registry
+	collectors []prometheus.Collector
+}
+
+func (r *recordingRegistry) Register(c prometheus.Collector) error {
+	r.collectors = append(r.collectors, c)
+	return r.Registry.Register(c)
+}
+
+func (r *recordingRegistry) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r *recordingRegistry) reconnectCollector(t *testing.T) *reconnectCollector {
+	t.Helper()
+
+	for _, c := range r.collectors {
+		if named, ok := c.(*NamedCollector); ok {
+			if rc, ok := named.Collector.(*reconnectCollector); ok {
+				return rc
+			}
+		}
+	}
+	t.Fatal("no reconnect collector registered")
+	return nil
+}
+
+func TestConnectStatus_RedactsError(t *testing.T) {
+	status := newConnectStatus("openstack", "test")
+	status.fail(errors.New(`parse "mysql://nova:s3cret@db:port/nova": invalid port ":port" after host`))
+
+	err := testutil.CollectAndCompare(status, strings.NewReader(`# HELP openstack_exporter_last_connect_error_info The last error returned while connecting to the service's database.
+# TYPE openstack_exporter_last_connect_error_info gauge
+openstack_exporter_last_connect_error_info{error="parse \"mysql://nova:xxxxx@db:port/nova\": invalid port \":port\" after host",service="test"} 1
+`), "openstack_exporter_last_connect_error_info")
+	require.NoError(t, err)
+}
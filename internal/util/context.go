@@ -0,0 +1,40 @@
+package util
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ContextCollector is implemented by collectors that run their database
+// queries under a caller-supplied context.
+type ContextCollector interface {
+	prometheus.Collector
+	CollectContext(ctx context.Context, ch chan<- prometheus.Metric)
+}
+
+// CollectContext collects c under ctx if it is a ContextCollector, and falls
+// back to its plain Collect otherwise.
+func CollectContext(ctx context.Context, c prometheus.Collector, ch chan<- prometheus.Metric) {
+	if cc, ok := c.(ContextCollector); ok {
+		cc.CollectContext(ctx, ch)
+		return
+	}
+	c.Collect(ch)
+}
+
+// NamedCollector is a collector that is known under a name within its
+// service, which labels the exporter's own collector metrics.
+type NamedCollector struct {
+	prometheus.Collector
+	Name string
+}
+
+// Named attaches name to c.
+func Named(name string, c prometheus.Collector) *NamedCollector {
+	return &NamedCollector{Collector: c, Name: name}
+}
+
+func (c *NamedCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	CollectContext(ctx, c.Collector, ch)
+}
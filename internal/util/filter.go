@@ -0,0 +1,34 @@
+package util
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// CollectorFilter reports whether the named collector of a service is
+// enabled. A nil filter enables every collector.
+type CollectorFilter func(name string) bool
+
+// Enabled reports whether the named collector is enabled.
+func (f CollectorFilter) Enabled(name string) bool {
+	return f == nil || f(name)
+}
+
+// Any reports whether at least one of the named collectors is enabled.
+func (f CollectorFilter) Any(names []string) bool {
+	for _, name := range names {
+		if f.Enabled(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the enabled collectors out of collectors, which are keyed
+// by name. The returned collectors are Named.
+func (f CollectorFilter) Select(collectors map[string]prometheus.Collector) []prometheus.Collector {
+	enabled := make([]prometheus.Collector, 0, len(collectors))
+	for name, c := range collectors {
+		if f.Enabled(name) {
+			enabled = append(enabled, Named(name, c))
+		}
+	}
+	return enabled
+}
@@ -0,0 +1,24 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorFilter(t *testing.T) {
+	agents := NewDownCollector("openstack", "agents")
+	volumes := NewDownCollector("openstack", "volumes")
+	collectors := map[string]prometheus.Collector{"agents": agents, "volumes": volumes}
+
+	var all CollectorFilter
+	assert.True(t, all.Enabled("agents"))
+	assert.ElementsMatch(t, []prometheus.Collector{Named("agents", agents), Named("volumes", volumes)}, all.Select(collectors))
+
+	onlyVolumes := CollectorFilter(func(name string) bool { return name == "volumes" })
+	assert.False(t, onlyVolumes.Enabled("agents"))
+	assert.True(t, onlyVolumes.Any([]string{"agents", "volumes"}))
+	assert.False(t, onlyVolumes.Any([]string{"agents"}))
+	assert.Equal(t, []prometheus.Collector{Named("volumes", volumes)}, onlyVolumes.Select(collectors))
+}
@@ -0,0 +1,186 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/vexxhost/openstack_database_exporter/internal/redact"
+)
+
+// Scheduler bounds how many collections run at the same time, both across
+// the whole exporter and per service. The per-service limit is meant to match
+// the service's connection pool size, so that a collection never holds a slot
+// while it waits for a free database connection.
+type Scheduler struct {
+	global chan struct{}
+
+	mu       sync.Mutex
+	services map[string]chan struct{}
+}
+
+// NewScheduler creates a scheduler that runs at most parallelism collections
+// at once. A parallelism of zero or less means unlimited.
+func NewScheduler(parallelism int) *Scheduler {
+	s := &Scheduler{
+		services: make(map[string]chan struct{}),
+	}
+	if parallelism > 0 {
+		s.global = make(chan struct{}, parallelism)
+	}
+	return s
+}
+
+// SetServiceLimit limits the named service to at most limit concurrent
+// collections. A limit of zero or less removes the limit.
+func (s *Scheduler) SetServiceLimit(service string, limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit <= 0 {
+		delete(s.services, service)
+		return
+	}
+	s.services[service] = make(chan struct{}, limit)
+}
+
+// Run runs fn for the given service once a slot is available. The context
+// passed to fn carries the slot, so that Parallel can hand it over to
+// sub-collectors. If ctx is done before a slot frees up, fn still runs so it
+// can fail fast and report itself as down.
+func (s *Scheduler) Run(ctx context.Context, service string, fn func(context.Context)) {
+	sl := &slot{scheduler: s, service: service}
+	if err := sl.acquire(ctx); err == nil {
+		defer sl.release()
+	}
+
+	fn(context.WithValue(ctx, slotKey{}, sl))
+}
+
+func (s *Scheduler) serviceSem(service string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.services[service]
+}
+
+type serialKey struct{}
+
+// Serial returns a context under which Run runs one fn at a time, including
+// the fns of Parallel, e.g. because they share a database transaction.
+func Serial(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serialKey{}, make(chan struct{}, 1))
+}
+
+type slotKey struct{}
+
+// slot is the right to run one collection for a service.
+type slot struct {
+	scheduler *Scheduler
+	service   string
+	serial    chan struct{}
+	sem       chan struct{}
+	held      bool
+}
+
+func (sl *slot) acquire(ctx context.Context) error {
+	// The turn of a serial context is taken first, so that waiting for
+	// it never holds a slot that other services could use.
+	serial, _ := ctx.Value(serialKey{}).(chan struct{})
+	if serial != nil {
+		select {
+		case serial <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	sem := sl.scheduler.serviceSem(sl.service)
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			if serial != nil {
+				<-serial
+			}
+			return ctx.Err()
+		}
+	}
+
+	if sl.scheduler.global != nil {
+		select {
+		case sl.scheduler.global <- struct{}{}:
+		case <-ctx.Done():
+			if sem != nil {
+				<-sem
+			}
+			if serial != nil {
+				<-serial
+			}
+			return ctx.Err()
+		}
+	}
+
+	sl.serial = serial
+	sl.sem = sem
+	sl.held = true
+	return nil
+}
+
+func (sl *slot) release() {
+	if !sl.held {
+		return
+	}
+	sl.held = false
+
+	if sl.scheduler.global != nil {
+		<-sl.scheduler.global
+	}
+	if sl.sem != nil {
+		<-sl.sem
+	}
+	if sl.serial != nil {
+		<-sl.serial
+	}
+}
+
+// Parallel runs fns concurrently and returns their joined errors. A panicking
+// fn fails with the redacted panic value instead of crashing. When ctx
+// carries a Scheduler slot, the caller's slot is released while the fns run
+// and each fn gets a slot of its own, so nesting never deadlocks. Without a
+// scheduler the fns all run at once.
+func Parallel(ctx context.Context, fns ...func(context.Context) error) error {
+	parent, _ := ctx.Value(slotKey{}).(*slot)
+	if parent != nil && parent.held {
+		parent.release()
+		defer func() {
+			_ = parent.acquire(ctx)
+		}()
+	}
+
+	errs := make([]error, len(fns))
+
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = redact.Panic(r)
+				}
+			}()
+
+			if parent == nil {
+				errs[i] = fn(ctx)
+				return
+			}
+			parent.scheduler.Run(ctx, parent.service, func(ctx context.Context) {
+				errs[i] = fn(ctx)
+			})
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
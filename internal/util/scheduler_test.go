@@ -0,0 +1,147 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrency tracks the highest number of simultaneously running tasks.
+type concurrency struct {
+	running atomic.Int32
+	max     atomic.Int32
+}
+
+func (c *concurrency) task() {
+	n := c.running.Add(1)
+	for {
+		m := c.max.Load()
+		if n <= m || c.max.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	c.running.Add(-1)
+}
+
+func TestScheduler_GlobalLimit(t *testing.T) {
+	s := NewScheduler(2)
+
+	var c concurrency
+	var wg sync.WaitGroup
+	for _, service := range []string{"cinder", "glance", "heat", "nova", "neutron", "octavia"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Run(context.Background(), service, func(context.Context) { c.task() })
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), c.max.Load())
+}
+
+func TestScheduler_ServiceLimit(t *testing.T) {
+	s := NewScheduler(0)
+	s.SetServiceLimit("nova", 1)
+
+	var c concurrency
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Run(context.Background(), "nova", func(context.Context) { c.task() })
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), c.max.Load())
+}
+
+func TestScheduler_Serial(t *testing.T) {
+	s := NewScheduler(0)
+	ctx := Serial(context.Background())
+
+	var c concurrency
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			s.Run(ctx, "nova", func(ctx context.Context) {
+				assert.NoError(t, Parallel(ctx,
+					func(context.Context) error { c.task(); return nil },
+					func(context.Context) error { c.task(); return nil },
+				))
+			})
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), c.max.Load())
+}
+
+func TestScheduler_RunsWhenContextDone(t *testing.T) {
+	s := NewScheduler(1)
+	s.global <- struct{}{} // occupy the only slot
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var ran bool
+	s.Run(ctx, "cinder", func(ctx context.Context) {
+		ran = true
+		assert.Error(t, ctx.Err())
+	})
+	assert.True(t, ran)
+	assert.Len(t, s.global, 1)
+}
+
+func TestParallel_NestedDoesNotDeadlock(t *testing.T) {
+	s := NewScheduler(1)
+	s.SetServiceLimit("nova", 1)
+
+	var c concurrency
+	done := make(chan error)
+	go s.Run(context.Background(), "nova", func(ctx context.Context) {
+		done <- Parallel(ctx,
+			func(context.Context) error { c.task(); return nil },
+			func(context.Context) error { c.task(); return nil },
+			func(context.Context) error { c.task(); return nil },
+		)
+	})
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested Parallel deadlocked")
+	}
+	assert.Equal(t, int32(1), c.max.Load())
+}
+
+func TestParallel_JoinsErrors(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+
+	err := Parallel(context.Background(),
+		func(context.Context) error { return errA },
+		func(context.Context) error { return nil },
+		func(context.Context) error { return errB },
+	)
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+}
+
+func TestParallel_RecoversPanics(t *testing.T) {
+	err := Parallel(context.Background(),
+		func(context.Context) error { panic("cannot open mysql://nova:s3cret@db/nova") },
+		func(context.Context) error { return nil },
+	)
+	require.EqualError(t, err, "panic: cannot open mysql://nova:xxxxx@db/nova")
+}
@@ -0,0 +1,103 @@
+-- name: GetAllServices :many
+SELECT
+    uuid,
+    host,
+    "binary" as service,
+    CASE
+        WHEN disabled THEN 'disabled'
+        ELSE 'enabled'
+    END as admin_state,
+    availability_zone as zone,
+    disabled_reason,
+    CASE
+        WHEN updated_at >= (now() AT TIME ZONE 'UTC') - INTERVAL '60 seconds' THEN 1
+        ELSE 0
+    END as state
+FROM
+    services
+WHERE
+    deleted = false;
+
+-- name: GetProjectQuotaLimits :many
+SELECT
+    q.project_id,
+    q.resource,
+    q.hard_limit,
+    COALESCE(qu.in_use, 0) as in_use
+FROM
+    quotas q
+    LEFT JOIN quota_usages qu ON q.project_id = qu.project_id
+        AND q.resource = qu.resource
+        AND qu.deleted = false
+WHERE
+    q.deleted = false
+    AND (q.resource IN ('gigabytes', 'backup_gigabytes') OR q.resource LIKE 'gigabytes\_%');
+
+-- name: GetAllProjectQuotas :many
+SELECT
+    q.project_id,
+    q.resource,
+    q.hard_limit,
+    COALESCE(qu.in_use, 0) as in_use
+FROM
+    quotas q
+    LEFT JOIN quota_usages qu ON q.project_id = qu.project_id
+        AND q.resource = qu.resource
+        AND qu.deleted = false
+WHERE
+    q.deleted = false;
+
+-- name: GetVolumeTypes :many
+SELECT
+    id,
+    name
+FROM
+    volume_types
+WHERE
+    deleted = false;
+
+-- name: GetSnapshotCount :one
+SELECT
+    COUNT(*) as count
+FROM
+    snapshots
+WHERE
+    deleted = false;
+
+-- name: GetAllVolumes :many
+SELECT
+    v.id,
+    v.display_name as name,
+    v.size,
+    v.status,
+    v.availability_zone,
+    v.bootable,
+    v.project_id,
+    v.user_id,
+    vt.name as volume_type,
+    va.instance_uuid as server_id
+FROM
+    volumes v
+    LEFT JOIN volume_types vt ON v.volume_type_id = vt.id
+    LEFT JOIN volume_attachment va ON v.id = va.volume_id AND va.deleted = false
+WHERE
+    v.deleted = false;
+
+-- name: GetVolumeAggregates :many
+SELECT
+    v.project_id,
+    v.status,
+    vt.name as volume_type,
+    v.availability_zone,
+    COUNT(*) as count,
+    CAST(COALESCE(SUM(v.size), 0) AS bigint) as size
+FROM
+    volumes v
+    LEFT JOIN volume_types vt ON v.volume_type_id = vt.id
+WHERE
+    v.deleted = false
+GROUP BY
+    v.project_id,
+    v.status,
+    vt.name,
+    v.availability_zone;
@@ -0,0 +1,154 @@
+CREATE TABLE
+    quotas (
+        id serial NOT NULL PRIMARY KEY,
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted boolean,
+        project_id varchar(255),
+        resource varchar(300) NOT NULL,
+        hard_limit integer,
+        allocated integer
+    );
+
+CREATE TABLE
+    quota_usages (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted boolean,
+        id serial NOT NULL PRIMARY KEY,
+        project_id varchar(255),
+        resource varchar(300),
+        in_use integer NOT NULL,
+        reserved integer NOT NULL,
+        until_refresh integer,
+        race_preventer boolean
+    );
+
+CREATE TABLE
+    services (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted boolean,
+        id serial NOT NULL PRIMARY KEY,
+        host varchar(255),
+        "binary" varchar(255),
+        topic varchar(255),
+        report_count integer NOT NULL,
+        disabled boolean,
+        availability_zone varchar(255),
+        disabled_reason varchar(255),
+        modified_at timestamp,
+        rpc_current_version varchar(36),
+        object_current_version varchar(36),
+        replication_status varchar(36),
+        frozen boolean,
+        active_backend_id varchar(255),
+        cluster_name varchar(255),
+        uuid varchar(36)
+    );
+
+CREATE TABLE
+    snapshots (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted boolean,
+        id varchar(36) NOT NULL PRIMARY KEY,
+        volume_id varchar(36) NOT NULL,
+        user_id varchar(255),
+        project_id varchar(255),
+        status varchar(255),
+        progress varchar(255),
+        volume_size integer,
+        scheduled_at timestamp,
+        display_name varchar(255),
+        display_description varchar(255),
+        provider_location varchar(255),
+        encryption_key_id varchar(36),
+        volume_type_id varchar(36) NOT NULL,
+        cgsnapshot_id varchar(36),
+        provider_id varchar(255),
+        provider_auth varchar(255),
+        group_snapshot_id varchar(36),
+        use_quota boolean NOT NULL DEFAULT true
+    );
+
+CREATE TABLE
+    volumes (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted boolean,
+        id varchar(36) NOT NULL PRIMARY KEY,
+        ec2_id varchar(255),
+        user_id varchar(255),
+        project_id varchar(255),
+        host varchar(255),
+        size integer,
+        availability_zone varchar(255),
+        status varchar(255),
+        attach_status varchar(255),
+        scheduled_at timestamp,
+        launched_at timestamp,
+        terminated_at timestamp,
+        display_name varchar(255),
+        display_description varchar(255),
+        provider_location varchar(256),
+        provider_auth varchar(256),
+        snapshot_id varchar(36),
+        volume_type_id varchar(36) NOT NULL,
+        source_volid varchar(36),
+        bootable boolean,
+        provider_geometry varchar(255),
+        _name_id varchar(36),
+        encryption_key_id varchar(36),
+        migration_status varchar(255),
+        replication_status varchar(255),
+        replication_extended_status varchar(255),
+        replication_driver_data varchar(255),
+        consistencygroup_id varchar(36),
+        provider_id varchar(255),
+        multiattach boolean,
+        previous_status varchar(255),
+        cluster_name varchar(255),
+        group_id varchar(36),
+        service_uuid varchar(36),
+        shared_targets boolean,
+        use_quota boolean NOT NULL DEFAULT true
+    );
+
+CREATE TABLE
+    volume_attachment (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted boolean,
+        id varchar(36) NOT NULL PRIMARY KEY,
+        volume_id varchar(36) NOT NULL,
+        attached_host varchar(255),
+        instance_uuid varchar(36),
+        mountpoint varchar(255),
+        attach_time timestamp,
+        detach_time timestamp,
+        attach_mode varchar(36),
+        attach_status varchar(255),
+        connection_info text,
+        connector text
+    );
+
+CREATE TABLE
+    volume_types (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted boolean,
+        id varchar(36) NOT NULL PRIMARY KEY,
+        name varchar(255),
+        qos_specs_id varchar(36),
+        is_public boolean,
+        description varchar(255)
+    );
+
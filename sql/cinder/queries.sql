@@ -83,3 +83,22 @@ FROM
 WHERE
     (v.service_uuid IS NULL OR v.service_uuid IS NOT NULL)
     AND v.deleted = 0;
+
+-- name: GetVolumeAggregates :many
+SELECT
+    v.project_id,
+    v.status,
+    vt.name as volume_type,
+    v.availability_zone,
+    COUNT(*) as count,
+    CAST(COALESCE(SUM(v.size), 0) AS SIGNED) as size
+FROM
+    volumes v
+    LEFT JOIN volume_types vt ON v.volume_type_id = vt.id
+WHERE
+    v.deleted = 0
+GROUP BY
+    v.project_id,
+    v.status,
+    vt.name,
+    v.availability_zone;
@@ -0,0 +1,24 @@
+-- name: GetAllImages :many
+SELECT
+    id,
+    name,
+    size,
+    status,
+    owner,
+    visibility,
+    disk_format,
+    container_format,
+    checksum,
+    created_at,
+    updated_at,
+    min_disk,
+    min_ram,
+    protected,
+    virtual_size,
+    os_hidden,
+    os_hash_algo,
+    os_hash_value
+FROM
+    images
+WHERE
+    deleted = false;
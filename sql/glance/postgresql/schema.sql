@@ -0,0 +1,27 @@
+CREATE TYPE image_visibility AS ENUM ('private', 'public', 'shared', 'community');
+
+CREATE TABLE
+    images (
+        id varchar(36) NOT NULL,
+        name varchar(255),
+        size bigint,
+        status varchar(30) NOT NULL,
+        created_at timestamp NOT NULL,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted boolean NOT NULL,
+        disk_format varchar(20),
+        container_format varchar(20),
+        checksum varchar(32),
+        owner varchar(255),
+        min_disk integer NOT NULL,
+        min_ram integer NOT NULL,
+        protected boolean NOT NULL DEFAULT false,
+        virtual_size bigint,
+        visibility image_visibility NOT NULL DEFAULT 'shared',
+        os_hidden boolean NOT NULL DEFAULT false,
+        os_hash_algo varchar(64),
+        os_hash_value varchar(128),
+        PRIMARY KEY (id)
+    );
+
@@ -0,0 +1,28 @@
+CREATE TABLE
+  stack (
+    id varchar(36) NOT NULL,
+    created_at timestamp,
+    updated_at timestamp,
+    deleted_at timestamp,
+    name varchar(255),
+    raw_template_id integer NOT NULL,
+    prev_raw_template_id integer,
+    user_creds_id integer,
+    username varchar(256),
+    owner_id varchar(36),
+    action varchar(255),
+    status varchar(255),
+    status_reason text,
+    timeout integer,
+    tenant varchar(256),
+    disable_rollback boolean NOT NULL,
+    stack_user_project_id varchar(64),
+    backup boolean,
+    nested_depth integer,
+    convergence boolean,
+    current_traversal varchar(36),
+    current_deps text,
+    parent_resource_name varchar(255),
+    PRIMARY KEY (id)
+  );
+
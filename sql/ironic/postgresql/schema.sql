@@ -0,0 +1,66 @@
+CREATE TABLE
+    nodes (
+        created_at timestamp,
+        updated_at timestamp,
+        id serial NOT NULL PRIMARY KEY,
+        uuid varchar(36) UNIQUE,
+        instance_uuid varchar(36) UNIQUE,
+        chassis_id integer,
+        power_state varchar(15),
+        target_power_state varchar(15),
+        provision_state varchar(15),
+        target_provision_state varchar(15),
+        last_error text,
+        properties text,
+        driver varchar(255),
+        driver_info text,
+        reservation varchar(255),
+        maintenance boolean,
+        extra text,
+        provision_updated_at timestamp,
+        console_enabled boolean,
+        instance_info text,
+        conductor_affinity integer,
+        maintenance_reason text,
+        driver_internal_info text,
+        name varchar(255) UNIQUE,
+        inspection_started_at timestamp,
+        inspection_finished_at timestamp,
+        clean_step text,
+        raid_config text,
+        target_raid_config text,
+        network_interface varchar(255),
+        resource_class varchar(80),
+        boot_interface varchar(255),
+        console_interface varchar(255),
+        deploy_interface varchar(255),
+        inspect_interface varchar(255),
+        management_interface varchar(255),
+        power_interface varchar(255),
+        raid_interface varchar(255),
+        vendor_interface varchar(255),
+        storage_interface varchar(255),
+        version varchar(15),
+        rescue_interface varchar(255),
+        bios_interface varchar(255),
+        fault varchar(255),
+        deploy_step text,
+        conductor_group varchar(255) NOT NULL DEFAULT '',
+        automated_clean boolean,
+        protected boolean NOT NULL DEFAULT false,
+        protected_reason text,
+        owner varchar(255),
+        allocation_id integer,
+        description text,
+        retired boolean DEFAULT false,
+        retired_reason text,
+        lessee varchar(255),
+        network_data text,
+        boot_mode varchar(16),
+        secure_boot boolean,
+        shard varchar(255),
+        parent_node varchar(36),
+        firmware_interface varchar(255),
+        service_step text
+    );
+
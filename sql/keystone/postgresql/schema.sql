@@ -0,0 +1,94 @@
+CREATE TABLE
+    project (
+        id varchar(64) NOT NULL,
+        name varchar(64) NOT NULL,
+        extra text,
+        description text,
+        enabled boolean,
+        domain_id varchar(64) NOT NULL,
+        parent_id varchar(64),
+        is_domain boolean NOT NULL DEFAULT false,
+        PRIMARY KEY (id),
+        CONSTRAINT ixu_project_name_domain_id UNIQUE (domain_id,name),
+        CONSTRAINT project_domain_id_fkey FOREIGN KEY (domain_id) REFERENCES project (id),
+        CONSTRAINT project_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES project (id)
+    );
+
+CREATE TABLE
+    region (
+        id varchar(255) NOT NULL,
+        description varchar(255) NOT NULL,
+        parent_region_id varchar(255),
+        extra text,
+        PRIMARY KEY (id)
+    );
+
+CREATE TABLE
+    project_tag (
+        project_id varchar(64) NOT NULL,
+        name varchar(255) NOT NULL,
+        PRIMARY KEY (project_id,name),
+        CONSTRAINT project_tag_ibfk_1 FOREIGN KEY (project_id) REFERENCES project (id) ON DELETE CASCADE
+    );
+
+CREATE TABLE
+    "user" (
+        id varchar(64) NOT NULL,
+        extra text,
+        enabled boolean,
+        default_project_id varchar(64),
+        created_at timestamp,
+        last_active_at date,
+        domain_id varchar(64) NOT NULL,
+        PRIMARY KEY (id),
+        CONSTRAINT ixu_user_id_domain_id UNIQUE (id,domain_id)
+    );
+
+CREATE TABLE
+    "group" (
+        id varchar(64) NOT NULL,
+        domain_id varchar(64) NOT NULL,
+        name varchar(64) NOT NULL,
+        description text,
+        extra text,
+        PRIMARY KEY (id),
+        CONSTRAINT ixu_group_name_domain_id UNIQUE (domain_id,name)
+    );
+
+
+CREATE TABLE
+    local_user (
+        id serial NOT NULL,
+        user_id varchar(64) NOT NULL,
+        domain_id varchar(64) NOT NULL,
+        name varchar(255) NOT NULL,
+        failed_auth_count integer,
+        failed_auth_at timestamp,
+        PRIMARY KEY (id),
+        CONSTRAINT local_user_user_id_key UNIQUE (user_id),
+        CONSTRAINT ixu_local_user_domain_id_name UNIQUE (domain_id,name),
+        CONSTRAINT local_user_user_id_fkey FOREIGN KEY (user_id,domain_id) REFERENCES "user" (id,domain_id) ON DELETE CASCADE ON UPDATE CASCADE
+    );
+
+CREATE TABLE
+    nonlocal_user (
+        domain_id varchar(64) NOT NULL,
+        name varchar(255) NOT NULL,
+        user_id varchar(64) NOT NULL,
+        PRIMARY KEY (domain_id,name),
+        CONSTRAINT ixu_nonlocal_user_user_id UNIQUE (user_id),
+        CONSTRAINT nonlocal_user_user_id_fkey FOREIGN KEY (user_id,domain_id) REFERENCES "user" (id,domain_id) ON DELETE CASCADE ON UPDATE CASCADE
+    );
+
+CREATE TABLE
+    federated_user (
+        id serial NOT NULL,
+        user_id varchar(64) NOT NULL,
+        idp_id varchar(64) NOT NULL,
+        protocol_id varchar(64) NOT NULL,
+        unique_id varchar(255) NOT NULL,
+        display_name varchar(255),
+        PRIMARY KEY (id),
+        CONSTRAINT federated_user_idp_id_unique_id_key UNIQUE (idp_id,unique_id),
+        CONSTRAINT federated_user_user_id_fkey FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE
+    );
@@ -45,4 +45,15 @@ SELECT
     domain_id,
     name,
     COALESCE(description, '') as description
-FROM `group`;
\ No newline at end of file
+FROM `group`;
+
+-- name: GetUserNames :many
+SELECT
+    u.id,
+    u.domain_id,
+    COALESCE(MIN(lu.name), MIN(nlu.name), MIN(fu.display_name), MIN(fu.unique_id), '') as name
+FROM user u
+LEFT JOIN local_user lu ON u.id = lu.user_id
+LEFT JOIN nonlocal_user nlu ON u.id = nlu.user_id
+LEFT JOIN federated_user fu ON u.id = fu.user_id
+GROUP BY u.id, u.domain_id;
\ No newline at end of file
@@ -57,3 +57,41 @@ CREATE TABLE
         PRIMARY KEY (`id`),
         UNIQUE KEY `ixu_group_name_domain_id` (`domain_id`,`name`)
     );
+
+CREATE TABLE
+    `local_user` (
+        `id` int NOT NULL AUTO_INCREMENT,
+        `user_id` varchar(64) NOT NULL,
+        `domain_id` varchar(64) NOT NULL,
+        `name` varchar(255) NOT NULL,
+        `failed_auth_count` int DEFAULT NULL,
+        `failed_auth_at` datetime DEFAULT NULL,
+        PRIMARY KEY (`id`),
+        UNIQUE KEY `user_id` (`user_id`),
+        UNIQUE KEY `ixu_local_user_domain_id_name` (`domain_id`,`name`),
+        CONSTRAINT `local_user_user_id_fkey` FOREIGN KEY (`user_id`, `domain_id`) REFERENCES `user` (`id`, `domain_id`) ON DELETE CASCADE ON UPDATE CASCADE
+    );
+
+CREATE TABLE
+    `nonlocal_user` (
+        `domain_id` varchar(64) NOT NULL,
+        `name` varchar(255) NOT NULL,
+        `user_id` varchar(64) NOT NULL,
+        PRIMARY KEY (`domain_id`,`name`),
+        UNIQUE KEY `ixu_nonlocal_user_user_id` (`user_id`),
+        CONSTRAINT `nonlocal_user_user_id_fkey` FOREIGN KEY (`user_id`, `domain_id`) REFERENCES `user` (`id`, `domain_id`) ON DELETE CASCADE ON UPDATE CASCADE
+    );
+
+CREATE TABLE
+    `federated_user` (
+        `id` int NOT NULL AUTO_INCREMENT,
+        `user_id` varchar(64) NOT NULL,
+        `idp_id` varchar(64) NOT NULL,
+        `protocol_id` varchar(64) NOT NULL,
+        `unique_id` varchar(255) NOT NULL,
+        `display_name` varchar(255) DEFAULT NULL,
+        PRIMARY KEY (`id`),
+        UNIQUE KEY `idp_id` (`idp_id`,`unique_id`),
+        KEY `federated_user_user_id_fkey` (`user_id`),
+        CONSTRAINT `federated_user_user_id_fkey` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE
+    );
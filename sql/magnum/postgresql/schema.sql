@@ -0,0 +1,69 @@
+CREATE TABLE
+    cluster (
+        created_at timestamp,
+        updated_at timestamp,
+        id serial NOT NULL,
+        uuid varchar(36),
+        name varchar(255),
+        cluster_template_id varchar(255),
+        api_address varchar(255),
+        stack_id varchar(255),
+        project_id varchar(255),
+        user_id varchar(255),
+        status varchar(20),
+        discovery_url varchar(255),
+        status_reason text,
+        ca_cert_ref varchar(512),
+        magnum_cert_ref varchar(512),
+        create_timeout integer,
+        trust_id varchar(255),
+        trustee_username varchar(255),
+        trustee_user_id varchar(255),
+        trustee_password varchar(255),
+        coe_version varchar(255),
+        container_version varchar(255),
+        keypair varchar(255),
+        docker_volume_size integer,
+        labels text,
+        master_flavor_id varchar(255),
+        flavor_id varchar(255),
+        health_status varchar(20),
+        health_status_reason text,
+        fixed_network varchar(255),
+        fixed_subnet varchar(255),
+        floating_ip_enabled boolean,
+        master_lb_enabled boolean,
+        etcd_ca_cert_ref varchar(512),
+        front_proxy_ca_cert_ref varchar(512),
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_bay0uuid UNIQUE (uuid)
+);
+
+CREATE TABLE
+    nodegroup (
+        created_at timestamp,
+        updated_at timestamp,
+        id serial NOT NULL,
+        uuid varchar(36) NOT NULL,
+        name varchar(255) NOT NULL,
+        cluster_id varchar(255) NOT NULL,
+        project_id varchar(255) NOT NULL,
+        docker_volume_size integer,
+        labels text,
+        flavor_id varchar(255),
+        image_id varchar(255),
+        node_addresses text,
+        node_count integer,
+        max_node_count integer,
+        min_node_count integer,
+        role varchar(255),
+        is_default boolean,
+        stack_id varchar(255),
+        status varchar(20),
+        status_reason text,
+        version varchar(20),
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_nodegroup0uuid UNIQUE (uuid),
+        CONSTRAINT uniq_nodegroup0cluster_id0name UNIQUE (cluster_id,name)
+);
+
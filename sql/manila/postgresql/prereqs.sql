@@ -0,0 +1,9 @@
+-- Manila schema prerequisites: stub tables referenced by foreign keys
+-- but not needed by our queries.
+CREATE TABLE IF NOT EXISTS share_groups (
+    id varchar(36) NOT NULL,
+    created_at timestamp,
+    deleted varchar(36),
+    PRIMARY KEY (id)
+);
+
@@ -0,0 +1,84 @@
+CREATE TABLE
+    shares (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted varchar(36),
+        id varchar(36) NOT NULL,
+        user_id varchar(255),
+        project_id varchar(255),
+        size integer,
+        display_name varchar(255),
+        display_description varchar(255),
+        snapshot_id varchar(36),
+        share_proto varchar(255),
+        is_public boolean,
+        snapshot_support boolean,
+        share_group_id varchar(36),
+        source_share_group_snapshot_member_id varchar(36),
+        task_state varchar(255),
+        replication_type varchar(255),
+        create_share_from_snapshot_support boolean,
+        revert_to_snapshot_support boolean,
+        mount_snapshot_support boolean,
+        is_soft_deleted boolean NOT NULL DEFAULT false,
+        scheduled_to_be_deleted_at timestamp,
+        source_backup_id varchar(36),
+        PRIMARY KEY (id),
+        CONSTRAINT fk_shares_share_group_id FOREIGN KEY (share_group_id) REFERENCES share_groups (id)
+    );
+
+CREATE TABLE
+    share_types (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted varchar(36),
+        id varchar(36) NOT NULL,
+        name varchar(255),
+        is_public boolean,
+        description varchar(255),
+        PRIMARY KEY (id),
+        CONSTRAINT st_name_uc UNIQUE (name,deleted)
+    );
+
+CREATE TABLE
+    availability_zones (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted varchar(36),
+        id varchar(36) NOT NULL,
+        name varchar(255),
+        PRIMARY KEY (id),
+        CONSTRAINT az_name_uc UNIQUE (name,deleted)
+    );
+
+CREATE TABLE
+    share_instances (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        deleted varchar(36),
+        id varchar(36) NOT NULL,
+        share_id varchar(36),
+        host varchar(255),
+        status varchar(255),
+        scheduled_at timestamp,
+        launched_at timestamp,
+        terminated_at timestamp,
+        share_network_id varchar(36),
+        share_server_id varchar(36),
+        availability_zone_id varchar(36),
+        access_rules_status varchar(255),
+        replica_state varchar(255),
+        share_type_id varchar(36),
+        cast_rules_to_readonly boolean NOT NULL,
+        progress varchar(32),
+        mount_point_name varchar(255),
+        PRIMARY KEY (id),
+        CONSTRAINT si_az_id_fk FOREIGN KEY (availability_zone_id) REFERENCES availability_zones (id),
+        CONSTRAINT si_share_fk FOREIGN KEY (share_id) REFERENCES shares (id),
+        CONSTRAINT si_st_id_fk FOREIGN KEY (share_type_id) REFERENCES share_types (id)
+    );
+
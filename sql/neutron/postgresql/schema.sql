@@ -0,0 +1,249 @@
+CREATE TYPE l3_ha_states AS ENUM ('active', 'standby', 'unknown');
+
+CREATE TYPE ipv6_ra_modes AS ENUM ('slaac', 'dhcpv6-stateful', 'dhcpv6-stateless');
+
+CREATE TYPE ipv6_address_modes AS ENUM ('slaac', 'dhcpv6-stateful', 'dhcpv6-stateless');
+
+CREATE TYPE securitygrouprules_direction AS ENUM ('ingress', 'egress');
+
+CREATE TABLE
+    agents (
+        id varchar(36) NOT NULL PRIMARY KEY,
+        agent_type varchar(255) NOT NULL,
+        "binary" varchar(255) NOT NULL,
+        topic varchar(255) NOT NULL,
+        host varchar(255) NOT NULL,
+        admin_state_up boolean NOT NULL DEFAULT true,
+        created_at timestamp NOT NULL,
+        started_at timestamp NOT NULL,
+        heartbeat_timestamp timestamp NOT NULL,
+        description varchar(255),
+        configurations varchar(4095) NOT NULL,
+        load integer NOT NULL DEFAULT 0,
+        availability_zone varchar(255),
+        resource_versions varchar(8191),
+        resources_synced boolean,
+        CONSTRAINT uniq_agents0agent_type0host UNIQUE (agent_type, host)
+    );
+
+CREATE TABLE
+    ha_router_agent_port_bindings (
+        port_id varchar(36) NOT NULL PRIMARY KEY,
+        router_id varchar(36) NOT NULL,
+        l3_agent_id varchar(36),
+        state l3_ha_states DEFAULT 'standby'
+    );
+
+CREATE TABLE
+    routers (
+        project_id varchar(255),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        name varchar(255),
+        status varchar(16),
+        admin_state_up boolean,
+        gw_port_id varchar(36),
+        enable_snat boolean NOT NULL DEFAULT true,
+        standard_attr_id bigint NOT NULL,
+        flavor_id varchar(36)
+    );
+
+CREATE TABLE
+    floatingips (
+        project_id varchar(255),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        floating_ip_address varchar(64) NOT NULL,
+        floating_network_id varchar(36) NOT NULL,
+        floating_port_id varchar(36) NOT NULL,
+        fixed_port_id varchar(36),
+        fixed_ip_address varchar(64),
+        router_id varchar(36),
+        last_known_router_id varchar(36),
+        status varchar(16),
+        standard_attr_id bigint NOT NULL
+    );
+
+CREATE TABLE
+    networks (
+        project_id varchar(255),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        name varchar(255),
+        status varchar(16),
+        admin_state_up boolean,
+        vlan_transparent boolean,
+        standard_attr_id bigint NOT NULL,
+        availability_zone_hints varchar(255),
+        mtu integer NOT NULL DEFAULT 1500
+    );
+
+CREATE TABLE
+    networksegments (
+        id varchar(36) NOT NULL PRIMARY KEY,
+        network_id varchar(36) NOT NULL,
+        network_type varchar(32) NOT NULL,
+        physical_network varchar(64),
+        segmentation_id integer,
+        is_dynamic boolean NOT NULL DEFAULT false,
+        segment_index integer NOT NULL DEFAULT 0,
+        standard_attr_id bigint NOT NULL,
+        name varchar(255)
+    );
+
+CREATE TABLE
+    subnets (
+        project_id varchar(255),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        name varchar(255),
+        network_id varchar(36) NOT NULL,
+        ip_version integer NOT NULL,
+        cidr varchar(64) NOT NULL,
+        gateway_ip varchar(64),
+        enable_dhcp boolean,
+        ipv6_ra_mode ipv6_ra_modes,
+        ipv6_address_mode ipv6_address_modes,
+        subnetpool_id varchar(36),
+        standard_attr_id bigint NOT NULL,
+        segment_id varchar(36)
+    );
+
+CREATE TABLE
+    externalnetworks (
+        network_id varchar(36) NOT NULL PRIMARY KEY,
+        is_default boolean NOT NULL DEFAULT false
+    );
+
+CREATE TABLE
+    ml2_port_bindings (
+        port_id varchar(36) NOT NULL,
+        host varchar(255) NOT NULL DEFAULT '',
+        vif_type varchar(64) NOT NULL,
+        vnic_type varchar(64) NOT NULL DEFAULT 'normal',
+        profile varchar(4095) NOT NULL DEFAULT '',
+        vif_details varchar(4095) NOT NULL DEFAULT '',
+        status varchar(16) NOT NULL DEFAULT 'ACTIVE',
+        PRIMARY KEY (port_id, host)
+    );
+
+CREATE TABLE
+    ports (
+        project_id varchar(255),
+        id varchar(36) NOT NULL,
+        name varchar(255),
+        network_id varchar(36) NOT NULL,
+        mac_address varchar(32) NOT NULL,
+        admin_state_up boolean NOT NULL,
+        status varchar(16) NOT NULL,
+        device_id varchar(255) NOT NULL,
+        device_owner varchar(255) NOT NULL,
+        standard_attr_id bigint NOT NULL,
+        ip_allocation varchar(16)
+    );
+
+CREATE TABLE
+    securitygroups (
+        project_id varchar(255),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        name varchar(255),
+        standard_attr_id bigint NOT NULL,
+        stateful boolean NOT NULL DEFAULT true
+    );
+
+CREATE TABLE
+    securitygrouprules (
+        project_id varchar(255),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        security_group_id varchar(36) NOT NULL,
+        remote_group_id varchar(36),
+        direction securitygrouprules_direction,
+        ethertype varchar(40),
+        protocol varchar(40),
+        port_range_min integer,
+        port_range_max integer,
+        remote_ip_prefix varchar(255),
+        standard_attr_id bigint NOT NULL,
+        remote_address_group_id varchar(36),
+        normalized_cidr varchar(255)
+    );
+
+CREATE TABLE
+    dnsnameservers (
+        address varchar(128) NOT NULL,
+        subnet_id varchar(36) NOT NULL,
+        "order" integer NOT NULL DEFAULT 0,
+        PRIMARY KEY (address,subnet_id)
+    );
+
+CREATE TABLE
+    ipallocations (
+        port_id varchar(36),
+        ip_address varchar(64) NOT NULL,
+        subnet_id varchar(36) NOT NULL,
+        network_id varchar(36) NOT NULL,
+        PRIMARY KEY (ip_address,subnet_id,network_id)
+    );
+
+CREATE TABLE
+    networkrbacs (
+        id varchar(36) NOT NULL PRIMARY KEY,
+        object_id varchar(36) NOT NULL,
+        project_id varchar(255),
+        target_project varchar(255) NOT NULL,
+        action varchar(255) NOT NULL
+    );
+
+CREATE TABLE
+    subnetpools (
+        project_id varchar(255),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        name varchar(255),
+        ip_version integer NOT NULL,
+        default_prefixlen integer NOT NULL,
+        min_prefixlen integer NOT NULL,
+        max_prefixlen integer NOT NULL,
+        shared boolean NOT NULL DEFAULT false,
+        default_quota integer,
+        hash varchar(36) NOT NULL DEFAULT '',
+        address_scope_id varchar(36),
+        is_default boolean NOT NULL DEFAULT false,
+        standard_attr_id bigint NOT NULL
+    );
+
+CREATE TABLE
+    subnetpoolprefixes (
+        cidr varchar(64) NOT NULL,
+        subnetpool_id varchar(36) NOT NULL,
+        PRIMARY KEY (cidr,subnetpool_id)
+    );
+
+CREATE TABLE
+    ipallocationpools (
+        id varchar(36) NOT NULL PRIMARY KEY,
+        subnet_id varchar(36),
+        first_ip varchar(64) NOT NULL,
+        last_ip varchar(64) NOT NULL
+    );
+
+CREATE TABLE
+    quotas (
+        id varchar(36) NOT NULL PRIMARY KEY,
+        project_id varchar(255),
+        resource varchar(255),
+        "limit" integer
+    );
+
+CREATE TABLE
+    standardattributes (
+        id bigserial NOT NULL PRIMARY KEY,
+        resource_type varchar(255) NOT NULL,
+        description varchar(255),
+        revision_number bigint NOT NULL DEFAULT 0,
+        created_at timestamp NOT NULL,
+        updated_at timestamp
+    );
+
+CREATE TABLE
+    tags (
+        standard_attr_id bigint NOT NULL,
+        tag varchar(255) NOT NULL,
+        PRIMARY KEY (standard_attr_id, tag)
+    );
+
@@ -120,6 +120,7 @@ GROUP BY
 -- name: GetPorts :many
 SELECT
     p.id,
+    p.project_id,
     p.mac_address,
     p.device_owner,
     p.status,
@@ -134,6 +135,7 @@ FROM
     LEFT JOIN ipallocations ia ON p.id = ia.port_id
 GROUP BY
     p.id,
+    p.project_id,
     p.mac_address,
     p.device_owner,
     p.status,
@@ -274,3 +276,18 @@ SELECT
     'subnetpool' as resource,
     CAST(COUNT(*) AS SIGNED) as cnt
 FROM subnetpools WHERE project_id IS NOT NULL GROUP BY project_id;
+
+-- name: GetFloatingIPAggregates :many
+SELECT
+    fip.project_id,
+    fip.floating_network_id,
+    fip.status,
+    (fip.router_id IS NOT NULL AND fip.router_id <> '') as associated,
+    CAST(COUNT(*) AS SIGNED) as cnt
+FROM
+    floatingips fip
+GROUP BY
+    fip.project_id,
+    fip.floating_network_id,
+    fip.status,
+    associated;
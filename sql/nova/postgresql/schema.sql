@@ -0,0 +1,129 @@
+-- Nova compute service database schema
+-- This schema contains instances, compute nodes, and services tables
+
+CREATE TYPE instances0locked_by AS ENUM ('owner', 'admin');
+
+CREATE TABLE IF NOT EXISTS
+    instances (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        id serial NOT NULL,
+        internal_id integer,
+        user_id varchar(255),
+        project_id varchar(255),
+        image_ref varchar(255),
+        kernel_id varchar(255),
+        ramdisk_id varchar(255),
+        launch_index integer,
+        key_name varchar(255),
+        key_data text,
+        power_state integer,
+        vm_state varchar(255),
+        memory_mb integer,
+        vcpus integer,
+        hostname varchar(255),
+        host varchar(255),
+        user_data text,
+        reservation_id varchar(255),
+        launched_at timestamp,
+        terminated_at timestamp,
+        display_name varchar(255),
+        display_description varchar(255),
+        availability_zone varchar(255),
+        locked boolean,
+        os_type varchar(255),
+        launched_on text,
+        instance_type_id integer,
+        vm_mode varchar(255),
+        uuid varchar(36) NOT NULL,
+        architecture varchar(255),
+        root_device_name varchar(255),
+        access_ip_v4 varchar(39),
+        access_ip_v6 varchar(39),
+        config_drive varchar(255),
+        task_state varchar(255),
+        default_ephemeral_device varchar(255),
+        default_swap_device varchar(255),
+        progress integer,
+        auto_disk_config boolean,
+        shutdown_terminate boolean,
+        disable_terminate boolean,
+        root_gb integer,
+        ephemeral_gb integer,
+        cell_name varchar(255),
+        node varchar(255),
+        deleted integer,
+        locked_by instances0locked_by,
+        cleaned integer,
+        ephemeral_key_uuid varchar(36),
+        hidden boolean,
+        compute_id bigint,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_instances0uuid UNIQUE (uuid)
+    );
+
+CREATE TABLE IF NOT EXISTS
+    services (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        id serial NOT NULL,
+        host varchar(255),
+        "binary" varchar(255),
+        topic varchar(255),
+        report_count integer NOT NULL,
+        disabled boolean,
+        deleted integer,
+        disabled_reason varchar(255),
+        last_seen_up timestamp,
+        forced_down boolean,
+        version integer,
+        uuid varchar(36),
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_services0host0topic0deleted UNIQUE (host, topic, deleted),
+        CONSTRAINT uniq_services0host0binary0deleted UNIQUE (host, "binary", deleted),
+        CONSTRAINT services_uuid_idx UNIQUE (uuid)
+    );
+
+CREATE TABLE IF NOT EXISTS
+    compute_nodes (
+        created_at timestamp,
+        updated_at timestamp,
+        deleted_at timestamp,
+        id serial NOT NULL,
+        service_id integer,
+        vcpus integer NOT NULL,
+        memory_mb integer NOT NULL,
+        local_gb integer NOT NULL,
+        vcpus_used integer NOT NULL,
+        memory_mb_used integer NOT NULL,
+        local_gb_used integer NOT NULL,
+        hypervisor_type text NOT NULL,
+        hypervisor_version integer NOT NULL,
+        cpu_info text NOT NULL,
+        disk_available_least integer,
+        free_ram_mb integer,
+        free_disk_gb integer,
+        current_workload integer,
+        running_vms integer,
+        hypervisor_hostname varchar(255),
+        deleted integer,
+        host_ip varchar(39),
+        supported_instances text,
+        pci_stats text,
+        metrics text,
+        extra_resources text,
+        stats text,
+        numa_topology text,
+        host varchar(255),
+        ram_allocation_ratio double precision,
+        cpu_allocation_ratio double precision,
+        uuid varchar(36),
+        disk_allocation_ratio double precision,
+        mapped integer,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_compute_nodes0host0hypervisor_hostname0deleted UNIQUE (host, hypervisor_hostname, deleted),
+        CONSTRAINT compute_nodes_uuid_idx UNIQUE (uuid)
+    );
+
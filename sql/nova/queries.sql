@@ -65,3 +65,16 @@ SELECT
     deleted
 FROM compute_nodes
 WHERE deleted = 0;
+
+-- name: GetInstanceAggregates :many
+SELECT
+    project_id,
+    availability_zone,
+    instance_type_id,
+    vm_state,
+    task_state,
+    COUNT(*) as count,
+    CAST(COALESCE(SUM(root_gb), 0) AS SIGNED) as root_gb
+FROM instances
+WHERE deleted = 0
+GROUP BY project_id, availability_zone, instance_type_id, vm_state, task_state;
@@ -0,0 +1,87 @@
+-- Nova API database schema  
+-- This schema contains flavors, quotas, and aggregates tables
+
+CREATE TABLE IF NOT EXISTS
+    flavors (
+        created_at timestamp,
+        updated_at timestamp,
+        name varchar(255) NOT NULL,
+        id serial NOT NULL,
+        memory_mb integer NOT NULL,
+        vcpus integer NOT NULL,
+        swap integer NOT NULL,
+        vcpu_weight integer,
+        flavorid varchar(255) NOT NULL,
+        rxtx_factor double precision,
+        root_gb integer,
+        ephemeral_gb integer,
+        disabled boolean,
+        is_public boolean,
+        description text,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_flavors0flavorid UNIQUE (flavorid),
+        CONSTRAINT uniq_flavors0name UNIQUE (name)
+    );
+
+CREATE TABLE IF NOT EXISTS
+    quotas (
+        id serial NOT NULL,
+        created_at timestamp,
+        updated_at timestamp,
+        project_id varchar(255),
+        resource varchar(255) NOT NULL,
+        hard_limit integer,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_quotas0project_id0resource UNIQUE (project_id, resource)
+    );
+
+CREATE TABLE IF NOT EXISTS
+    aggregates (
+        created_at timestamp,
+        updated_at timestamp,
+        id serial NOT NULL,
+        uuid varchar(36),
+        name varchar(255),
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_aggregate0name UNIQUE (name)
+    );
+
+CREATE TABLE IF NOT EXISTS
+    aggregate_hosts (
+        created_at timestamp,
+        updated_at timestamp,
+        id serial NOT NULL,
+        host varchar(255),
+        aggregate_id integer NOT NULL,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_aggregate_hosts0host0aggregate_id UNIQUE (host, aggregate_id),
+        CONSTRAINT aggregate_hosts_ibfk_1 FOREIGN KEY (aggregate_id) REFERENCES aggregates (id)
+    );
+
+CREATE TABLE IF NOT EXISTS
+    quota_classes (
+        created_at timestamp,
+        updated_at timestamp,
+        id serial NOT NULL,
+        class_name varchar(255),
+        resource varchar(255),
+        hard_limit integer,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_quota_classes0class_name0resource UNIQUE (class_name, resource)
+    );
+
+CREATE TABLE IF NOT EXISTS
+    quota_usages (
+        created_at timestamp,
+        updated_at timestamp,
+        id serial NOT NULL,
+        project_id varchar(255),
+        user_id varchar(255),
+        resource varchar(255) NOT NULL,
+        in_use integer NOT NULL,
+        reserved integer NOT NULL,
+        until_refresh integer,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_quota_usages0project_id0user_id0resource UNIQUE (project_id, user_id, resource)
+    );
+
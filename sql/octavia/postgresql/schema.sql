@@ -0,0 +1,77 @@
+CREATE TABLE
+    amphora (
+        id varchar(36) NOT NULL PRIMARY KEY,
+        compute_id varchar(36),
+        status varchar(36) NOT NULL,
+        load_balancer_id varchar(36),
+        lb_network_ip varchar(64),
+        vrrp_ip varchar(64),
+        ha_ip varchar(64),
+        vrrp_port_id varchar(36),
+        ha_port_id varchar(36),
+        role varchar(36),
+        cert_expiration timestamp,
+        cert_busy boolean NOT NULL,
+        vrrp_interface varchar(16),
+        vrrp_id integer,
+        vrrp_priority integer,
+        cached_zone varchar(255),
+        created_at timestamp,
+        updated_at timestamp,
+        image_id varchar(36),
+        compute_flavor varchar(255)
+    );
+
+CREATE TABLE
+    load_balancer (
+        project_id varchar(36),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        name varchar(255),
+        description varchar(255),
+        provisioning_status varchar(16) NOT NULL,
+        operating_status varchar(16) NOT NULL,
+        enabled boolean NOT NULL,
+        topology varchar(36),
+        server_group_id varchar(36),
+        created_at timestamp,
+        updated_at timestamp,
+        provider varchar(64),
+        flavor_id varchar(36),
+        availability_zone varchar(255)
+    );
+
+CREATE TABLE
+    pool (
+        project_id varchar(36),
+        id varchar(36) NOT NULL PRIMARY KEY,
+        name varchar(255),
+        description varchar(255),
+        protocol varchar(16) NOT NULL,
+        lb_algorithm varchar(255) NOT NULL,
+        operating_status varchar(16) NOT NULL,
+        enabled boolean NOT NULL,
+        load_balancer_id varchar(36),
+        created_at timestamp,
+        updated_at timestamp,
+        provisioning_status varchar(16) NOT NULL,
+        tls_certificate_id varchar(255),
+        ca_tls_certificate_id varchar(255),
+        crl_container_id varchar(255),
+        tls_enabled boolean NOT NULL DEFAULT false,
+        tls_ciphers varchar(2048),
+        tls_versions varchar(512),
+        alpn_protocols varchar(512)
+    );
+
+CREATE TABLE
+    vip (
+        load_balancer_id varchar(36) NOT NULL PRIMARY KEY,
+        ip_address varchar(64),
+        port_id varchar(36),
+        subnet_id varchar(36),
+        network_id varchar(36),
+        qos_policy_id varchar(36),
+        octavia_owned boolean,
+        vnic_type varchar(64) NOT NULL DEFAULT 'normal'
+    );
+
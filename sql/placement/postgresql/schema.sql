@@ -0,0 +1,92 @@
+CREATE TABLE
+    resource_providers (
+        id serial NOT NULL,
+        uuid varchar(36) NOT NULL,
+        name varchar(200),
+        generation integer,
+        created_at timestamp,
+        updated_at timestamp,
+        root_provider_id integer,
+        parent_provider_id integer,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_resource_providers0uuid UNIQUE (uuid),
+        CONSTRAINT uniq_resource_providers0name UNIQUE (name),
+        CONSTRAINT resource_providers_ibfk_1 FOREIGN KEY (parent_provider_id) REFERENCES resource_providers (id),
+        CONSTRAINT resource_providers_ibfk_2 FOREIGN KEY (root_provider_id) REFERENCES resource_providers (id)
+    );
+
+CREATE TABLE
+    resource_classes (
+        id serial NOT NULL,
+        name varchar(255) NOT NULL,
+        created_at timestamp,
+        updated_at timestamp,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_resource_classes0name UNIQUE (name)
+    );
+
+CREATE TABLE
+    allocations (
+        id serial NOT NULL,
+        resource_provider_id integer NOT NULL,
+        consumer_id varchar(36) NOT NULL,
+        resource_class_id integer NOT NULL,
+        used integer NOT NULL,
+        created_at timestamp,
+        updated_at timestamp,
+        PRIMARY KEY (id)
+    );
+
+CREATE TABLE
+    inventories (
+        id serial NOT NULL,
+        resource_provider_id integer NOT NULL,
+        resource_class_id integer NOT NULL,
+        total integer NOT NULL,
+        reserved integer NOT NULL,
+        min_unit integer NOT NULL,
+        max_unit integer NOT NULL,
+        step_size integer NOT NULL,
+        allocation_ratio double precision NOT NULL,
+        created_at timestamp,
+        updated_at timestamp,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_inventories0resource_provider_resource_class UNIQUE (resource_provider_id,resource_class_id)
+    );
+
+CREATE TABLE
+    projects (
+        id serial NOT NULL,
+        external_id varchar(255) NOT NULL,
+        created_at timestamp,
+        updated_at timestamp,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_projects0external_id UNIQUE (external_id)
+    );
+
+CREATE TABLE
+    users (
+        id serial NOT NULL,
+        external_id varchar(255) NOT NULL,
+        created_at timestamp,
+        updated_at timestamp,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_users0external_id UNIQUE (external_id)
+    );
+
+CREATE TABLE
+    consumers (
+        id serial NOT NULL,
+        uuid varchar(36) NOT NULL,
+        project_id integer NOT NULL,
+        user_id integer NOT NULL,
+        generation integer NOT NULL DEFAULT 0,
+        consumer_type_id integer,
+        created_at timestamp,
+        updated_at timestamp,
+        PRIMARY KEY (id),
+        CONSTRAINT uniq_consumers0uuid UNIQUE (uuid),
+        CONSTRAINT consumers_ibfk_1 FOREIGN KEY (project_id) REFERENCES projects (id),
+        CONSTRAINT consumers_ibfk_2 FOREIGN KEY (user_id) REFERENCES users (id)
+    );
+
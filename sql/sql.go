@@ -0,0 +1,13 @@
+// Package sql embeds the queries that are not compiled into the sqlc packages
+// under internal/db.
+package sql
+
+import "embed"
+
+// PostgreSQL holds <schema>/postgresql/queries.sql of every schema. The sqlc
+// packages are generated from the MySQL queries; each PostgreSQL query
+// returns the same columns under the same sqlc name, so that it is scanned by
+// the generated code unchanged.
+//
+//go:embed */postgresql/queries.sql
+var PostgreSQL embed.FS